@@ -0,0 +1,55 @@
+// backfill-media-meta 为上传时还没有探测元数据的已有视频和音频补充MediaMeta，只需执行一次：
+//
+//	go run ./cmd/backfill-media-meta
+//
+// 已有元数据的媒体会被跳过，因此中断后可以重复执行
+package main
+
+import (
+	"betalyr-learning-server/internal/config"
+	"betalyr-learning-server/internal/database"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/repository"
+	"betalyr-learning-server/internal/service"
+	"betalyr-learning-server/internal/storage"
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+func main() {
+	// 加载.env文件，不存在时使用环境变量
+	_ = godotenv.Load()
+
+	// 初始化日志和配置
+	logger.InitLogger("development")
+	cfg := config.NewConfig()
+
+	// 初始化数据库连接
+	if err := database.Initialize(cfg); err != nil {
+		fmt.Printf("数据库初始化失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 初始化R2对象存储，补充元数据需要下载原文件
+	if err := storage.InitializeR2(cfg); err != nil {
+		fmt.Printf("R2初始化失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	processingService := service.NewMediaProcessingService(cfg, repository.NewMediaRepository(), repository.NewMediaJobRepository())
+	updated, failed, err := processingService.BackfillMeta()
+	if err != nil {
+		logger.Fatal("Media meta backfill failed", zap.Error(err), zap.Int("updated", updated), zap.Int("failed", failed))
+	}
+
+	logger.Info("Media meta backfill finished", zap.Int("updated", updated), zap.Int("failed", failed))
+	if err := logger.Log.Sync(); err != nil {
+		fmt.Printf("failed to sync logger: %v\n", err)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
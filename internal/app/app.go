@@ -4,8 +4,11 @@ import (
 	"betalyr-learning-server/internal/config"
 	"betalyr-learning-server/internal/database"
 	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/auth"
 	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/repository"
 	"betalyr-learning-server/internal/router"
+	"betalyr-learning-server/internal/service"
 	"betalyr-learning-server/internal/storage"
 	"fmt"
 	"os"
@@ -60,6 +63,27 @@ func (a *App) Initialize() error {
 	}
 	logger.Info("database migrated")
 
+	// 为尚未建立搜索索引的文档补充搜索文本
+	if count, err := repository.NewDocumentRepository().RebuildSearchIndex(); err != nil {
+		logger.Warn("failed to rebuild document search index", zap.Error(err))
+	} else if count > 0 {
+		logger.Info("document search index rebuilt", zap.Int64("count", count))
+	}
+
+	// 为标签持久化之前保存的文档补充标签
+	if count, err := repository.NewDocumentRepository().BackfillTags(); err != nil {
+		logger.Warn("failed to backfill document tags", zap.Error(err))
+	} else if count > 0 {
+		logger.Info("document tags backfilled", zap.Int64("count", count))
+	}
+
+	// 为短名称功能上线前已公开的文档生成短名称
+	if count, err := service.BackfillDocSlugs(repository.NewDocumentRepository()); err != nil {
+		logger.Warn("failed to backfill document slugs", zap.Error(err))
+	} else if count > 0 {
+		logger.Info("document slugs backfilled", zap.Int64("count", count))
+	}
+
 	// 初始化R2对象存储 (如果配置了R2)
 	if a.Config.R2.Endpoint != "" {
 		if err := storage.InitializeR2(a.Config); err != nil {
@@ -71,6 +95,13 @@ func (a *App) Initialize() error {
 		logger.Warn("R2 object storage not configured, media storage will not be available")
 	}
 
+	// 初始化JWT验证器 (如果配置了签发者和受众)
+	if err := auth.InitializeVerifier(a.Config); err != nil {
+		logger.Warn("JWT verifier initialization failed, Authorization tokens will be rejected", zap.Error(err))
+	} else {
+		logger.Info("JWT verifier initialized")
+	}
+
 	// 初始化路由器
 	a.Router = router.SetupRouter(a.Config)
 	logger.Info("router initialized")
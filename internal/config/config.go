@@ -14,6 +14,12 @@ type Config struct {
 	Server     ServerConfig     `yaml:"server"`
 	Cloudinary CloudinaryConfig `yaml:"cloudinary"`
 	R2         R2Config         `yaml:"r2"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Export     ExportConfig     `yaml:"export"`
+	Trash      TrashConfig      `yaml:"trash"`
+	Site       SiteConfig       `yaml:"site"`
+	Media      MediaConfig      `yaml:"media"`
+	Engagement EngagementConfig `yaml:"engagement"`
 }
 
 // DBConfig 数据库配置
@@ -48,6 +54,45 @@ type R2Config struct {
 	PublicURL       string `yaml:"public_url"`
 }
 
+// AuthConfig JWT身份验证配置 (Firebase)
+type AuthConfig struct {
+	JWKSURL  string `yaml:"jwks_url"`  // 公钥集(JWKS)地址
+	JWKSFile string `yaml:"jwks_file"` // 本地公钥集文件，设置后优先于jwks_url
+	Issuer   string `yaml:"issuer"`    // 期望的iss，如 https://securetoken.google.com/<project-id>
+	Audience string `yaml:"audience"`  // 期望的aud，Firebase中为项目ID
+}
+
+// ExportConfig 文档导出配置
+type ExportConfig struct {
+	PDFFont string `yaml:"pdf_font"` // 导出PDF使用的UTF-8 TrueType字体(.ttf)路径，需要包含中文字形
+}
+
+// TrashConfig 回收站配置
+type TrashConfig struct {
+	RetentionDays string `yaml:"retention_days"` // 文档在回收站中保留的天数，超过后自动永久删除，0表示不自动删除
+}
+
+// SiteConfig 前端站点配置，用于生成订阅源和站点地图中的链接
+type SiteConfig struct {
+	URL          string `yaml:"url"`           // 前端站点地址，如 https://example.com
+	Title        string `yaml:"title"`         // 订阅源标题
+	Description  string `yaml:"description"`   // 订阅源描述
+	DocumentPath string `yaml:"document_path"` // 文档页面路径，:slug替换为短名称（没有时为ID），:id替换为ID
+	VideoPath    string `yaml:"video_path"`    // 视频页面路径，:id替换为媒体ID
+	AudioPath    string `yaml:"audio_path"`    // 音频页面路径，:id替换为媒体ID
+}
+
+// MediaConfig 媒体后台处理配置
+type MediaConfig struct {
+	Workers     string `yaml:"workers"`      // 并发处理媒体任务的数量
+	MaxAttempts string `yaml:"max_attempts"` // 处理任务失败后的最大尝试次数
+}
+
+// EngagementConfig 点赞、收藏和浏览配置
+type EngagementConfig struct {
+	ViewHashSecret string `yaml:"view_hash_secret"` // 浏览去重时对访客IP做HMAC的密钥，为空时每次启动随机生成
+}
+
 // expandEnvVars 展开环境变量
 func expandEnvVars(value string) string {
 	// 找到格式为 ${VAR:-default} 的模式
@@ -102,6 +147,33 @@ func processConfig(cfg *Config) {
 	cfg.R2.SecretAccessKey = expandEnvVars(cfg.R2.SecretAccessKey)
 	cfg.R2.Bucket = expandEnvVars(cfg.R2.Bucket)
 	cfg.R2.PublicURL = expandEnvVars(cfg.R2.PublicURL)
+
+	// 处理身份验证配置
+	cfg.Auth.JWKSURL = expandEnvVars(cfg.Auth.JWKSURL)
+	cfg.Auth.JWKSFile = expandEnvVars(cfg.Auth.JWKSFile)
+	cfg.Auth.Issuer = expandEnvVars(cfg.Auth.Issuer)
+	cfg.Auth.Audience = expandEnvVars(cfg.Auth.Audience)
+
+	// 处理导出配置
+	cfg.Export.PDFFont = expandEnvVars(cfg.Export.PDFFont)
+
+	// 处理回收站配置
+	cfg.Trash.RetentionDays = expandEnvVars(cfg.Trash.RetentionDays)
+
+	// 处理站点配置
+	cfg.Site.URL = expandEnvVars(cfg.Site.URL)
+	cfg.Site.Title = expandEnvVars(cfg.Site.Title)
+	cfg.Site.Description = expandEnvVars(cfg.Site.Description)
+	cfg.Site.DocumentPath = expandEnvVars(cfg.Site.DocumentPath)
+	cfg.Site.VideoPath = expandEnvVars(cfg.Site.VideoPath)
+	cfg.Site.AudioPath = expandEnvVars(cfg.Site.AudioPath)
+
+	// 处理媒体配置
+	cfg.Media.Workers = expandEnvVars(cfg.Media.Workers)
+	cfg.Media.MaxAttempts = expandEnvVars(cfg.Media.MaxAttempts)
+
+	// 处理点赞、收藏和浏览配置
+	cfg.Engagement.ViewHashSecret = expandEnvVars(cfg.Engagement.ViewHashSecret)
 }
 
 // NewConfig 创建配置
@@ -132,6 +204,33 @@ func NewConfig() *Config {
 			Bucket:          "",
 			PublicURL:       "",
 		},
+		Auth: AuthConfig{
+			JWKSURL:  "https://www.googleapis.com/service_accounts/v1/jwk/securetoken@system.gserviceaccount.com",
+			JWKSFile: "",
+			Issuer:   "",
+			Audience: "",
+		},
+		Export: ExportConfig{
+			PDFFont: "",
+		},
+		Trash: TrashConfig{
+			RetentionDays: "30",
+		},
+		Site: SiteConfig{
+			URL:          "https://375566.xyz",
+			Title:        "Betalyr Learning",
+			Description:  "",
+			DocumentPath: "/documents/:slug",
+			VideoPath:    "/videos/:id",
+			AudioPath:    "/audios/:id",
+		},
+		Media: MediaConfig{
+			Workers:     "2",
+			MaxAttempts: "5",
+		},
+		Engagement: EngagementConfig{
+			ViewHashSecret: "",
+		},
 	}
 
 	// 尝试从配置文件加载
@@ -44,11 +44,65 @@ func Initialize(config *config.Config) error {
 	err = db.AutoMigrate(
 		&models.Document{},
 		&models.Media{},
+		&models.MediaJob{},
+		&models.UploadSession{},
+		&models.UploadPart{},
+		&models.DocumentRevision{},
+		&models.DocumentCollaborator{},
+		&models.DocumentShareLink{},
+		&models.DocumentSlugRedirect{},
+		&models.Course{},
+		&models.CourseLesson{},
+		&models.LearningProgress{},
+		&models.Quiz{},
+		&models.QuizAttempt{},
+		&models.DocumentComment{},
+		&models.CommentReport{},
+		&models.ItemLike{},
+		&models.ItemBookmark{},
+		&models.ItemView{},
+		&models.EngagementStats{},
+		&models.DataMigration{},
 	)
 	if err != nil {
 		log.Printf("Failed to migrate database: %v", err)
 		return err
 	}
 
+	// 创建全文搜索所需的列、索引和触发器
+	if err := migrateDocumentSearch(db); err != nil {
+		log.Printf("Failed to migrate document search: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// migrateDocumentSearch 为documents表添加search_vector列，并通过触发器在每次写入时
+// 根据search_title（权重A）和search_text（权重B）重新计算
+func migrateDocumentSearch(db *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS search_vector tsvector`,
+		`CREATE INDEX IF NOT EXISTS idx_documents_search_vector ON documents USING GIN (search_vector)`,
+		`CREATE OR REPLACE FUNCTION documents_search_vector_update() RETURNS trigger AS $$
+		BEGIN
+			NEW.search_vector :=
+				setweight(to_tsvector('simple', coalesce(NEW.search_title, '')), 'A') ||
+				setweight(to_tsvector('simple', coalesce(NEW.search_text, '')), 'B');
+			RETURN NEW;
+		END
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS documents_search_vector_trigger ON documents`,
+		`CREATE TRIGGER documents_search_vector_trigger
+			BEFORE INSERT OR UPDATE OF search_title, search_text ON documents
+			FOR EACH ROW EXECUTE FUNCTION documents_search_vector_update()`,
+	}
+
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
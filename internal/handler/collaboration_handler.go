@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/service"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	// 写消息超时时间
+	collabWriteWait = 10 * time.Second
+	// 等待客户端pong的超时时间
+	collabPongWait = 60 * time.Second
+	// 发送ping的间隔，必须小于collabPongWait
+	collabPingPeriod = (collabPongWait * 9) / 10
+	// 单条消息的最大长度
+	collabMaxMessageSize = 1 << 20
+)
+
+// CollaborationHandler 定义文档实时协作处理器接口
+type CollaborationHandler interface {
+	// 建立文档协作WebSocket连接
+	Connect(c *gin.Context)
+}
+
+// collaborationHandler 实现文档实时协作处理器接口
+type collaborationHandler struct {
+	service  service.CollaborationService
+	upgrader websocket.Upgrader
+}
+
+// NewCollaborationHandler 创建新的文档实时协作处理器实例
+func NewCollaborationHandler(service service.CollaborationService, allowedOrigins []string) CollaborationHandler {
+	return &collaborationHandler{
+		service: service,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				// 非浏览器客户端没有Origin
+				if origin == "" {
+					return true
+				}
+				for _, allowed := range allowedOrigins {
+					if origin == allowed {
+						return true
+					}
+				}
+				return false
+			},
+		},
+	}
+}
+
+// Connect 建立文档协作WebSocket连接
+func (h *collaborationHandler) Connect(c *gin.Context) {
+	documentID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	// 升级连接前先检查权限，以便返回正常的HTTP错误
+	client, err := h.service.Join(documentID, userIdStr)
+	if err != nil {
+		if errors.Is(err, service.ErrDocumentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+			return
+		}
+		if errors.Is(err, service.ErrPermissionDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "No permission to edit this document"})
+			return
+		}
+		logger.Error("Failed to join collaboration session", zap.Error(err), zap.String("documentID", documentID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade已经写入了错误响应
+		logger.Warn("Failed to upgrade collaboration connection", zap.Error(err), zap.String("documentID", documentID))
+		h.service.Leave(client)
+		return
+	}
+
+	go h.writePump(conn, client)
+	h.readPump(conn, client)
+}
+
+// readPump 读取客户端消息，连接断开时离开协作房间
+func (h *collaborationHandler) readPump(conn *websocket.Conn, client *service.CollabClient) {
+	defer func() {
+		h.service.Leave(client)
+		conn.Close()
+	}()
+
+	conn.SetReadLimit(collabMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(collabPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(collabPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				logger.Warn("Collaboration connection closed unexpectedly", zap.Error(err), zap.String("clientID", client.ID))
+			}
+			return
+		}
+		h.service.Receive(client, data)
+	}
+}
+
+// writePump 将房间中的消息写入连接，并定期发送ping
+func (h *collaborationHandler) writePump(conn *websocket.Conn, client *service.CollabClient) {
+	ticker := time.NewTicker(collabPingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case data, ok := <-client.Send:
+			conn.SetWriteDeadline(time.Now().Add(collabWriteWait))
+			if !ok {
+				// 客户端已被移出房间
+				conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(collabWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
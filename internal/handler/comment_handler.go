@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CommentHandler 定义文档评论处理器接口
+type CommentHandler interface {
+	ListComments(c *gin.Context)
+	GetPublicComments(c *gin.Context)
+	CreateComment(c *gin.Context)
+	UpdateComment(c *gin.Context)
+	DeleteComment(c *gin.Context)
+	ResolveComment(c *gin.Context)
+	UnresolveComment(c *gin.Context)
+	HideComment(c *gin.Context)
+	UnhideComment(c *gin.Context)
+	ReportComment(c *gin.Context)
+}
+
+// commentHandler 实现文档评论处理器接口
+type commentHandler struct {
+	service service.CommentService
+}
+
+// NewCommentHandler 创建新的文档评论处理器实例
+func NewCommentHandler(service service.CommentService) CommentHandler {
+	return &commentHandler{
+		service: service,
+	}
+}
+
+// updateCommentRequest 修改评论请求
+type updateCommentRequest struct {
+	Content string `json:"content"`
+}
+
+// reportCommentRequest 举报评论请求
+type reportCommentRequest struct {
+	Reason string `json:"reason"`
+}
+
+// shareLinkCredentials 读取请求头中的分享链接令牌和密码，
+// 通过分享链接访问私有文档时在X-Share-Token和X-Share-Password请求头中提供
+func shareLinkCredentials(c *gin.Context) models.ShareLinkCredentials {
+	return models.ShareLinkCredentials{
+		Token:    c.GetHeader("X-Share-Token"),
+		Password: c.GetHeader("X-Share-Password"),
+	}
+}
+
+// ListComments 分页获取文档的讨论串，文档所有者可以通过 ?includeHidden=true 查看隐藏的评论
+func (h *commentHandler) ListComments(c *gin.Context) {
+	docID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	includeHidden := c.Query("includeHidden") == "true"
+	h.listComments(c, docID, userIdStr, includeHidden)
+}
+
+// GetPublicComments 分页获取公开文档或分享链接可访问文档的讨论串，不需要身份验证
+func (h *commentHandler) GetPublicComments(c *gin.Context) {
+	h.listComments(c, c.Param("id"), "", false)
+}
+
+// listComments 解析分页参数并返回讨论串列表
+func (h *commentHandler) listComments(c *gin.Context, docID, userID string, includeHidden bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	comments, total, err := h.service.ListComments(docID, userID, shareLinkCredentials(c), includeHidden, page, limit)
+	if err != nil {
+		h.handleError(c, err, docID, "")
+		return
+	}
+
+	// 通过分享链接获取的私有文档评论不允许被缓存
+	if c.GetHeader("X-Share-Token") != "" {
+		c.Header("Cache-Control", "private, no-store")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": comments,
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// CreateComment 发表评论或回复
+func (h *commentHandler) CreateComment(c *gin.Context) {
+	docID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req models.CommentInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	comment, err := h.service.CreateComment(docID, userIdStr, shareLinkCredentials(c), req)
+	if err != nil {
+		h.handleError(c, err, docID, "")
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// UpdateComment 修改自己的评论
+func (h *commentHandler) UpdateComment(c *gin.Context) {
+	docID := c.Param("id")
+	commentID := c.Param("commentId")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req updateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	comment, err := h.service.UpdateComment(docID, commentID, userIdStr, shareLinkCredentials(c), req.Content)
+	if err != nil {
+		h.handleError(c, err, docID, commentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment 删除自己的评论
+func (h *commentHandler) DeleteComment(c *gin.Context) {
+	docID := c.Param("id")
+	commentID := c.Param("commentId")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if err := h.service.DeleteComment(docID, commentID, userIdStr, shareLinkCredentials(c)); err != nil {
+		h.handleError(c, err, docID, commentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, true)
+}
+
+// ResolveComment 将讨论串标记为已解决
+func (h *commentHandler) ResolveComment(c *gin.Context) {
+	h.setResolved(c, true)
+}
+
+// UnresolveComment 重新打开已解决的讨论串
+func (h *commentHandler) UnresolveComment(c *gin.Context) {
+	h.setResolved(c, false)
+}
+
+// setResolved 设置讨论串的解决状态
+func (h *commentHandler) setResolved(c *gin.Context, resolved bool) {
+	docID := c.Param("id")
+	commentID := c.Param("commentId")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	comment, err := h.service.ResolveComment(docID, commentID, userIdStr, resolved)
+	if err != nil {
+		h.handleError(c, err, docID, commentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// HideComment 隐藏评论
+func (h *commentHandler) HideComment(c *gin.Context) {
+	h.setHidden(c, true)
+}
+
+// UnhideComment 取消隐藏评论
+func (h *commentHandler) UnhideComment(c *gin.Context) {
+	h.setHidden(c, false)
+}
+
+// setHidden 设置评论的隐藏状态
+func (h *commentHandler) setHidden(c *gin.Context, hidden bool) {
+	docID := c.Param("id")
+	commentID := c.Param("commentId")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	comment, err := h.service.HideComment(docID, commentID, userIdStr, hidden)
+	if err != nil {
+		h.handleError(c, err, docID, commentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// ReportComment 举报评论
+func (h *commentHandler) ReportComment(c *gin.Context) {
+	docID := c.Param("id")
+	commentID := c.Param("commentId")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req reportCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	authType, _ := middleware.GetAuthType(c)
+	virtual := authType == middleware.AuthTypeVirtual
+	if err := h.service.ReportComment(docID, commentID, userIdStr, virtual, shareLinkCredentials(c), req.Reason); err != nil {
+		h.handleError(c, err, docID, commentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, true)
+}
+
+// handleError 将评论服务的错误转换为HTTP响应
+func (h *commentHandler) handleError(c *gin.Context, err error, docID, commentID string) {
+	switch {
+	case errors.Is(err, service.ErrDocumentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+	case errors.Is(err, service.ErrCommentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+	case errors.Is(err, service.ErrPermissionDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+	case errors.Is(err, service.ErrInvalidComment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment"})
+	case errors.Is(err, service.ErrInvalidAnchor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment anchor"})
+	case errors.Is(err, service.ErrShareLinkNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+	case errors.Is(err, service.ErrShareLinkExpired):
+		c.JSON(http.StatusGone, gin.H{"error": "Share link has expired"})
+	case errors.Is(err, service.ErrPasswordRequired):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password required", "passwordRequired": true})
+	case errors.Is(err, service.ErrInvalidPassword):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password", "passwordRequired": true})
+	default:
+		logger.Error("Failed to handle comment request",
+			zap.Error(err),
+			zap.String("documentID", docID),
+			zap.String("commentID", commentID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
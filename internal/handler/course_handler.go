@@ -0,0 +1,289 @@
+package handler
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CourseHandler 定义课程处理器接口
+type CourseHandler interface {
+	CreateCourse(c *gin.Context)
+	GetCourse(c *gin.Context)
+	GetUserCourses(c *gin.Context)
+	UpdateCourse(c *gin.Context)
+	SetLessons(c *gin.Context)
+	PublishCourse(c *gin.Context)
+	UnpublishCourse(c *gin.Context)
+	DeleteCourse(c *gin.Context)
+	GetPublishedCourses(c *gin.Context)
+	GetPublicCourse(c *gin.Context)
+}
+
+// courseHandler 实现课程处理器接口
+type courseHandler struct {
+	service service.CourseService
+}
+
+// NewCourseHandler 创建新的课程处理器实例
+func NewCourseHandler(service service.CourseService) CourseHandler {
+	return &courseHandler{
+		service: service,
+	}
+}
+
+// createCourseRequest 创建课程请求
+type createCourseRequest struct {
+	models.CourseUpdate
+	Lessons []models.CourseLesson `json:"lessons"`
+}
+
+// setLessonsRequest 替换课程章节请求
+type setLessonsRequest struct {
+	Lessons []models.CourseLesson `json:"lessons"`
+}
+
+// CreateCourse 创建课程
+func (h *courseHandler) CreateCourse(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req createCourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	course, err := h.service.CreateCourse(userIdStr, req.CourseUpdate, req.Lessons)
+	if err != nil {
+		h.handleError(c, err, "")
+		return
+	}
+
+	c.JSON(http.StatusCreated, course)
+}
+
+// GetCourse 获取课程详情，所有者可以查看未公开的课程
+func (h *courseHandler) GetCourse(c *gin.Context) {
+	courseID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	course, err := h.service.GetCourse(courseID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, courseID)
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// GetUserCourses 获取当前用户的课程列表
+func (h *courseHandler) GetUserCourses(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	courses, err := h.service.GetUserCourses(userIdStr)
+	if err != nil {
+		logger.Error("Failed to get user courses", zap.Error(err), zap.String("userID", userIdStr))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, courses)
+}
+
+// UpdateCourse 更新课程的标题、描述和封面
+func (h *courseHandler) UpdateCourse(c *gin.Context) {
+	courseID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req models.CourseUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	course, err := h.service.UpdateCourse(courseID, userIdStr, req)
+	if err != nil {
+		h.handleError(c, err, courseID)
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// SetLessons 按请求中的顺序替换课程的所有章节
+func (h *courseHandler) SetLessons(c *gin.Context) {
+	courseID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req setLessonsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	course, err := h.service.SetLessons(courseID, userIdStr, req.Lessons)
+	if err != nil {
+		h.handleError(c, err, courseID)
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// PublishCourse 公开课程
+func (h *courseHandler) PublishCourse(c *gin.Context) {
+	courseID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	course, err := h.service.PublishCourse(courseID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, courseID)
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// UnpublishCourse 取消公开课程
+func (h *courseHandler) UnpublishCourse(c *gin.Context) {
+	courseID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	course, err := h.service.UnpublishCourse(courseID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, courseID)
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// DeleteCourse 删除课程
+func (h *courseHandler) DeleteCourse(c *gin.Context) {
+	courseID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if err := h.service.DeleteCourse(courseID, userIdStr); err != nil {
+		h.handleError(c, err, courseID)
+		return
+	}
+
+	c.JSON(http.StatusOK, true)
+}
+
+// GetPublishedCourses 分页获取公开的课程
+func (h *courseHandler) GetPublishedCourses(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	courses, total, err := h.service.GetPublishedCourses(page, limit)
+	if err != nil {
+		logger.Error("Failed to get published courses", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": courses,
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// GetPublicCourse 获取公开课程详情
+func (h *courseHandler) GetPublicCourse(c *gin.Context) {
+	courseID := c.Param("id")
+
+	course, err := h.service.GetPublicCourse(courseID)
+	if err != nil {
+		h.handleError(c, err, courseID)
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// handleError 将课程服务的错误转换为HTTP响应
+func (h *courseHandler) handleError(c *gin.Context, err error, courseID string) {
+	var itemErr *service.CourseItemError
+	switch {
+	case errors.As(err, &itemErr):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Invalid lesson: " + itemErr.Reason,
+			"index":  itemErr.Index,
+			"type":   itemErr.Type,
+			"itemId": itemErr.ItemID,
+		})
+	case errors.Is(err, service.ErrCourseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+	case errors.Is(err, service.ErrPermissionDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the course owner can modify the course"})
+	case errors.Is(err, service.ErrInvalidCourse):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Course title must be 1-200 characters and a course can have at most 200 lessons"})
+	default:
+		logger.Error("Failed to handle course request", zap.Error(err), zap.String("courseID", courseID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -1,14 +1,18 @@
 package handler
 
 import (
+	"betalyr-learning-server/internal/models"
 	"betalyr-learning-server/internal/pkg/logger"
 	"betalyr-learning-server/internal/pkg/middleware"
 	"betalyr-learning-server/internal/service"
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -18,35 +22,57 @@ import (
 type DocumentHandler interface {
 	FindDoc(c *gin.Context)
 	GetDoc(c *gin.Context)
+	GetPublicDoc(c *gin.Context)
+	GetPublicDocBySlug(c *gin.Context)
+	GetPublicDocMeta(c *gin.Context)
+	GetPublicDocMetaBySlug(c *gin.Context)
+	SetSlug(c *gin.Context)
 	CreateEmptyDoc(c *gin.Context)
+	CreateChildDoc(c *gin.Context)
 	GetUserDocs(c *gin.Context)
+	GetDocTree(c *gin.Context)
+	MoveDoc(c *gin.Context)
+	ReorderDocs(c *gin.Context)
 	UpdateDoc(c *gin.Context)
 	PublishDoc(c *gin.Context)
 	UnpublishDoc(c *gin.Context)
 	DeleteDoc(c *gin.Context)
 	CloudinarySignRequest(c *gin.Context)
 	GetPublishedDocs(c *gin.Context)
+	GetPublicTags(c *gin.Context)
+	SuggestTags(c *gin.Context)
+	SearchPublishedDocs(c *gin.Context)
+	SearchUserDocs(c *gin.Context)
 }
 
 // documentHandler 实现文档处理器接口
 type documentHandler struct {
-	service      service.DocumentService
-	cloudService service.CloudinaryService
+	service       service.DocumentService
+	cloudService  service.CloudinaryService
+	collaboration service.CollaborationService
 }
 
-// NewDocumentHandler 创建新的文档处理器实例
-func NewDocumentHandler(service service.DocumentService, cloudService service.CloudinaryService) DocumentHandler {
+// NewDocumentHandler 创建新的文档处理器实例，collaboration为nil时删除文档不关闭实时协作房间
+func NewDocumentHandler(service service.DocumentService, cloudService service.CloudinaryService, collaboration service.CollaborationService) DocumentHandler {
 	return &documentHandler{
-		service:      service,
-		cloudService: cloudService,
+		service:       service,
+		cloudService:  cloudService,
+		collaboration: collaboration,
 	}
 }
 
-// FindDoc 检查文档是否存在
+// FindDoc 检查文档是否存在，当前用户无权查看的文档返回false
 func (h *documentHandler) FindDoc(c *gin.Context) {
 	documentID := c.Param("id")
 
-	exists, err := h.service.FindDoc(documentID)
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	exists, err := h.service.FindDoc(documentID, userIdStr)
 	if err != nil {
 		logger.Error("Failed to check if document exists", zap.Error(err), zap.String("documentID", documentID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
@@ -56,11 +82,18 @@ func (h *documentHandler) FindDoc(c *gin.Context) {
 	c.JSON(http.StatusOK, exists)
 }
 
-// GetDoc 获取文档详情
+// GetDoc 获取文档详情，当前用户无权查看时返回404
 func (h *documentHandler) GetDoc(c *gin.Context) {
 	documentID := c.Param("id")
 
-	doc, err := h.service.GetDoc(documentID)
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	doc, err := h.service.GetDoc(documentID, userIdStr)
 	if err != nil {
 		logger.Error("Failed to get document", zap.Error(err), zap.String("documentID", documentID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
@@ -72,9 +105,141 @@ func (h *documentHandler) GetDoc(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", doc.ETag())
+	c.JSON(http.StatusOK, doc)
+}
+
+// GetPublicDoc 获取公开文档详情，不需要身份验证，非公开文档返回404
+func (h *documentHandler) GetPublicDoc(c *gin.Context) {
+	documentID := c.Param("id")
+
+	doc, err := h.service.GetPublicDoc(documentID)
+	if err != nil {
+		logger.Error("Failed to get public document", zap.Error(err), zap.String("documentID", documentID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if doc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
+
+// GetPublicDocBySlug 根据短名称获取公开文档，短名称已变化时301重定向到当前短名称
+func (h *documentHandler) GetPublicDocBySlug(c *gin.Context) {
+	doc, ok := h.findPublicDocBySlug(c)
+	if !ok {
+		return
+	}
 	c.JSON(http.StatusOK, doc)
 }
 
+// GetPublicDocMeta 获取公开文档的SEO和OpenGraph元数据
+func (h *documentHandler) GetPublicDocMeta(c *gin.Context) {
+	documentID := c.Param("id")
+
+	doc, err := h.service.GetPublicDoc(documentID)
+	if err != nil {
+		logger.Error("Failed to get public document", zap.Error(err), zap.String("documentID", documentID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if doc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, doc.ToDocumentMeta())
+}
+
+// GetPublicDocMetaBySlug 根据短名称获取公开文档的SEO和OpenGraph元数据，短名称已变化时301重定向
+func (h *documentHandler) GetPublicDocMetaBySlug(c *gin.Context) {
+	doc, ok := h.findPublicDocBySlug(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, doc.ToDocumentMeta())
+}
+
+// findPublicDocBySlug 根据路径中的短名称查找公开文档，找不到或需要重定向时写入响应并返回false
+func (h *documentHandler) findPublicDocBySlug(c *gin.Context) (*models.Document, bool) {
+	slug := c.Param("slug")
+
+	doc, current, err := h.service.GetPublicDocBySlug(slug)
+	if err != nil {
+		logger.Error("Failed to get public document by slug", zap.Error(err), zap.String("slug", slug))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return nil, false
+	}
+
+	if current != "" {
+		// 将路径中的旧短名称替换为当前短名称，保留其余路径和查询参数
+		location := strings.Replace(c.Request.URL.Path, "/by-slug/"+slug, "/by-slug/"+current, 1)
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
+		return nil, false
+	}
+
+	if doc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return nil, false
+	}
+
+	return doc, true
+}
+
+// setSlugRequest 设置短名称请求，slug为空表示恢复为根据标题自动生成
+type setSlugRequest struct {
+	Slug string `json:"slug"`
+}
+
+// SetSlug 设置文档公开访问使用的短名称
+func (h *documentHandler) SetSlug(c *gin.Context) {
+	documentID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req setSlugRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	doc, err := h.service.SetSlug(documentID, userIdStr, req.Slug)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDocumentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		case errors.Is(err, service.ErrPermissionDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the document owner can change the slug"})
+		case errors.Is(err, service.ErrInvalidSlug):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Slug must contain letters or digits"})
+		case errors.Is(err, service.ErrSlugTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Slug is already taken"})
+		default:
+			logger.Error("Failed to set document slug", zap.Error(err), zap.String("documentID", documentID))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"slug":       doc.Slug,
+		"slugCustom": doc.SlugCustom,
+	})
+}
+
 // CreateEmptyDoc 创建空文档
 func (h *documentHandler) CreateEmptyDoc(c *gin.Context) {
 	// 使用辅助函数从上下文中获取用户ID
@@ -94,6 +259,7 @@ func (h *documentHandler) CreateEmptyDoc(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", doc.ETag())
 	c.JSON(http.StatusCreated, doc)
 }
 
@@ -129,6 +295,13 @@ func (h *documentHandler) UpdateDoc(c *gin.Context) {
 		return
 	}
 
+	// 解析If-Match请求头中的版本号
+	expectedVersion, ok := parseIfMatch(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid If-Match header"})
+		return
+	}
+
 	// 解析请求体
 	var updates map[string]interface{}
 
@@ -150,7 +323,10 @@ func (h *documentHandler) UpdateDoc(c *gin.Context) {
 	updatesJson, _ := json.Marshal(updates)
 	logger.Info("Document update content", zap.String("documentID", documentID), zap.String("updates", string(updatesJson)))
 
-	doc, err := h.service.UpdateDoc(documentID, userIdStr, updates)
+	doc, err := h.service.UpdateDoc(documentID, userIdStr, updates, expectedVersion)
+	if respondVersionConflict(c, err) {
+		return
+	}
 	if err != nil {
 		logger.Error("Failed to update document", zap.Error(err), zap.String("documentID", documentID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
@@ -162,10 +338,139 @@ func (h *documentHandler) UpdateDoc(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", doc.ETag())
 	c.JSON(http.StatusOK, doc)
 }
 
-// PublishDoc 将文档设为公开
+// CreateChildDoc 在文档下创建空的子页面
+func (h *documentHandler) CreateChildDoc(c *gin.Context) {
+	parentID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	doc, err := h.service.CreateChildDoc(parentID, userIdStr)
+	if err != nil {
+		h.handleTreeError(c, err, parentID)
+		return
+	}
+
+	c.Header("ETag", doc.ETag())
+	c.JSON(http.StatusCreated, doc)
+}
+
+// GetDocTree 获取当前用户的文档树，用于侧边栏
+func (h *documentHandler) GetDocTree(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	tree, err := h.service.GetDocTree(userIdStr)
+	if err != nil {
+		logger.Error("Failed to get document tree", zap.Error(err), zap.String("userID", userIdStr))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
+// MoveDoc 将文档移动到新的父文档下，请求体为 {"parentId": "..."|null, "position": 0}，省略position时追加到末尾
+func (h *documentHandler) MoveDoc(c *gin.Context) {
+	documentID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req struct {
+		ParentID *string `json:"parentId"`
+		Position *int    `json:"position"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	position := -1
+	if req.Position != nil {
+		position = *req.Position
+	}
+	if req.ParentID != nil && *req.ParentID == "" {
+		req.ParentID = nil
+	}
+
+	doc, err := h.service.MoveDoc(documentID, userIdStr, req.ParentID, position)
+	if err != nil {
+		h.handleTreeError(c, err, documentID)
+		return
+	}
+
+	c.Header("ETag", doc.ETag())
+	c.JSON(http.StatusOK, doc.ToDocumentList())
+}
+
+// ReorderDocs 调整同级文档的顺序，请求体为 {"parentId": "..."|null, "ids": ["...", ...]}
+func (h *documentHandler) ReorderDocs(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req struct {
+		ParentID *string  `json:"parentId"`
+		IDs      []string `json:"ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if req.ParentID != nil && *req.ParentID == "" {
+		req.ParentID = nil
+	}
+
+	parentID := ""
+	if req.ParentID != nil {
+		parentID = *req.ParentID
+	}
+	if err := h.service.ReorderDocs(userIdStr, req.ParentID, req.IDs); err != nil {
+		h.handleTreeError(c, err, parentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, true)
+}
+
+// handleTreeError 将文档层级操作的错误转换为HTTP响应
+func (h *documentHandler) handleTreeError(c *gin.Context, err error, documentID string) {
+	switch {
+	case errors.Is(err, service.ErrDocumentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+	case errors.Is(err, service.ErrPermissionDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the document owner can change the page tree"})
+	case errors.Is(err, service.ErrInvalidMove):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot move a page under itself or its sub-pages"})
+	case errors.Is(err, service.ErrInvalidOrder):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids must list every sibling page exactly once"})
+	default:
+		logger.Error("Failed to update document tree", zap.Error(err), zap.String("documentID", documentID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+// PublishDoc 将文档设为公开，?cascade=true 时同时公开所有子页面
 func (h *documentHandler) PublishDoc(c *gin.Context) {
 	documentID := c.Param("id")
 
@@ -177,7 +482,20 @@ func (h *documentHandler) PublishDoc(c *gin.Context) {
 		return
 	}
 
-	doc, err := h.service.PublishDoc(documentID, userIdStr)
+	// 解析If-Match请求头中的版本号
+	expectedVersion, ok := parseIfMatch(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid If-Match header"})
+		return
+	}
+
+	// ?cascade=true 时同时公开所有子页面
+	cascade, _ := strconv.ParseBool(c.DefaultQuery("cascade", "false"))
+
+	doc, err := h.service.PublishDoc(documentID, userIdStr, expectedVersion, cascade)
+	if respondVersionConflict(c, err) {
+		return
+	}
 	if err != nil {
 		logger.Error("Failed to publish document", zap.Error(err), zap.String("documentID", documentID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
@@ -190,6 +508,7 @@ func (h *documentHandler) PublishDoc(c *gin.Context) {
 	}
 
 	// 返回成功状态
+	c.Header("ETag", doc.ETag())
 	c.JSON(http.StatusOK, true)
 }
 
@@ -203,7 +522,18 @@ func (h *documentHandler) UnpublishDoc(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
-	doc, err := h.service.UnpublishDoc(documentID, userIdStr)
+
+	// 解析If-Match请求头中的版本号
+	expectedVersion, ok := parseIfMatch(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid If-Match header"})
+		return
+	}
+
+	doc, err := h.service.UnpublishDoc(documentID, userIdStr, expectedVersion)
+	if respondVersionConflict(c, err) {
+		return
+	}
 	if err != nil {
 		logger.Error("Failed to unpublish document", zap.Error(err), zap.String("documentID", documentID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
@@ -216,6 +546,7 @@ func (h *documentHandler) UnpublishDoc(c *gin.Context) {
 	}
 
 	// 返回成功状态
+	c.Header("ETag", doc.ETag())
 	c.JSON(http.StatusOK, true)
 }
 
@@ -233,7 +564,17 @@ func (h *documentHandler) DeleteDoc(c *gin.Context) {
 
 	logger.Info("Deleting document", zap.String("documentID", documentID), zap.String("userID", userIdStr))
 
-	success, err := h.service.DeleteDoc(documentID, userIdStr)
+	// 解析If-Match请求头中的版本号
+	expectedVersion, ok := parseIfMatch(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid If-Match header"})
+		return
+	}
+
+	success, err := h.service.DeleteDoc(documentID, userIdStr, expectedVersion)
+	if respondVersionConflict(c, err) {
+		return
+	}
 	if err != nil {
 		logger.Error("Failed to delete document", zap.Error(err), zap.String("documentID", documentID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
@@ -245,6 +586,11 @@ func (h *documentHandler) DeleteDoc(c *gin.Context) {
 		return
 	}
 
+	// 关闭文档的实时协作房间，避免协作者继续编辑回收站中的文档
+	if h.collaboration != nil {
+		h.collaboration.CloseDocument(documentID)
+	}
+
 	c.JSON(http.StatusOK, true)
 }
 
@@ -316,12 +662,18 @@ func (h *documentHandler) GetPublishedDocs(c *gin.Context) {
 		limit = 100
 	}
 
+	// 可选的标签过滤和排序方式（recent、popular、trending）
+	tag := c.Query("tag")
+	sort := models.ParsePublicListSort(c.Query("sort"))
+
 	logger.Info("Getting published articles list",
 		zap.Int("page", page),
-		zap.Int("limit", limit))
+		zap.Int("limit", limit),
+		zap.String("tag", tag),
+		zap.String("sort", string(sort)))
 
 	// 调用服务层获取数据
-	docs, total, err := h.service.GetPublishedDocs(page, limit)
+	docs, total, err := h.service.GetPublishedDocs(page, limit, tag, sort)
 	if err != nil {
 		logger.Error("Failed to get published articles", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
@@ -338,3 +690,141 @@ func (h *documentHandler) GetPublishedDocs(c *gin.Context) {
 		},
 	})
 }
+
+// GetPublicTags 获取公开文档的标签及文档数量
+func (h *documentHandler) GetPublicTags(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	tags, err := h.service.GetPublicTags(limit)
+	if err != nil {
+		logger.Error("Failed to get public tags", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// SuggestTags 根据前缀补全当前用户使用过的标签
+func (h *documentHandler) SuggestTags(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	prefix := c.Query("prefix")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	tags, err := h.service.SuggestTags(userIdStr, prefix, limit)
+	if err != nil {
+		logger.Error("Failed to suggest tags", zap.Error(err), zap.String("userID", userIdStr))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// SearchPublishedDocs 全文搜索公开文档，参数 q、tag、owner、page、limit
+func (h *documentHandler) SearchPublishedDocs(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query cannot be empty"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	results, total, err := h.service.SearchPublishedDocs(q, c.Query("tag"), c.Query("owner"), page, limit)
+	if err != nil {
+		logger.Error("Failed to search published documents", zap.Error(err), zap.String("q", q))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": results,
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// SearchUserDocs 全文搜索当前用户自己的文档，参数 q、tag、page、limit
+func (h *documentHandler) SearchUserDocs(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	q := c.Query("q")
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query cannot be empty"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	results, total, err := h.service.SearchUserDocs(userIdStr, q, c.Query("tag"), page, limit)
+	if err != nil {
+		logger.Error("Failed to search user documents", zap.Error(err), zap.String("userID", userIdStr))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": results,
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// parseIfMatch 解析If-Match请求头中的文档版本号
+// 未提供或为"*"时返回0表示不检查版本，格式错误时返回false。
+// If-Match是可选的：为兼容不发送该请求头的旧客户端，未提供时按最后写入者为准处理，
+// 需要防止覆盖他人修改的客户端应在所有修改请求中携带GET返回的ETag
+func parseIfMatch(c *gin.Context) (int64, bool) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, true
+	}
+
+	ifMatch = strings.TrimPrefix(ifMatch, "W/")
+	version, err := strconv.ParseInt(strings.Trim(ifMatch, "\""), 10, 64)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
+
+// respondVersionConflict 如果err为版本冲突，返回409/412以及服务器当前版本号
+func respondVersionConflict(c *gin.Context, err error) bool {
+	var conflict *service.VersionConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+
+	status := http.StatusConflict
+	message := "Document was modified by another request"
+	if conflict.PreconditionFailed {
+		status = http.StatusPreconditionFailed
+		message = "Document version is out of date"
+	}
+
+	c.Header("ETag", fmt.Sprintf("\"%d\"", conflict.CurrentVersion))
+	c.JSON(status, gin.H{
+		"error":          message,
+		"currentVersion": conflict.CurrentVersion,
+	})
+	return true
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// EngagementHandler 定义点赞、收藏和浏览处理器接口
+type EngagementHandler interface {
+	GetState(c *gin.Context)
+	Like(c *gin.Context)
+	Unlike(c *gin.Context)
+	Bookmark(c *gin.Context)
+	Unbookmark(c *gin.Context)
+	GetBookmarks(c *gin.Context)
+	RecordView(c *gin.Context)
+}
+
+// engagementHandler 实现点赞、收藏和浏览处理器接口
+type engagementHandler struct {
+	service service.EngagementService
+}
+
+// NewEngagementHandler 创建新的点赞、收藏和浏览处理器实例
+func NewEngagementHandler(service service.EngagementService) EngagementHandler {
+	return &engagementHandler{
+		service: service,
+	}
+}
+
+// GetState 获取内容的点赞、收藏和浏览数量，登录用户同时返回是否已点赞、收藏
+func (h *engagementHandler) GetState(c *gin.Context) {
+	itemType := models.EngagementItemType(c.Param("type"))
+	itemID := c.Param("id")
+
+	// 匿名访问时用户ID为空
+	userIdStr, _ := middleware.GetUserID(c)
+
+	state, err := h.service.GetState(userIdStr, itemType, itemID)
+	if err != nil {
+		h.handleError(c, err, itemType, itemID)
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// Like 点赞
+func (h *engagementHandler) Like(c *gin.Context) {
+	h.setLiked(c, true)
+}
+
+// Unlike 取消点赞
+func (h *engagementHandler) Unlike(c *gin.Context) {
+	h.setLiked(c, false)
+}
+
+// setLiked 设置当前用户对内容的点赞状态
+func (h *engagementHandler) setLiked(c *gin.Context, liked bool) {
+	itemType := models.EngagementItemType(c.Param("type"))
+	itemID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	authType, _ := middleware.GetAuthType(c)
+
+	state, err := h.service.SetLiked(userIdStr, authType == middleware.AuthTypeVirtual, itemType, itemID, liked)
+	if err != nil {
+		h.handleError(c, err, itemType, itemID)
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// Bookmark 收藏
+func (h *engagementHandler) Bookmark(c *gin.Context) {
+	h.setBookmarked(c, true)
+}
+
+// Unbookmark 取消收藏
+func (h *engagementHandler) Unbookmark(c *gin.Context) {
+	h.setBookmarked(c, false)
+}
+
+// setBookmarked 设置当前用户对内容的收藏状态
+func (h *engagementHandler) setBookmarked(c *gin.Context, bookmarked bool) {
+	itemType := models.EngagementItemType(c.Param("type"))
+	itemID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	authType, _ := middleware.GetAuthType(c)
+
+	state, err := h.service.SetBookmarked(userIdStr, authType == middleware.AuthTypeVirtual, itemType, itemID, bookmarked)
+	if err != nil {
+		h.handleError(c, err, itemType, itemID)
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// GetBookmarks 分页获取当前用户的收藏，可通过 ?type=document|media 过滤
+func (h *engagementHandler) GetBookmarks(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	itemType := models.EngagementItemType(c.Query("type"))
+	bookmarks, total, err := h.service.GetBookmarks(userIdStr, itemType, page, limit)
+	if err != nil {
+		h.handleError(c, err, itemType, "")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": bookmarks,
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// RecordView 记录一次浏览，登录用户按用户ID去重，匿名用户和虚拟用户按IP去重
+func (h *engagementHandler) RecordView(c *gin.Context) {
+	itemType := models.EngagementItemType(c.Param("type"))
+	itemID := c.Param("id")
+
+	// 匿名访问时用户ID为空
+	userIdStr, _ := middleware.GetUserID(c)
+	authType, _ := middleware.GetAuthType(c)
+
+	counted, err := h.service.RecordView(itemType, itemID, userIdStr, authType == middleware.AuthTypeVirtual, c.ClientIP())
+	if err != nil {
+		h.handleError(c, err, itemType, itemID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"counted": counted})
+}
+
+// handleError 将点赞、收藏和浏览服务的错误转换为HTTP响应
+func (h *engagementHandler) handleError(c *gin.Context, err error, itemType models.EngagementItemType, itemID string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidItemType):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be document or media"})
+	case errors.Is(err, service.ErrDocumentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+	case errors.Is(err, service.ErrMediaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+	case errors.Is(err, service.ErrLoginRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Please log in to like or bookmark"})
+	default:
+		logger.Error("Failed to handle engagement request",
+			zap.Error(err),
+			zap.String("type", string(itemType)),
+			zap.String("itemID", itemID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
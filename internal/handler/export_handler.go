@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"betalyr-learning-server/internal/pkg/export"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/service"
+	"errors"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ExportHandler 定义文档导出处理器接口
+type ExportHandler interface {
+	ExportDoc(c *gin.Context)
+	ExportUserDocs(c *gin.Context)
+}
+
+// exportHandler 实现文档导出处理器接口
+type exportHandler struct {
+	service service.ExportService
+}
+
+// NewExportHandler 创建新的文档导出处理器实例
+func NewExportHandler(service service.ExportService) ExportHandler {
+	return &exportHandler{
+		service: service,
+	}
+}
+
+// ExportDoc 导出单个文档，参数为 ?format=markdown|html|pdf，默认为markdown
+func (h *exportHandler) ExportDoc(c *gin.Context) {
+	documentID := c.Param("id")
+
+	format, ok := export.ParseFormat(c.DefaultQuery("format", "markdown"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be one of markdown, html or pdf"})
+		return
+	}
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	file, err := h.service.ExportDoc(documentID, userIdStr, format)
+	if err != nil {
+		if errors.Is(err, service.ErrDocumentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+			return
+		}
+		logger.Error("Failed to export document", zap.Error(err), zap.String("documentID", documentID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.Header("Content-Disposition", attachmentDisposition(file.Name))
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+// ExportUserDocs 将当前用户的所有文档导出为zip压缩包，参数为 ?format=markdown|html|pdf
+func (h *exportHandler) ExportUserDocs(c *gin.Context) {
+	format, ok := export.ParseFormat(c.DefaultQuery("format", "markdown"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be one of markdown, html or pdf"})
+		return
+	}
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	// 压缩包直接写入响应，开始写入后无法再返回错误状态码
+	name := "documents-" + time.Now().Format("20060102") + ".zip"
+	c.Header("Content-Disposition", attachmentDisposition(name))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+
+	if err := h.service.ExportUserDocs(userIdStr, format, c.Writer); err != nil {
+		logger.Error("Failed to export user documents", zap.Error(err), zap.String("userID", userIdStr))
+	}
+}
+
+// attachmentDisposition 生成下载文件的Content-Disposition，支持非ASCII文件名
+func attachmentDisposition(filename string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+}
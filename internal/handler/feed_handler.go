@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"betalyr-learning-server/internal/pkg/feed"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 订阅源和站点地图的缓存时间
+const feedCacheControl = "public, max-age=900"
+
+// FeedHandler 定义订阅源和站点地图处理器接口
+type FeedHandler interface {
+	GetFeed(c *gin.Context)
+	GetSitemap(c *gin.Context)
+}
+
+// feedHandler 实现订阅源和站点地图处理器接口
+type feedHandler struct {
+	service service.FeedService
+}
+
+// NewFeedHandler 创建新的订阅源和站点地图处理器实例
+func NewFeedHandler(service service.FeedService) FeedHandler {
+	return &feedHandler{
+		service: service,
+	}
+}
+
+// GetFeed 获取公开内容的订阅源。?format=atom 返回Atom，默认返回RSS 2.0；
+// ?tag= 和 ?author= 分别按标签和作者过滤；?full=true 时条目包含HTML全文，默认只包含摘要
+func (h *feedHandler) GetFeed(c *gin.Context) {
+	tag := c.Query("tag")
+	author := c.Query("author")
+	fullText, _ := strconv.ParseBool(c.DefaultQuery("full", "false"))
+
+	result, err := h.service.GetFeed(tag, author, fullText, requestURL(c))
+	if err != nil {
+		logger.Error("Failed to get feed", zap.Error(err), zap.String("tag", tag), zap.String("author", author))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	render, contentType := feed.RSS, "application/rss+xml; charset=utf-8"
+	if c.Query("format") == "atom" {
+		render, contentType = feed.Atom, "application/atom+xml; charset=utf-8"
+	}
+
+	data, err := render(*result)
+	if err != nil {
+		logger.Error("Failed to render feed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.Header("Cache-Control", feedCacheControl)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// GetSitemap 获取公开文档、视频和音频的XML站点地图
+func (h *feedHandler) GetSitemap(c *gin.Context) {
+	urls, err := h.service.GetSitemap()
+	if err != nil {
+		logger.Error("Failed to get sitemap", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	data, err := feed.Sitemap(urls)
+	if err != nil {
+		logger.Error("Failed to render sitemap", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.Header("Cache-Control", feedCacheControl)
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", data)
+}
+
+// requestURL 返回当前请求的完整地址，部署在反向代理后时根据X-Forwarded-Proto判断协议
+func requestURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + c.Request.URL.RequestURI()
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/service"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 导入请求的最大大小（zip压缩包）
+const maxImportUploadSize = 50 << 20
+
+// ImportHandler 定义文档导入处理器接口
+type ImportHandler interface {
+	ImportDocs(c *gin.Context)
+}
+
+// importHandler 实现文档导入处理器接口
+type importHandler struct {
+	service service.ImportService
+}
+
+// NewImportHandler 创建新的文档导入处理器实例
+func NewImportHandler(service service.ImportService) ImportHandler {
+	return &importHandler{
+		service: service,
+	}
+}
+
+// ImportDocs 导入文档，multipart表单字段file为.md、.html、.txt文件或包含这些文件的.zip压缩包
+func (h *importHandler) ImportDocs(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportUploadSize)
+	file, fileHeader, err := c.Request.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		logger.Error("Failed to read uploaded file", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+
+	fileName := fileHeader.Filename
+	if strings.EqualFold(path.Ext(fileName), ".zip") {
+		docs, failed, err := h.service.ImportZip(userIdStr, data)
+		if err != nil {
+			h.handleError(c, err, fileName)
+			return
+		}
+		if failed == nil {
+			failed = []service.ImportFailure{}
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"documents": docs,
+			"failed":    failed,
+		})
+		return
+	}
+
+	doc, err := h.service.ImportFile(userIdStr, fileName, data)
+	if err != nil {
+		h.handleError(c, err, fileName)
+		return
+	}
+	c.JSON(http.StatusCreated, doc)
+}
+
+// handleError 将导入服务的错误转换为HTTP响应
+func (h *importHandler) handleError(c *gin.Context, err error, fileName string) {
+	switch {
+	case errors.Is(err, service.ErrUnsupportedImport):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File must be .md, .html, .txt or a .zip of these files"})
+	case errors.Is(err, service.ErrImportTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+	case errors.Is(err, service.ErrInvalidImport):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		logger.Error("Failed to import documents", zap.Error(err), zap.String("fileName", fileName))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -5,17 +5,14 @@ import (
 	"betalyr-learning-server/internal/pkg/logger"
 	"betalyr-learning-server/internal/pkg/middleware"
 	"betalyr-learning-server/internal/repository"
-	"fmt"
-	"io"
+	"betalyr-learning-server/internal/service"
+	"errors"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	ffmpeg_go "github.com/u2takey/ffmpeg-go"
 	"go.uber.org/zap"
 )
 
@@ -27,6 +24,8 @@ type MediaHandler interface {
 	UploadAudio(c *gin.Context)
 	// 删除媒体文件
 	DeleteMedia(c *gin.Context)
+	// 获取媒体的处理状态
+	GetMediaStatus(c *gin.Context)
 	// 获取视频列表
 	GetVideos(c *gin.Context)
 	// 获取视频详情
@@ -39,13 +38,19 @@ type MediaHandler interface {
 
 // mediaHandler 实现媒体处理器接口
 type mediaHandler struct {
-	repo repository.MediaRepository
+	repo       repository.MediaRepository
+	engagement repository.EngagementRepository
+	quizzes    repository.QuizRepository
+	processing service.MediaProcessingService
 }
 
 // NewMediaHandler 创建新的媒体处理器实例
-func NewMediaHandler(repo repository.MediaRepository) MediaHandler {
+func NewMediaHandler(repo repository.MediaRepository, engagement repository.EngagementRepository, quizzes repository.QuizRepository, processing service.MediaProcessingService) MediaHandler {
 	return &mediaHandler{
-		repo: repo,
+		repo:       repo,
+		engagement: engagement,
+		quizzes:    quizzes,
+		processing: processing,
 	}
 }
 
@@ -97,12 +102,51 @@ func (h *mediaHandler) DeleteMedia(c *gin.Context) {
 		return
 	}
 
+	// 删除媒体的点赞、收藏和浏览记录
+	if err := h.engagement.DeleteByItem(models.EngagementItemMedia, mediaID); err != nil {
+		logger.Error("Failed to delete media engagement", zap.Error(err), zap.String("mediaID", mediaID))
+	}
+
+	// 删除视频的测验和作答记录
+	if err := h.quizzes.DeleteByTarget(models.QuizTargetVideo, mediaID); err != nil {
+		logger.Error("Failed to delete media quiz", zap.Error(err), zap.String("mediaID", mediaID))
+	}
+
+	// 删除媒体的处理任务，尚未执行的任务会因媒体不存在而跳过
+	if err := h.processing.DeleteJobs(mediaID); err != nil {
+		logger.Error("Failed to delete media jobs", zap.Error(err), zap.String("mediaID", mediaID))
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Media deleted successfully",
 	})
 }
 
+// GetMediaStatus 获取媒体的处理状态，供上传者在上传后轮询
+func (h *mediaHandler) GetMediaStatus(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	mediaID := c.Param("id")
+	status, err := h.processing.GetStatus(mediaID, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrMediaNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+			return
+		}
+		logger.Error("Failed to get media status", zap.Error(err), zap.String("mediaID", mediaID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
 // 根据文件名推断内容类型
 func inferContentType(fileName string) string {
 	ext := strings.ToLower(fileName[strings.LastIndex(fileName, ".")+1:])
@@ -150,18 +194,30 @@ func (h *mediaHandler) GetVideos(c *gin.Context) {
 		limit = 20
 	}
 
+	// 排序方式：recent（默认）、popular、trending
+	sort := models.ParsePublicListSort(c.Query("sort"))
+
 	// 获取视频列表
-	videos, err := h.repo.GetVideos(page, limit)
+	videos, err := h.repo.GetVideos(page, limit, sort)
 	if err != nil {
 		logger.Error("Failed to get videos", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get videos"})
 		return
 	}
 
+	// 获取互动统计
+	stats, err := h.getMediaStats(videos)
+	if err != nil {
+		logger.Error("Failed to get video engagement stats", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get videos"})
+		return
+	}
+
 	// 转换为列表格式
 	videoList := make([]models.PublicVideoList, len(videos))
 	for i, video := range videos {
 		videoList[i] = video.ToPublicVideoList()
+		videoList[i].EngagementCounts = stats[video.ID].Counts()
 	}
 
 	// 按前端期望的格式返回
@@ -253,32 +309,7 @@ func (h *mediaHandler) UploadVideo(c *gin.Context) {
 		zap.Int64("fileSize", fileSize),
 		zap.String("contentType", contentType))
 
-	// 保存临时文件用于处理
-	tempDir := os.TempDir()
-	tempVideoPath := filepath.Join(tempDir, fmt.Sprintf("video_%s_%s", uuid.New().String(), fileName))
-
-	tempFile, err := os.Create(tempVideoPath)
-	if err != nil {
-		logger.Error("Failed to create temp file", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-		return
-	}
-	defer os.Remove(tempVideoPath)
-	defer tempFile.Close()
-
-	// 复制文件内容到临时文件
-	_, err = io.Copy(tempFile, file)
-	if err != nil {
-		logger.Error("Failed to copy file to temp", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-		return
-	}
-	tempFile.Close()
-
-	// 重新打开文件用于上传
-	file.Seek(0, 0)
-
-	// 上传原视频文件到存储
+	// 上传原视频文件到存储，预览图等由后台任务处理
 	fileURL, err := h.repo.UploadMedia(file, fileSize, fileName, contentType)
 	if err != nil {
 		logger.Error("Failed to upload video file", zap.Error(err))
@@ -289,13 +320,6 @@ func (h *mediaHandler) UploadVideo(c *gin.Context) {
 	// 从URL中提取文件键
 	fileKey := strings.TrimPrefix(fileURL, strings.Split(fileURL, "/")[0]+"//"+strings.Split(fileURL, "/")[2]+"/")
 
-	// 提取视频帧
-	previewURL, thumbnailURL, err := h.extractVideoFrames(tempVideoPath, fileName)
-	if err != nil {
-		logger.Error("Failed to extract video frames", zap.Error(err))
-		// 不阻断上传，继续保存视频记录
-	}
-
 	// 生成媒体记录ID
 	mediaID := uuid.New().String()
 
@@ -318,27 +342,24 @@ func (h *mediaHandler) UploadVideo(c *gin.Context) {
 		ContentType: contentType,
 		MediaType:   models.MediaTypeVideo,
 		Category:    category,
-		Status:      models.MediaStatusReady,
-		Preview:     previewURL,
-		Thumbnail:   thumbnailURL,
+		Status:      models.MediaStatusProcessing,
 	}
 
-	// 保存媒体记录到数据库
-	if err := h.repo.CreateMedia(media); err != nil {
-		logger.Error("Failed to create media record", zap.Error(err))
+	// 保存媒体记录并加入处理队列
+	if !h.createAndEnqueue(c, media) {
+		return
 	}
 
-	// 返回上传成功结果
-	c.JSON(http.StatusOK, gin.H{
+	// 返回上传成功结果，客户端通过状态接口轮询处理进度
+	c.JSON(http.StatusAccepted, gin.H{
 		"id":          mediaID,
 		"url":         fileURL,
 		"fileName":    fileName,
 		"fileSize":    fileSize,
 		"contentType": contentType,
 		"category":    category,
-		"preview":     previewURL,
-		"thumbnail":   thumbnailURL,
-		"message":     "Video upload successful",
+		"status":      media.Status,
+		"message":     "Video upload successful, processing in background",
 	})
 }
 
@@ -415,154 +436,62 @@ func (h *mediaHandler) UploadAudio(c *gin.Context) {
 		ContentType: contentType,
 		MediaType:   models.MediaTypeAudio,
 		Category:    "音频", // 音频默认分类
-		Status:      models.MediaStatusReady,
+		Status:      models.MediaStatusProcessing,
 	}
 
-	// 保存媒体记录到数据库
-	if err := h.repo.CreateMedia(media); err != nil {
-		logger.Error("Failed to create media record", zap.Error(err))
+	// 保存媒体记录并加入处理队列
+	if !h.createAndEnqueue(c, media) {
+		return
 	}
 
-	// 返回上传成功结果
-	c.JSON(http.StatusOK, gin.H{
+	// 返回上传成功结果，客户端通过状态接口轮询处理进度
+	c.JSON(http.StatusAccepted, gin.H{
 		"id":          mediaID,
 		"url":         fileURL,
 		"fileName":    fileName,
 		"fileSize":    fileSize,
 		"contentType": contentType,
-		"message":     "Audio upload successful",
+		"status":      media.Status,
+		"message":     "Audio upload successful, processing in background",
 	})
 }
 
-// extractVideoFrames 提取视频第一帧并生成预览图和缩略图
-func (h *mediaHandler) extractVideoFrames(videoPath, originalFileName string) (*string, *string, error) {
-	tempDir := os.TempDir()
-
-	// 生成输出文件名
-	baseFileName := strings.TrimSuffix(originalFileName, filepath.Ext(originalFileName))
-	previewPath := filepath.Join(tempDir, fmt.Sprintf("preview_%s_%s.jpg", baseFileName, uuid.New().String()))
-	thumbnailPath := filepath.Join(tempDir, fmt.Sprintf("thumbnail_%s_%s.jpg", baseFileName, uuid.New().String()))
-
-	defer os.Remove(previewPath)
-	defer os.Remove(thumbnailPath)
-
-	// 添加调试信息
-	logger.Info("Extracting video frames",
-		zap.String("videoPath", videoPath),
-		zap.String("previewPath", previewPath),
-		zap.String("thumbnailPath", thumbnailPath))
-
-	// 提取第一帧 (高质量预览图) - 简化命令
-	err := ffmpeg_go.Input(videoPath).
-		Output(previewPath, ffmpeg_go.KwArgs{
-			"vframes": 1,
-			"ss":      "00:00:01", // 从第1秒开始提取，避免全黑帧
-			"q:v":     2,          // 高质量
-			"vf":      "scale=1280:720:force_original_aspect_ratio=decrease,pad=1280:720:(ow-iw)/2:(oh-ih)/2:black",
-		}).
-		OverWriteOutput().
-		Run()
-
-	if err != nil {
-		logger.Error("Failed to extract preview frame", zap.Error(err))
-		// 尝试更简单的命令
-		err = ffmpeg_go.Input(videoPath).
-			Output(previewPath, ffmpeg_go.KwArgs{
-				"vframes": 1,
-				"ss":      "00:00:01",
-			}).
-			OverWriteOutput().
-			Run()
-
-		if err != nil {
-			logger.Error("Failed to extract preview frame with simple command", zap.Error(err))
-			return nil, nil, err
-		}
-	}
-
-	// 提取第一帧 (缩略图) - 简化命令
-	err = ffmpeg_go.Input(videoPath).
-		Output(thumbnailPath, ffmpeg_go.KwArgs{
-			"vframes": 1,
-			"ss":      "00:00:01", // 从第1秒开始提取，避免全黑帧
-			"q:v":     8,          // 较低质量，用于缩略图
-			"vf":      "scale=320:180:force_original_aspect_ratio=decrease,pad=320:180:(ow-iw)/2:(oh-ih)/2:black",
-		}).
-		OverWriteOutput().
-		Run()
-
-	if err != nil {
-		logger.Error("Failed to extract thumbnail frame", zap.Error(err))
-		// 尝试更简单的命令
-		err = ffmpeg_go.Input(videoPath).
-			Output(thumbnailPath, ffmpeg_go.KwArgs{
-				"vframes": 1,
-				"ss":      "00:00:01",
-				"vf":      "scale=320:180",
-			}).
-			OverWriteOutput().
-			Run()
-
-		if err != nil {
-			logger.Error("Failed to extract thumbnail frame with simple command", zap.Error(err))
-			return nil, nil, err
-		}
-	}
-
-	// 上传预览图到R2
-	previewURL, err := h.uploadImageToR2(previewPath, fmt.Sprintf("preview_%s.jpg", baseFileName))
-	if err != nil {
-		logger.Error("Failed to upload preview image", zap.Error(err))
-		return nil, nil, err
-	}
-
-	// 上传缩略图到R2
-	thumbnailURL, err := h.uploadImageToR2(thumbnailPath, fmt.Sprintf("thumbnail_%s.jpg", baseFileName))
-	if err != nil {
-		logger.Error("Failed to upload thumbnail image", zap.Error(err))
-		return nil, nil, err
-	}
-
-	return previewURL, thumbnailURL, nil
-}
-
-// uploadImageToR2 上传图片到R2存储
-func (h *mediaHandler) uploadImageToR2(imagePath, fileName string) (*string, error) {
-	file, err := os.Open(imagePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	// 获取文件大小
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return nil, err
-	}
-
-	// 上传到R2
-	imageURL, err := h.repo.UploadMedia(file, fileInfo.Size(), fileName, "image/jpeg")
-	if err != nil {
-		return nil, err
+// createAndEnqueue 保存媒体记录并加入处理队列，失败时写入错误响应并返回false
+func (h *mediaHandler) createAndEnqueue(c *gin.Context, media *models.Media) bool {
+	if err := h.processing.Submit(media); err != nil {
+		logger.Error("Failed to submit media for processing", zap.Error(err), zap.String("fileKey", media.FileKey))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Upload failed"})
+		return false
 	}
-
-	return &imageURL, nil
+	return true
 }
 
 // GetAudios 获取公开音频列表 - 前端调用 /public/media/audio
 func (h *mediaHandler) GetAudios(c *gin.Context) {
+	// 排序方式：recent（默认）、popular、trending
+	sort := models.ParsePublicListSort(c.Query("sort"))
+
 	// 获取音频列表 (不需要分页参数，前端未使用)
-	audios, err := h.repo.GetAudios(1, 100) // 获取前100个音频
+	audios, err := h.repo.GetAudios(1, 100, sort) // 获取前100个音频
 	if err != nil {
 		logger.Error("Failed to get audios", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get audios"})
 		return
 	}
 
+	// 获取互动统计
+	stats, err := h.getMediaStats(audios)
+	if err != nil {
+		logger.Error("Failed to get audio engagement stats", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get audios"})
+		return
+	}
+
 	// 转换为列表格式
 	audioList := make([]models.PublicAudioList, len(audios))
 	for i, audio := range audios {
 		audioList[i] = audio.ToPublicAudioList()
+		audioList[i].EngagementCounts = stats[audio.ID].Counts()
 	}
 
 	// 按前端期望的格式返回
@@ -600,3 +529,12 @@ func (h *mediaHandler) GetAudioDetail(c *gin.Context) {
 	audioDetail := media.ToAudioDetail()
 	c.JSON(http.StatusOK, audioDetail)
 }
+
+// getMediaStats 批量获取媒体的点赞、收藏和浏览数量
+func (h *mediaHandler) getMediaStats(media []models.Media) (map[string]*models.EngagementStats, error) {
+	ids := make([]string, len(media))
+	for i := range media {
+		ids[i] = media[i].ID
+	}
+	return h.engagement.GetStats(models.EngagementItemMedia, ids)
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ProgressHandler 定义学习进度处理器接口
+type ProgressHandler interface {
+	GetProgress(c *gin.Context)
+	ReportProgress(c *gin.Context)
+	ResetProgress(c *gin.Context)
+	GetUserProgress(c *gin.Context)
+	GetContinueLearning(c *gin.Context)
+}
+
+// progressHandler 实现学习进度处理器接口
+type progressHandler struct {
+	service service.ProgressService
+}
+
+// NewProgressHandler 创建新的学习进度处理器实例
+func NewProgressHandler(service service.ProgressService) ProgressHandler {
+	return &progressHandler{
+		service: service,
+	}
+}
+
+// GetProgress 获取当前用户对文档或媒体的学习进度，路径为 /progress/:type/:id
+func (h *progressHandler) GetProgress(c *gin.Context) {
+	itemType := models.ProgressItemType(c.Param("type"))
+	itemID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	progress, err := h.service.GetProgress(userIdStr, itemType, itemID)
+	if err != nil {
+		h.handleError(c, err, itemID)
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// ReportProgress 上报学习进度，请求体为 {"position": 0.5, "completed": true}，两个字段都是可选的
+func (h *progressHandler) ReportProgress(c *gin.Context) {
+	itemType := models.ProgressItemType(c.Param("type"))
+	itemID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req models.ProgressUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	progress, err := h.service.ReportProgress(userIdStr, itemType, itemID, req)
+	if err != nil {
+		h.handleError(c, err, itemID)
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// ResetProgress 清除当前用户对文档或媒体的学习进度
+func (h *progressHandler) ResetProgress(c *gin.Context) {
+	itemType := models.ProgressItemType(c.Param("type"))
+	itemID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if err := h.service.ResetProgress(userIdStr, itemType, itemID); err != nil {
+		h.handleError(c, err, itemID)
+		return
+	}
+
+	c.JSON(http.StatusOK, true)
+}
+
+// GetUserProgress 获取当前用户的所有学习进度，可通过?type=document|media过滤
+func (h *progressHandler) GetUserProgress(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	progress, err := h.service.GetUserProgress(userIdStr, models.ProgressItemType(c.Query("type")))
+	if err != nil {
+		h.handleError(c, err, "")
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// GetContinueLearning 获取当前用户最近学习但未完成的内容
+func (h *progressHandler) GetContinueLearning(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	items, err := h.service.GetContinueLearning(userIdStr, limit)
+	if err != nil {
+		h.handleError(c, err, "")
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// handleError 将学习进度服务的错误转换为HTTP响应
+func (h *progressHandler) handleError(c *gin.Context, err error, itemID string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidProgress):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be document or media, and position must be between 0 and 1 for documents or non-negative seconds for media"})
+	case errors.Is(err, service.ErrDocumentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+	case errors.Is(err, service.ErrMediaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+	default:
+		logger.Error("Failed to handle learning progress request", zap.Error(err), zap.String("itemID", itemID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
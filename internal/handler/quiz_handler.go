@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// QuizHandler 定义测验处理器接口
+type QuizHandler interface {
+	CreateQuiz(c *gin.Context)
+	GetQuiz(c *gin.Context)
+	UpdateQuiz(c *gin.Context)
+	DeleteQuiz(c *gin.Context)
+	GetLearnerQuiz(c *gin.Context)
+	GetLearnerQuizByTarget(c *gin.Context)
+	SubmitAttempt(c *gin.Context)
+	GetUserAttempts(c *gin.Context)
+	GetQuizStats(c *gin.Context)
+}
+
+// quizHandler 实现测验处理器接口
+type quizHandler struct {
+	service service.QuizService
+}
+
+// NewQuizHandler 创建新的测验处理器实例
+func NewQuizHandler(service service.QuizService) QuizHandler {
+	return &quizHandler{
+		service: service,
+	}
+}
+
+// submitAttemptRequest 提交测验作答请求
+type submitAttemptRequest struct {
+	Answers []models.QuizAnswer `json:"answers"`
+}
+
+// CreateQuiz 在文档或视频上创建测验
+func (h *quizHandler) CreateQuiz(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req models.QuizInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	quiz, err := h.service.CreateQuiz(userIdStr, req)
+	if err != nil {
+		h.handleError(c, err, "")
+		return
+	}
+
+	c.JSON(http.StatusCreated, quiz)
+}
+
+// GetQuiz 获取包含答案的测验，供测验作者编辑
+func (h *quizHandler) GetQuiz(c *gin.Context) {
+	quizID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	quiz, err := h.service.GetQuiz(quizID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, quizID)
+		return
+	}
+
+	c.JSON(http.StatusOK, quiz)
+}
+
+// UpdateQuiz 更新测验的标题、及格线和题目
+func (h *quizHandler) UpdateQuiz(c *gin.Context) {
+	quizID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req models.QuizInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	quiz, err := h.service.UpdateQuiz(quizID, userIdStr, req)
+	if err != nil {
+		h.handleError(c, err, quizID)
+		return
+	}
+
+	c.JSON(http.StatusOK, quiz)
+}
+
+// DeleteQuiz 删除测验及其所有作答记录
+func (h *quizHandler) DeleteQuiz(c *gin.Context) {
+	quizID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if err := h.service.DeleteQuiz(quizID, userIdStr); err != nil {
+		h.handleError(c, err, quizID)
+		return
+	}
+
+	c.JSON(http.StatusOK, true)
+}
+
+// GetLearnerQuiz 获取不包含答案的测验
+func (h *quizHandler) GetLearnerQuiz(c *gin.Context) {
+	quizID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	quiz, err := h.service.GetLearnerQuiz(quizID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, quizID)
+		return
+	}
+
+	c.JSON(http.StatusOK, quiz)
+}
+
+// GetLearnerQuizByTarget 获取附加在文档或视频上的测验，查询参数为 ?type=document|video&targetId=...
+func (h *quizHandler) GetLearnerQuizByTarget(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	targetID := c.Query("targetId")
+	if targetID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "targetId is required"})
+		return
+	}
+
+	quiz, err := h.service.GetLearnerQuizByTarget(models.QuizTargetType(c.Query("type")), targetID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, "")
+		return
+	}
+
+	c.JSON(http.StatusOK, quiz)
+}
+
+// SubmitAttempt 提交测验作答，返回得分以及每道题的正确答案和解析
+func (h *quizHandler) SubmitAttempt(c *gin.Context) {
+	quizID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req submitAttemptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	result, err := h.service.SubmitAttempt(quizID, userIdStr, req.Answers)
+	if err != nil {
+		h.handleError(c, err, quizID)
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// GetUserAttempts 获取当前用户的作答记录
+func (h *quizHandler) GetUserAttempts(c *gin.Context) {
+	quizID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	attempts, err := h.service.GetUserAttempts(quizID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, quizID)
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
+
+// GetQuizStats 获取测验的作答统计
+func (h *quizHandler) GetQuizStats(c *gin.Context) {
+	quizID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	stats, err := h.service.GetQuizStats(quizID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, quizID)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// handleError 将测验服务的错误转换为HTTP响应
+func (h *quizHandler) handleError(c *gin.Context, err error, quizID string) {
+	var questionErr *service.QuizQuestionError
+	switch {
+	case errors.As(err, &questionErr):
+		response := gin.H{"error": "Invalid quiz: " + questionErr.Reason}
+		if questionErr.Index >= 0 {
+			response["index"] = questionErr.Index
+		}
+		c.JSON(http.StatusBadRequest, response)
+	case errors.Is(err, service.ErrQuizNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+	case errors.Is(err, service.ErrDocumentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+	case errors.Is(err, service.ErrMediaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+	case errors.Is(err, service.ErrPermissionDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the quiz author can manage the quiz"})
+	case errors.Is(err, service.ErrQuizExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "This item already has a quiz"})
+	case errors.Is(err, service.ErrQuizAttemptsExhausted):
+		c.JSON(http.StatusConflict, gin.H{"error": "No attempts left for this quiz"})
+	default:
+		logger.Error("Failed to handle quiz request", zap.Error(err), zap.String("quizID", quizID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
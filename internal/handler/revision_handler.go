@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RevisionHandler 定义文档修订版本处理器接口
+type RevisionHandler interface {
+	ListRevisions(c *gin.Context)
+	GetRevision(c *gin.Context)
+	DiffRevisions(c *gin.Context)
+	RestoreRevision(c *gin.Context)
+}
+
+// revisionHandler 实现文档修订版本处理器接口
+type revisionHandler struct {
+	service service.RevisionService
+}
+
+// NewRevisionHandler 创建新的文档修订版本处理器实例
+func NewRevisionHandler(service service.RevisionService) RevisionHandler {
+	return &revisionHandler{
+		service: service,
+	}
+}
+
+// ListRevisions 获取文档的修订版本列表
+func (h *revisionHandler) ListRevisions(c *gin.Context) {
+	documentID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	revisions, err := h.service.ListRevisions(documentID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, "Failed to list document revisions", documentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetRevision 获取某个修订版本的详情
+func (h *revisionHandler) GetRevision(c *gin.Context) {
+	documentID := c.Param("id")
+	revisionID := c.Param("revisionId")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	revision, err := h.service.GetRevision(documentID, revisionID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, "Failed to get document revision", documentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffRevisions 比较两个修订版本，参数为 ?from=<revisionId>&to=<revisionId>
+func (h *revisionHandler) DiffRevisions(c *gin.Context) {
+	documentID := c.Param("id")
+	fromID := c.Query("from")
+	toID := c.Query("to")
+
+	if fromID == "" || toID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Both from and to revision IDs are required"})
+		return
+	}
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	diff, err := h.service.DiffRevisions(documentID, fromID, toID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, "Failed to diff document revisions", documentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// RestoreRevision 将修订版本恢复为文档的当前版本
+func (h *revisionHandler) RestoreRevision(c *gin.Context) {
+	documentID := c.Param("id")
+	revisionID := c.Param("revisionId")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	doc, err := h.service.RestoreRevision(documentID, revisionID, userIdStr)
+	if respondVersionConflict(c, err) {
+		return
+	}
+	if err != nil {
+		h.handleError(c, err, "Failed to restore document revision", documentID)
+		return
+	}
+
+	c.Header("ETag", doc.ETag())
+	c.JSON(http.StatusOK, doc)
+}
+
+// handleError 将服务层错误转换为HTTP响应
+func (h *revisionHandler) handleError(c *gin.Context, err error, msg string, documentID string) {
+	switch {
+	case errors.Is(err, service.ErrDocumentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+	case errors.Is(err, service.ErrPermissionDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "No permission to access document revisions"})
+	case errors.Is(err, service.ErrRevisionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+	default:
+		logger.Error(msg, zap.Error(err), zap.String("documentID", documentID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
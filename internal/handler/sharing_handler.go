@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/service"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SharingHandler 定义文档共享处理器接口
+type SharingHandler interface {
+	ListCollaborators(c *gin.Context)
+	InviteCollaborator(c *gin.Context)
+	RevokeCollaborator(c *gin.Context)
+	GetSharedDocs(c *gin.Context)
+	CreateShareLink(c *gin.Context)
+	ListShareLinks(c *gin.Context)
+	RevokeShareLink(c *gin.Context)
+	GetSharedDocument(c *gin.Context)
+}
+
+// sharingHandler 实现文档共享处理器接口
+type sharingHandler struct {
+	service       service.SharingService
+	collaboration service.CollaborationService
+}
+
+// NewSharingHandler 创建新的文档共享处理器实例，collaboration为nil时不断开被移除协作者的实时协作连接
+func NewSharingHandler(service service.SharingService, collaboration service.CollaborationService) SharingHandler {
+	return &sharingHandler{
+		service:       service,
+		collaboration: collaboration,
+	}
+}
+
+// inviteCollaboratorRequest 邀请协作者请求
+type inviteCollaboratorRequest struct {
+	UserID string              `json:"userId" binding:"required"`
+	Role   models.DocumentRole `json:"role" binding:"required"`
+}
+
+// createShareLinkRequest 创建分享链接请求
+type createShareLinkRequest struct {
+	Role      models.DocumentRole `json:"role"`
+	Password  string              `json:"password"`
+	ExpiresAt *time.Time          `json:"expiresAt"`
+}
+
+// ListCollaborators 获取文档的协作者列表
+func (h *sharingHandler) ListCollaborators(c *gin.Context) {
+	documentID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	collaborators, err := h.service.ListCollaborators(documentID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, "Failed to list document collaborators", documentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, collaborators)
+}
+
+// InviteCollaborator 邀请用户协作或修改协作者角色
+func (h *sharingHandler) InviteCollaborator(c *gin.Context) {
+	documentID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var req inviteCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	collaborator, err := h.service.InviteCollaborator(documentID, userIdStr, req.UserID, req.Role)
+	if err != nil {
+		h.handleError(c, err, "Failed to invite document collaborator", documentID)
+		return
+	}
+
+	// 角色降级为不能编辑时断开该用户的实时协作连接
+	if h.collaboration != nil {
+		h.collaboration.RefreshAccess(documentID, req.UserID)
+	}
+
+	c.JSON(http.StatusOK, collaborator)
+}
+
+// RevokeCollaborator 移除协作者
+func (h *sharingHandler) RevokeCollaborator(c *gin.Context) {
+	documentID := c.Param("id")
+	collaboratorID := c.Param("userId")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if err := h.service.RevokeCollaborator(documentID, userIdStr, collaboratorID); err != nil {
+		h.handleError(c, err, "Failed to revoke document collaborator", documentID)
+		return
+	}
+
+	// 被移除的协作者立即退出实时协作
+	if h.collaboration != nil {
+		h.collaboration.RefreshAccess(documentID, collaboratorID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// GetSharedDocs 获取共享给当前用户的文档列表
+func (h *sharingHandler) GetSharedDocs(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	docs, err := h.service.GetSharedDocs(userIdStr)
+	if err != nil {
+		logger.Error("Failed to get shared documents", zap.Error(err), zap.String("userID", userIdStr))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, docs)
+}
+
+// CreateShareLink 创建私密分享链接，role默认为viewer
+func (h *sharingHandler) CreateShareLink(c *gin.Context) {
+	documentID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	// 请求体可以为空，此时使用默认设置
+	var req createShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.DocumentRoleViewer
+	}
+
+	link, err := h.service.CreateShareLink(documentID, userIdStr, req.Role, req.Password, req.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRole):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be viewer or commenter"})
+		case errors.Is(err, service.ErrInvalidExpiry):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be in the future"})
+		default:
+			h.handleError(c, err, "Failed to create share link", documentID)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// ListShareLinks 获取文档的分享链接列表
+func (h *sharingHandler) ListShareLinks(c *gin.Context) {
+	documentID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	links, err := h.service.ListShareLinks(documentID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, "Failed to list share links", documentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// RevokeShareLink 撤销分享链接
+func (h *sharingHandler) RevokeShareLink(c *gin.Context) {
+	documentID := c.Param("id")
+	linkID := c.Param("linkId")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if err := h.service.RevokeShareLink(documentID, userIdStr, linkID); err != nil {
+		h.handleError(c, err, "Failed to revoke share link", documentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// GetSharedDocument 通过分享链接获取文档，不需要登录。
+// 设置了密码的链接需要在X-Share-Password请求头中提供密码
+func (h *sharingHandler) GetSharedDocument(c *gin.Context) {
+	token := c.Param("token")
+	password := c.GetHeader("X-Share-Password")
+
+	doc, err := h.service.GetSharedDocument(token, password)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrShareLinkNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		case errors.Is(err, service.ErrShareLinkExpired):
+			c.JSON(http.StatusGone, gin.H{"error": "Share link has expired"})
+		case errors.Is(err, service.ErrPasswordRequired):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Password required", "passwordRequired": true})
+		case errors.Is(err, service.ErrInvalidPassword):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password", "passwordRequired": true})
+		default:
+			logger.Error("Failed to get shared document", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}
+		return
+	}
+
+	// 私密文档不允许被缓存
+	c.Header("Cache-Control", "private, no-store")
+	c.JSON(http.StatusOK, doc)
+}
+
+// handleError 将服务层错误转换为HTTP响应
+func (h *sharingHandler) handleError(c *gin.Context, err error, msg string, documentID string) {
+	switch {
+	case errors.Is(err, service.ErrDocumentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+	case errors.Is(err, service.ErrPermissionDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the document owner can manage sharing"})
+	case errors.Is(err, service.ErrCollaboratorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collaborator not found"})
+	case errors.Is(err, service.ErrShareLinkNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+	case errors.Is(err, service.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be one of viewer, commenter or editor"})
+	case errors.Is(err, service.ErrInvalidCollaborator):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The document owner cannot be invited as a collaborator"})
+	default:
+		logger.Error(msg, zap.Error(err), zap.String("documentID", documentID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TrashHandler 定义回收站处理器接口
+type TrashHandler interface {
+	GetTrash(c *gin.Context)
+	RestoreDoc(c *gin.Context)
+	PurgeDoc(c *gin.Context)
+}
+
+// trashHandler 实现回收站处理器接口
+type trashHandler struct {
+	service service.TrashService
+}
+
+// NewTrashHandler 创建新的回收站处理器实例
+func NewTrashHandler(service service.TrashService) TrashHandler {
+	return &trashHandler{
+		service: service,
+	}
+}
+
+// GetTrash 获取当前用户回收站中的文档
+func (h *trashHandler) GetTrash(c *gin.Context) {
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	docs, err := h.service.GetTrash(userIdStr)
+	if err != nil {
+		logger.Error("Failed to get trash", zap.Error(err), zap.String("userID", userIdStr))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, docs)
+}
+
+// RestoreDoc 恢复回收站中的文档
+func (h *trashHandler) RestoreDoc(c *gin.Context) {
+	documentID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	doc, err := h.service.RestoreDoc(documentID, userIdStr)
+	if err != nil {
+		h.handleError(c, err, documentID)
+		return
+	}
+
+	c.Header("ETag", doc.ETag())
+	c.JSON(http.StatusOK, doc)
+}
+
+// PurgeDoc 永久删除回收站中的文档
+func (h *trashHandler) PurgeDoc(c *gin.Context) {
+	documentID := c.Param("id")
+
+	userIdStr, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if err := h.service.PurgeDoc(documentID, userIdStr); err != nil {
+		h.handleError(c, err, documentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, true)
+}
+
+// handleError 将回收站服务的错误转换为HTTP响应
+func (h *trashHandler) handleError(c *gin.Context, err error, documentID string) {
+	if errors.Is(err, service.ErrDocumentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found in trash"})
+		return
+	}
+	logger.Error("Failed to handle trash request", zap.Error(err), zap.String("documentID", documentID))
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// UploadHandler 定义可断点续传的分片上传处理器接口
+type UploadHandler interface {
+	// 创建上传会话
+	CreateUpload(c *gin.Context)
+	// 创建直传会话
+	CreateDirectUpload(c *gin.Context)
+	// 获取直传分片的预签名地址
+	PresignParts(c *gin.Context)
+	// 获取上传会话和已上传的分片
+	GetUpload(c *gin.Context)
+	// 上传一个分片
+	UploadPart(c *gin.Context)
+	// 合并分片完成上传
+	CompleteUpload(c *gin.Context)
+	// 取消上传
+	AbortUpload(c *gin.Context)
+}
+
+// uploadHandler 实现分片上传处理器接口
+type uploadHandler struct {
+	service service.UploadService
+}
+
+// NewUploadHandler 创建新的分片上传处理器实例
+func NewUploadHandler(service service.UploadService) UploadHandler {
+	return &uploadHandler{
+		service: service,
+	}
+}
+
+// CreateUpload 创建上传会话，返回分片大小和分片总数
+func (h *uploadHandler) CreateUpload(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.UploadSessionInit
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	// 如果未提供Content-Type，尝试根据文件名推断
+	if req.ContentType == "" {
+		req.ContentType = inferContentType(req.FileName)
+	}
+
+	session, err := h.service.CreateSession(userID, req)
+	if err != nil {
+		h.handleError(c, err, "")
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// CreateDirectUpload 创建直传会话，文件由客户端使用预签名地址直接上传到存储，不经过服务器。
+// 整个文件一次上传时响应中包含uploadUrl，分片上传时通过PresignParts获取分片地址
+func (h *uploadHandler) CreateDirectUpload(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.UploadSessionInit
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	// 如果未提供Content-Type，尝试根据文件名推断
+	if req.ContentType == "" {
+		req.ContentType = inferContentType(req.FileName)
+	}
+
+	session, err := h.service.CreateDirectSession(userID, req)
+	if err != nil {
+		h.handleError(c, err, "")
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// presignPartsRequest 获取预签名分片地址的请求
+type presignPartsRequest struct {
+	PartNumbers []int `json:"partNumbers" binding:"required"`
+}
+
+// PresignParts 获取直传分片的预签名地址，地址过期后可以重新获取
+func (h *uploadHandler) PresignParts(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req presignPartsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	sessionID := c.Param("id")
+	urls, err := h.service.PresignParts(sessionID, userID, req.PartNumbers)
+	if err != nil {
+		h.handleError(c, err, sessionID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"urls": urls})
+}
+
+// GetUpload 获取上传会话，客户端中断后根据offset和uploadedParts续传
+func (h *uploadHandler) GetUpload(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	sessionID := c.Param("id")
+	session, err := h.service.GetSession(sessionID, userID)
+	if err != nil {
+		h.handleError(c, err, sessionID)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// UploadPart 上传一个分片，请求体为分片的原始内容。
+// 可以通过X-Checksum-SHA256请求头提供分片内容的SHA-256（十六进制）以校验传输完整性
+func (h *uploadHandler) UploadPart(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	sessionID := c.Param("id")
+	partNumber, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid part number"})
+		return
+	}
+
+	part, err := h.service.UploadPart(sessionID, userID, partNumber, c.Request.Body, c.GetHeader("X-Checksum-SHA256"))
+	if err != nil {
+		h.handleError(c, err, sessionID)
+		return
+	}
+
+	c.JSON(http.StatusOK, part)
+}
+
+// CompleteUpload 合并分片完成上传，媒体在后台处理
+func (h *uploadHandler) CompleteUpload(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	sessionID := c.Param("id")
+	media, err := h.service.Complete(sessionID, userID)
+	if err != nil {
+		h.handleError(c, err, sessionID)
+		return
+	}
+
+	// 与直接上传的响应一致，客户端通过状态接口轮询处理进度
+	c.JSON(http.StatusAccepted, gin.H{
+		"id":          media.ID,
+		"url":         media.FileURL,
+		"fileName":    media.FileName,
+		"fileSize":    media.FileSize,
+		"contentType": media.ContentType,
+		"category":    media.Category,
+		"status":      media.Status,
+		"message":     "Upload completed, processing in background",
+	})
+}
+
+// AbortUpload 取消上传
+func (h *uploadHandler) AbortUpload(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		logger.Error("User ID not found")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	sessionID := c.Param("id")
+	if err := h.service.Abort(sessionID, userID); err != nil {
+		h.handleError(c, err, sessionID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Upload aborted successfully",
+	})
+}
+
+// handleError 将服务层错误转换为HTTP响应
+func (h *uploadHandler) handleError(c *gin.Context, err error, sessionID string) {
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+	case errors.Is(err, service.ErrInvalidUpload):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mediaType must be video or audio, contentType must match it, and fileName and a positive fileSize are required"})
+	case errors.Is(err, service.ErrInvalidUploadPart):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Part number is out of range, part size does not match the session, or too many parts were requested"})
+	case errors.Is(err, service.ErrChecksumMismatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Part checksum mismatch"})
+	case errors.Is(err, service.ErrUploadIncomplete):
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload is incomplete"})
+	case errors.Is(err, service.ErrUploadClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload session is completed, aborted or expired"})
+	case errors.Is(err, service.ErrUploadModeMismatch):
+		c.JSON(http.StatusConflict, gin.H{"error": "Operation is not supported by this upload session"})
+	default:
+		logger.Error("Failed to handle upload request", zap.Error(err), zap.String("sessionID", sessionID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
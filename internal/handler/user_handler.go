@@ -17,13 +17,25 @@ type UserHandler interface {
 
 // userHandler 实现用户处理器接口
 type userHandler struct {
-	docRepo repository.DocumentRepository
+	docRepo          repository.DocumentRepository
+	collaboratorRepo repository.DocumentCollaboratorRepository
+	courseRepo       repository.CourseRepository
+	progressRepo     repository.LearningProgressRepository
+	quizRepo         repository.QuizRepository
+	commentRepo      repository.DocumentCommentRepository
+	engagementRepo   repository.EngagementRepository
 }
 
 // NewUserHandler 创建新的用户处理器实例
-func NewUserHandler(docRepo repository.DocumentRepository) UserHandler {
+func NewUserHandler(docRepo repository.DocumentRepository, collaboratorRepo repository.DocumentCollaboratorRepository, courseRepo repository.CourseRepository, progressRepo repository.LearningProgressRepository, quizRepo repository.QuizRepository, commentRepo repository.DocumentCommentRepository, engagementRepo repository.EngagementRepository) UserHandler {
 	return &userHandler{
-		docRepo: docRepo,
+		docRepo:          docRepo,
+		collaboratorRepo: collaboratorRepo,
+		courseRepo:       courseRepo,
+		progressRepo:     progressRepo,
+		quizRepo:         quizRepo,
+		commentRepo:      commentRepo,
+		engagementRepo:   engagementRepo,
 	}
 }
 
@@ -65,6 +77,48 @@ func (h *userHandler) UpdateStoriesUser(c *gin.Context) {
 		return
 	}
 
+	// 迁移虚拟用户被授予的协作者角色
+	if _, err := h.collaboratorRepo.UpdateUserID(virtualUserId, newUserId); err != nil {
+		logger.Error("Failed to migrate document collaborators", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	// 迁移虚拟用户创建的课程
+	if _, err := h.courseRepo.UpdateOwnerID(virtualUserId, newUserId); err != nil {
+		logger.Error("Failed to migrate user courses", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	// 迁移虚拟用户的学习进度，与新用户已有的进度合并
+	if _, err := h.progressRepo.UpdateUserID(virtualUserId, newUserId); err != nil {
+		logger.Error("Failed to migrate learning progress", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	// 迁移虚拟用户创建的测验和作答记录
+	if _, err := h.quizRepo.UpdateUserID(virtualUserId, newUserId); err != nil {
+		logger.Error("Failed to migrate quizzes", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	// 迁移虚拟用户发表的评论和举报记录
+	if _, err := h.commentRepo.UpdateUserID(virtualUserId, newUserId); err != nil {
+		logger.Error("Failed to migrate document comments", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	// 迁移虚拟用户的点赞和收藏，与新用户已有的记录合并
+	if _, err := h.engagementRepo.UpdateUserID(virtualUserId, newUserId); err != nil {
+		logger.Error("Failed to migrate likes and bookmarks", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
 	// 返回迁移成功的信息
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
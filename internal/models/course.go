@@ -0,0 +1,165 @@
+package models
+
+import (
+	"time"
+)
+
+// LessonType 课程章节引用的内容类型
+type LessonType string
+
+const (
+	LessonTypeDocument LessonType = "document"
+	LessonTypeVideo    LessonType = "video"
+	LessonTypeAudio    LessonType = "audio"
+)
+
+// IsValid 判断章节类型是否有效
+func (t LessonType) IsValid() bool {
+	switch t {
+	case LessonTypeDocument, LessonTypeVideo, LessonTypeAudio:
+		return true
+	}
+	return false
+}
+
+// Course 课程（学习路径），按顺序引用文档、视频和音频
+type Course struct {
+	ID          string         `gorm:"primaryKey" json:"id"`
+	OwnerID     string         `gorm:"index" json:"ownerId"`
+	Title       string         `json:"title"`
+	Description string         `gorm:"type:text" json:"description"`
+	CoverImage  *Image         `gorm:"type:jsonb" json:"coverImage,omitempty"`
+	IsPublic    *bool          `gorm:"default:false" json:"isPublic"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	Lessons     []CourseLesson `gorm:"foreignKey:CourseID;constraint:OnDelete:CASCADE" json:"lessons"`
+}
+
+// CourseLesson 课程章节，引用一个文档或媒体
+type CourseLesson struct {
+	ID        uint       `gorm:"primaryKey" json:"-"`
+	CourseID  string     `gorm:"index" json:"-"`
+	Position  int        `gorm:"not null" json:"position"`
+	ItemType  LessonType `gorm:"type:varchar(16)" json:"type"`
+	ItemID    string     `gorm:"index" json:"itemId"`
+	Title     string     `json:"title,omitempty"` // 自定义章节标题，为空时使用引用内容的标题
+	CreatedAt time.Time  `json:"-"`
+}
+
+// CourseList 课程列表项模型
+type CourseList struct {
+	ID          string    `json:"id"`
+	OwnerID     string    `json:"ownerId"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	CoverImage  *Image    `json:"coverImage,omitempty"`
+	IsPublic    bool      `json:"isPublic"`
+	LessonCount int       `json:"lessonCount"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// CourseDetail 课程详情，章节包含引用内容的摘要信息
+type CourseDetail struct {
+	ID          string          `json:"id"`
+	OwnerID     string          `json:"ownerId"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	CoverImage  *Image          `json:"coverImage,omitempty"`
+	IsPublic    bool            `json:"isPublic"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+	Lessons     []LessonSummary `json:"lessons"`
+}
+
+// LessonSummary 课程章节及其引用内容的摘要
+type LessonSummary struct {
+	Position  int        `json:"position"`
+	Type      LessonType `json:"type"`
+	ItemID    string     `json:"itemId"`
+	Title     string     `json:"title"`
+	Thumbnail *string    `json:"thumbnail,omitempty"` // 文档的图标或视频缩略图
+	Duration  string     `json:"duration,omitempty"`  // 媒体时长，格式如"25:30"
+	Available bool       `json:"available"`           // 引用内容是否仍然存在且可见
+}
+
+// ToCourseList 将Course转换为CourseList
+func (c *Course) ToCourseList() CourseList {
+	return CourseList{
+		ID:          c.ID,
+		OwnerID:     c.OwnerID,
+		Title:       c.Title,
+		Description: c.Description,
+		CoverImage:  c.CoverImage,
+		IsPublic:    c.IsPublic != nil && *c.IsPublic,
+		LessonCount: len(c.Lessons),
+		UpdatedAt:   c.UpdatedAt,
+	}
+}
+
+// ToCourseDetail 将Course转换为CourseDetail，lessons为已解析引用内容的章节摘要
+func (c *Course) ToCourseDetail(lessons []LessonSummary) CourseDetail {
+	if lessons == nil {
+		lessons = []LessonSummary{}
+	}
+	return CourseDetail{
+		ID:          c.ID,
+		OwnerID:     c.OwnerID,
+		Title:       c.Title,
+		Description: c.Description,
+		CoverImage:  c.CoverImage,
+		IsPublic:    c.IsPublic != nil && *c.IsPublic,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+		Lessons:     lessons,
+	}
+}
+
+// LessonSummaryFromDocument 使用文档信息生成章节摘要
+func LessonSummaryFromDocument(lesson CourseLesson, doc *Document) LessonSummary {
+	summary := LessonSummary{
+		Position: lesson.Position,
+		Type:     lesson.ItemType,
+		ItemID:   lesson.ItemID,
+		Title:    lesson.Title,
+	}
+	if doc == nil {
+		return summary
+	}
+	summary.Available = true
+	if summary.Title == "" {
+		summary.Title = doc.Title
+	}
+	if doc.IconImage != nil {
+		summary.Thumbnail = &doc.IconImage.URL
+	}
+	return summary
+}
+
+// LessonSummaryFromMedia 使用媒体信息生成章节摘要
+func LessonSummaryFromMedia(lesson CourseLesson, media *Media) LessonSummary {
+	summary := LessonSummary{
+		Position: lesson.Position,
+		Type:     lesson.ItemType,
+		ItemID:   lesson.ItemID,
+		Title:    lesson.Title,
+	}
+	if media == nil {
+		return summary
+	}
+	summary.Available = true
+	if summary.Title == "" {
+		summary.Title = media.Title
+	}
+	summary.Thumbnail = media.Thumbnail
+	if media.Meta != nil && media.Meta.Duration != nil {
+		summary.Duration = formatDuration(*media.Meta.Duration)
+	}
+	return summary
+}
+
+// CourseUpdate 课程基本信息的更新内容，nil字段表示不修改
+type CourseUpdate struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	CoverImage  *Image  `json:"coverImage"` // URL为空时移除封面
+}
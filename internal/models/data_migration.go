@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// 一次性数据迁移的名称
+const (
+	DataMigrationDocumentTags = "document_tags" // 为标签持久化之前保存的文档补充标签
+)
+
+// DataMigration 已完成的一次性数据迁移，用于避免每次启动时重复扫描
+type DataMigration struct {
+	Name        string    `gorm:"primaryKey" json:"name"`
+	CompletedAt time.Time `json:"completedAt"`
+}
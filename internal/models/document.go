@@ -1,10 +1,15 @@
 package models
 
 import (
+	"betalyr-learning-server/internal/pkg/editorjson"
+	"betalyr-learning-server/internal/pkg/search"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"gorm.io/gorm"
 )
@@ -46,6 +51,32 @@ func (j *JSONContent) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, &j)
 }
 
+// StringList 以JSON数组形式存储的字符串列表
+type StringList []string
+
+// Value 实现driver.Valuer接口
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return json.Marshal([]string{})
+	}
+	return json.Marshal([]string(l))
+}
+
+// Scan 实现sql.Scanner接口
+func (l *StringList) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, l)
+}
+
+// 标签限制
+const (
+	maxTagLength = 50
+	maxTagCount  = 20
+)
+
 // Document 文档模型
 type Document struct {
 	ID         string       `gorm:"primaryKey" json:"id"`
@@ -57,23 +88,89 @@ type Document struct {
 	CoverImage *Image       `gorm:"type:jsonb" json:"coverImage,omitempty"`
 	EditorJSON *JSONContent `gorm:"type:jsonb" json:"editorJson,omitempty"`
 	IsPublic   *bool        `gorm:"default:false" json:"isPublic,omitempty"`
+	Version    int64        `gorm:"not null;default:1" json:"version"`         // 乐观锁版本号，每次保存递增
+	Tags       StringList   `gorm:"type:jsonb;default:'[]'" json:"tags"`       // 有效标签（手动设置的标签与内容中提取的标签合并）
+	ManualTags StringList   `gorm:"type:jsonb;default:'[]'" json:"manualTags"` // 通过UpdateDoc手动设置的标签
+	ParentID   *string      `gorm:"index" json:"parentId"`                     // 父文档ID，为空表示顶层文档
+	Position   int          `gorm:"not null;default:0" json:"position"`        // 在同级文档中的排序位置，从0开始
+	Slug       *string      `gorm:"uniqueIndex" json:"slug,omitempty"`         // 公开访问使用的短名称，首次公开时根据标题生成
+	SlugCustom bool         `gorm:"not null;default:false" json:"slugCustom"`  // 短名称是否由所有者手动设置，手动设置后不再随标题变化
+	// 全文搜索使用的分词后文本，search_vector列由数据库触发器根据这两列维护
+	SearchTitle string `gorm:"type:text" json:"-"`
+	SearchText  string `gorm:"type:text" json:"-"`
+	// 移入回收站的时间，不为空的文档不会出现在普通查询中
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // DocumentList 文档列表项模型
 type DocumentList struct {
-	ID         string `json:"id"`
-	Title      string `json:"title"`
-	CoverImage *Image `json:"coverImage,omitempty"`
+	ID         string  `json:"id"`
+	Title      string  `json:"title"`
+	CoverImage *Image  `json:"coverImage,omitempty"`
+	ParentID   *string `json:"parentId"`
+	Position   int     `json:"position"`
+}
+
+// DocumentTreeNode 文档树节点，用于侧边栏展示嵌套页面
+type DocumentTreeNode struct {
+	ID        string              `json:"id"`
+	ParentID  *string             `json:"parentId"`
+	Title     string              `json:"title"`
+	IconImage *Image              `json:"iconImage,omitempty"`
+	IsPublic  bool                `json:"isPublic"`
+	Position  int                 `json:"position"`
+	Children  []*DocumentTreeNode `json:"children"`
+}
+
+// TrashDocumentList 回收站文档列表项模型
+type TrashDocumentList struct {
+	ID         string     `json:"id"`
+	Title      string     `json:"title"`
+	CoverImage *Image     `json:"coverImage,omitempty"`
+	DeletedAt  time.Time  `json:"deletedAt"`
+	PurgeAt    *time.Time `json:"purgeAt,omitempty"` // 自动永久删除的时间，未开启自动清理时为空
 }
 
 // PublicDocumentList 公开文档列表项模型
 type PublicDocumentList struct {
 	ID        string    `json:"id"`
+	Slug      string    `json:"slug,omitempty"`
 	Title     string    `json:"title"`
 	IconImage *Image    `json:"iconImage,omitempty"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	Tags      []string  `json:"tags,omitempty"`
+	EngagementCounts
+}
+
+// DocumentSearchQuery 文档全文搜索条件
+type DocumentSearchQuery struct {
+	Query      string // 搜索关键词（已分词）
+	Tag        string // 按标签过滤
+	OwnerID    string // 按作者过滤
+	PublicOnly bool   // 只搜索公开文档
+	Page       int
+	Limit      int
+}
+
+// DocumentSearchResult 文档全文搜索结果
+type DocumentSearchResult struct {
+	ID        string     `json:"id"`
+	OwnerID   string     `json:"ownerId"`
+	Title     string     `json:"title"`
+	IconImage *Image     `json:"iconImage,omitempty"`
+	Tags      StringList `json:"tags"`
+	IsPublic  bool       `json:"isPublic"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	Rank      float64    `json:"rank"`
+	Snippet   string     `json:"snippet"` // 带<mark>高亮的正文片段（HTML）
+}
+
+// TagCount 标签及使用该标签的文档数量
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
 }
 
 // BeforeCreate 在创建文档前设置默认值
@@ -83,29 +180,135 @@ func (d *Document) BeforeCreate(tx *gorm.DB) error {
 		isPublic := false
 		d.IsPublic = &isPublic
 	}
+	// 新文档从版本1开始
+	if d.Version == 0 {
+		d.Version = 1
+	}
 	return nil
 }
 
+// ETag 返回文档当前版本对应的ETag
+func (d *Document) ETag() string {
+	return fmt.Sprintf("\"%d\"", d.Version)
+}
+
 // ToDocumentList 将Document转换为DocumentList
 func (d *Document) ToDocumentList() DocumentList {
 	return DocumentList{
 		ID:         d.ID,
 		Title:      d.Title,
 		CoverImage: d.CoverImage,
+		ParentID:   d.ParentID,
+		Position:   d.Position,
+	}
+}
+
+// BuildDocumentTree 将文档列表组装为树，docs需要已按同级顺序排序；父文档不在列表中的文档作为顶层节点
+func BuildDocumentTree(docs []Document) []*DocumentTreeNode {
+	nodes := make(map[string]*DocumentTreeNode, len(docs))
+	for i := range docs {
+		doc := &docs[i]
+		nodes[doc.ID] = &DocumentTreeNode{
+			ID:        doc.ID,
+			ParentID:  doc.ParentID,
+			Title:     doc.Title,
+			IconImage: doc.IconImage,
+			IsPublic:  doc.IsPublic != nil && *doc.IsPublic,
+			Position:  doc.Position,
+			Children:  []*DocumentTreeNode{},
+		}
+	}
+
+	roots := []*DocumentTreeNode{}
+	for i := range docs {
+		node := nodes[docs[i].ID]
+		if node.ParentID != nil {
+			if parent, ok := nodes[*node.ParentID]; ok && parent != node {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+// ToTrashDocumentList 将回收站中的Document转换为TrashDocumentList，retention为保留时长，不大于0表示不自动清理
+func (d *Document) ToTrashDocumentList(retention time.Duration) TrashDocumentList {
+	item := TrashDocumentList{
+		ID:         d.ID,
+		Title:      d.Title,
+		CoverImage: d.CoverImage,
+		DeletedAt:  d.DeletedAt.Time,
+	}
+	if retention > 0 {
+		purgeAt := d.DeletedAt.Time.Add(retention)
+		item.PurgeAt = &purgeAt
 	}
+	return item
 }
 
 // ToPublicDocumentList 将Document转换为PublicDocumentList
 func (d *Document) ToPublicDocumentList() PublicDocumentList {
-	// TODO: 未来可以添加从EditorJSON中提取tags的逻辑
-	return PublicDocumentList{
+	tags := []string(d.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	item := PublicDocumentList{
 		ID:        d.ID,
 		Title:     d.Title,
 		IconImage: d.IconImage,
 		CreatedAt: d.CreatedAt,
 		UpdatedAt: d.UpdatedAt,
-		Tags:      []string{}, // 暂时返回空数组
+		Tags:      tags,
+	}
+	if d.Slug != nil {
+		item.Slug = *d.Slug
+	}
+	return item
+}
+
+// RefreshIndex 重新计算由内容派生的字段（标签和全文搜索文本），保存文档前调用
+func (d *Document) RefreshIndex() {
+	d.RefreshTags()
+	d.RefreshSearchText()
+}
+
+// RefreshSearchText 重新计算全文搜索使用的分词文本
+func (d *Document) RefreshSearchText() {
+	d.SearchTitle = search.Segment(d.Title)
+	if d.EditorJSON != nil {
+		d.SearchText = search.Segment(editorjson.PlainText(*d.EditorJSON))
+	} else {
+		d.SearchText = ""
+	}
+}
+
+// RefreshTags 根据手动设置的标签和EditorJSON中的标签重新计算有效标签
+func (d *Document) RefreshTags() {
+	tags := append([]string{}, d.ManualTags...)
+	if d.EditorJSON != nil {
+		tags = append(tags, editorjson.Tags(*d.EditorJSON)...)
+	}
+	d.Tags = NormalizeTags(tags)
+}
+
+// NormalizeTags 规范化标签：去除#前缀和空白、转为小写、去重并限制长度和数量
+func NormalizeTags(tags []string) StringList {
+	result := make(StringList, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(tag), "#")))
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLength || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+		if len(result) >= maxTagCount {
+			break
+		}
 	}
+	return result
 }
 
 // ToEmptyDocument 将Document转换为不包含内容的空文档
@@ -116,5 +319,6 @@ func (d *Document) ToEmptyDocument() map[string]interface{} {
 		"title":     d.Title,
 		"createdAt": d.CreatedAt,
 		"updatedAt": d.UpdatedAt,
+		"version":   d.Version,
 	}
 }
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+// DocumentRole 用户对文档的角色
+type DocumentRole string
+
+const (
+	DocumentRoleOwner     DocumentRole = "owner"     // 所有者
+	DocumentRoleEditor    DocumentRole = "editor"    // 可编辑
+	DocumentRoleCommenter DocumentRole = "commenter" // 可评论
+	DocumentRoleViewer    DocumentRole = "viewer"    // 只读
+)
+
+// 角色等级，等级高的角色拥有等级低的角色的所有权限
+var documentRoleRank = map[DocumentRole]int{
+	DocumentRoleViewer:    1,
+	DocumentRoleCommenter: 2,
+	DocumentRoleEditor:    3,
+	DocumentRoleOwner:     4,
+}
+
+// AtLeast 判断角色是否拥有required角色的权限
+func (r DocumentRole) AtLeast(required DocumentRole) bool {
+	rank, ok := documentRoleRank[r]
+	return ok && rank >= documentRoleRank[required]
+}
+
+// IsCollaboratorRole 判断角色是否可以授予协作者（所有者除外）
+func (r DocumentRole) IsCollaboratorRole() bool {
+	return r == DocumentRoleEditor || r == DocumentRoleCommenter || r == DocumentRoleViewer
+}
+
+// DocumentCollaborator 文档协作者，记录授予其他用户的角色
+type DocumentCollaborator struct {
+	ID         string       `gorm:"primaryKey" json:"id"`
+	DocumentID string       `gorm:"uniqueIndex:idx_document_collaborator" json:"documentId"`
+	UserID     string       `gorm:"uniqueIndex:idx_document_collaborator;index" json:"userId"`
+	Role       DocumentRole `json:"role"`
+	InvitedBy  string       `json:"invitedBy"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	UpdatedAt  time.Time    `json:"updatedAt"`
+}
+
+// SharedDocumentList 共享给当前用户的文档列表项模型
+type SharedDocumentList struct {
+	ID         string       `json:"id"`
+	Title      string       `json:"title"`
+	CoverImage *Image       `json:"coverImage,omitempty"`
+	OwnerID    string       `json:"ownerId"`
+	Role       DocumentRole `json:"role"`
+	SharedAt   time.Time    `json:"sharedAt"`
+}
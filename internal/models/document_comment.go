@@ -0,0 +1,115 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CommentAnchor 评论在文档中的锚点。BlockID为空时From/To是文档纯文本中的字符偏移，
+// 否则是该块文本中的字符偏移；From/To为空时锚定整个块
+type CommentAnchor struct {
+	BlockID string `json:"blockId,omitempty"`
+	From    *int   `json:"from,omitempty"`
+	To      *int   `json:"to,omitempty"`
+	Quote   string `json:"quote,omitempty"` // 创建评论时锚定的原文，文档修改后可用于重新定位
+}
+
+// Value 实现driver.Valuer接口
+func (a CommentAnchor) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Scan 实现sql.Scanner接口
+func (a *CommentAnchor) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, &a)
+}
+
+// DocumentComment 文档评论。ParentID为空的评论是讨论串的第一条，回复的ParentID指向它
+type DocumentComment struct {
+	ID          string         `gorm:"primaryKey" json:"id"`
+	DocumentID  string         `gorm:"index" json:"documentId"`
+	AuthorID    string         `gorm:"index" json:"authorId"`
+	ParentID    *string        `gorm:"index" json:"parentId"`
+	Anchor      *CommentAnchor `gorm:"type:jsonb" json:"anchor,omitempty"`
+	Content     string         `gorm:"type:text" json:"content"`
+	Resolved    bool           `gorm:"not null;default:false" json:"resolved"`
+	ResolvedBy  *string        `json:"resolvedBy,omitempty"`
+	ResolvedAt  *time.Time     `json:"resolvedAt,omitempty"`
+	Hidden      bool           `gorm:"not null;default:false" json:"hidden"` // 被文档所有者隐藏或被多次举报后自动隐藏
+	HiddenBy    *string        `json:"-"`
+	HiddenAt    *time.Time     `json:"-"`
+	ReportCount int            `gorm:"not null;default:0" json:"-"`
+	EditedAt    *time.Time     `json:"editedAt,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// CommentReport 用户对评论的举报，每个用户对同一条评论只能举报一次
+type CommentReport struct {
+	ID         string    `gorm:"primaryKey" json:"id"`
+	CommentID  string    `gorm:"uniqueIndex:idx_comment_report" json:"commentId"`
+	ReporterID string    `gorm:"uniqueIndex:idx_comment_report;index" json:"reporterId"`
+	Reason     string    `json:"reason"`
+	Virtual    bool      `gorm:"not null;default:false" json:"virtual"` // 虚拟用户的举报，不计入举报次数
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// CommentInput 创建评论的内容
+type CommentInput struct {
+	Content  string         `json:"content"`
+	ParentID *string        `json:"parentId"` // 回复的评论ID，回复一条回复时归入同一讨论串
+	Anchor   *CommentAnchor `json:"anchor"`   // 为空时评论整个文档，回复会忽略该字段
+}
+
+// CommentView 评论的展示模型，讨论串的第一条评论包含所有回复
+type CommentView struct {
+	ID          string         `json:"id"`
+	DocumentID  string         `json:"documentId"`
+	AuthorID    string         `json:"authorId"`
+	ParentID    *string        `json:"parentId"`
+	Anchor      *CommentAnchor `json:"anchor,omitempty"`
+	Content     string         `json:"content"`
+	Resolved    bool           `json:"resolved"`
+	ResolvedAt  *time.Time     `json:"resolvedAt,omitempty"`
+	Hidden      bool           `json:"hidden"`
+	Deleted     bool           `json:"deleted"`               // 已删除但仍有回复的评论，作者和内容为空
+	ReportCount int            `json:"reportCount,omitempty"` // 仅文档所有者可见
+	EditedAt    *time.Time     `json:"editedAt,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	Replies     []CommentView  `json:"replies,omitempty"`
+}
+
+// ToCommentView 将DocumentComment转换为CommentView，moderator为true时包含举报次数
+func (c *DocumentComment) ToCommentView(moderator bool) CommentView {
+	view := CommentView{
+		ID:         c.ID,
+		DocumentID: c.DocumentID,
+		AuthorID:   c.AuthorID,
+		ParentID:   c.ParentID,
+		Anchor:     c.Anchor,
+		Content:    c.Content,
+		Resolved:   c.Resolved,
+		ResolvedAt: c.ResolvedAt,
+		Hidden:     c.Hidden,
+		EditedAt:   c.EditedAt,
+		CreatedAt:  c.CreatedAt,
+	}
+	if c.DeletedAt.Valid {
+		view.Deleted = true
+		view.AuthorID = ""
+		view.Content = ""
+	}
+	if moderator {
+		view.ReportCount = c.ReportCount
+	}
+	return view
+}
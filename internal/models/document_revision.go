@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+)
+
+// DocumentRevision 文档修订版本，每次保存文档时生成一份快照
+type DocumentRevision struct {
+	ID         string       `gorm:"primaryKey" json:"id"`
+	DocumentID string       `gorm:"index" json:"documentId"`
+	AuthorID   string       `gorm:"index" json:"authorId"`
+	Title      string       `json:"title"`
+	IconImage  *Image       `gorm:"type:jsonb" json:"iconImage,omitempty"`
+	CoverImage *Image       `gorm:"type:jsonb" json:"coverImage,omitempty"`
+	EditorJSON *JSONContent `gorm:"type:jsonb" json:"editorJson,omitempty"`
+	Size       int64        `json:"size"`    // 内容大小（字节）
+	Summary    string       `json:"summary"` // 变更摘要
+	CreatedAt  time.Time    `gorm:"index" json:"createdAt"`
+}
+
+// DocumentRevisionList 修订版本列表项模型（不包含内容）
+type DocumentRevisionList struct {
+	ID        string    `json:"id"`
+	AuthorID  string    `json:"authorId"`
+	Title     string    `json:"title"`
+	Size      int64     `json:"size"`
+	Summary   string    `json:"summary"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// DiffLine 文本差异中的一行
+type DiffLine struct {
+	Op   string `json:"op"` // equal, insert, delete
+	Text string `json:"text"`
+}
+
+// FieldChange 字段变更
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// RevisionDiff 两个修订版本之间的差异
+type RevisionDiff struct {
+	FromID     string       `json:"fromId"`
+	ToID       string       `json:"toId"`
+	Title      *FieldChange `json:"title,omitempty"`
+	IconImage  *FieldChange `json:"iconImage,omitempty"`
+	CoverImage *FieldChange `json:"coverImage,omitempty"`
+	SizeDelta  int64        `json:"sizeDelta"`
+	Content    []DiffLine   `json:"content"`
+	Coarse     bool         `json:"coarse,omitempty"` // 内容过大时只标记被替换的区间，不逐行比较
+}
+
+// ToRevisionList 将DocumentRevision转换为DocumentRevisionList
+func (r *DocumentRevision) ToRevisionList() DocumentRevisionList {
+	return DocumentRevisionList{
+		ID:        r.ID,
+		AuthorID:  r.AuthorID,
+		Title:     r.Title,
+		Size:      r.Size,
+		Summary:   r.Summary,
+		CreatedAt: r.CreatedAt,
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+)
+
+// DocumentShareLink 文档的私密分享链接，持有token的任何人都可以访问文档（无需登录）
+type DocumentShareLink struct {
+	ID           string       `gorm:"primaryKey" json:"id"`
+	DocumentID   string       `gorm:"index" json:"documentId"`
+	Token        string       `gorm:"uniqueIndex" json:"token"`
+	Role         DocumentRole `json:"role"` // 只能是viewer或commenter
+	PasswordHash string       `json:"-"`
+	ExpiresAt    *time.Time   `json:"expiresAt,omitempty"`
+	CreatedBy    string       `json:"createdBy"`
+	CreatedAt    time.Time    `json:"createdAt"`
+}
+
+// DocumentShareLinkList 分享链接列表项模型
+type DocumentShareLinkList struct {
+	ID          string       `json:"id"`
+	Token       string       `json:"token"`
+	Role        DocumentRole `json:"role"`
+	HasPassword bool         `json:"hasPassword"`
+	ExpiresAt   *time.Time   `json:"expiresAt,omitempty"`
+	Expired     bool         `json:"expired"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
+// SharedDocument 通过分享链接访问的文档
+type SharedDocument struct {
+	ID         string       `json:"id"`
+	OwnerID    string       `json:"ownerId"`
+	Title      string       `json:"title"`
+	IconImage  *Image       `json:"iconImage,omitempty"`
+	CoverImage *Image       `json:"coverImage,omitempty"`
+	EditorJSON *JSONContent `json:"editorJson,omitempty"`
+	Tags       []string     `json:"tags"`
+	UpdatedAt  time.Time    `json:"updatedAt"`
+	Role       DocumentRole `json:"role"`
+}
+
+// ShareLinkCredentials 通过分享链接访问文档时提供的令牌和密码
+type ShareLinkCredentials struct {
+	Token    string
+	Password string
+}
+
+// IsExpired 判断分享链接是否已过期
+func (l *DocumentShareLink) IsExpired() bool {
+	return l.ExpiresAt != nil && time.Now().After(*l.ExpiresAt)
+}
+
+// ToShareLinkList 将DocumentShareLink转换为DocumentShareLinkList
+func (l *DocumentShareLink) ToShareLinkList() DocumentShareLinkList {
+	return DocumentShareLinkList{
+		ID:          l.ID,
+		Token:       l.Token,
+		Role:        l.Role,
+		HasPassword: l.PasswordHash != "",
+		ExpiresAt:   l.ExpiresAt,
+		Expired:     l.IsExpired(),
+		CreatedAt:   l.CreatedAt,
+	}
+}
+
+// ToSharedDocument 将Document转换为通过分享链接访问的文档
+func (d *Document) ToSharedDocument(role DocumentRole) SharedDocument {
+	tags := []string(d.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	return SharedDocument{
+		ID:         d.ID,
+		OwnerID:    d.OwnerID,
+		Title:      d.Title,
+		IconImage:  d.IconImage,
+		CoverImage: d.CoverImage,
+		EditorJSON: d.EditorJSON,
+		Tags:       tags,
+		UpdatedAt:  d.UpdatedAt,
+		Role:       role,
+	}
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"betalyr-learning-server/internal/pkg/editorjson"
+	"time"
+)
+
+// 文档元数据中摘要的最大长度
+const metaDescriptionLength = 160
+
+// DocumentSlugRedirect 文档曾经使用过的短名称，用于在短名称变化后重定向到当前地址
+type DocumentSlugRedirect struct {
+	Slug       string    `gorm:"primaryKey" json:"slug"`
+	DocumentID string    `gorm:"index" json:"documentId"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// MetaTag 页面的meta标签，如 {"property": "og:title", "content": "..."}
+type MetaTag struct {
+	Property string `json:"property"`
+	Content  string `json:"content"`
+}
+
+// DocumentMeta 公开文档的SEO和OpenGraph元数据，供前端渲染页面头部
+type DocumentMeta struct {
+	ID            string    `json:"id"`
+	Slug          string    `json:"slug,omitempty"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`     // 从正文提取的摘要
+	Image         *string   `json:"image,omitempty"` // 封面图片URL
+	Tags          []string  `json:"tags"`
+	PublishedTime time.Time `json:"publishedTime"`
+	ModifiedTime  time.Time `json:"modifiedTime"`
+	MetaTags      []MetaTag `json:"metaTags"` // OpenGraph和Twitter卡片标签
+}
+
+// ToDocumentMeta 生成文档的SEO和OpenGraph元数据
+func (d *Document) ToDocumentMeta() DocumentMeta {
+	meta := DocumentMeta{
+		ID:            d.ID,
+		Title:         d.Title,
+		Tags:          []string(d.Tags),
+		PublishedTime: d.CreatedAt,
+		ModifiedTime:  d.UpdatedAt,
+	}
+	if d.Slug != nil {
+		meta.Slug = *d.Slug
+	}
+	if d.EditorJSON != nil {
+		meta.Description = editorjson.Excerpt(*d.EditorJSON, metaDescriptionLength)
+	}
+	if d.CoverImage != nil && d.CoverImage.URL != "" {
+		meta.Image = &d.CoverImage.URL
+	}
+	if meta.Tags == nil {
+		meta.Tags = []string{}
+	}
+
+	meta.MetaTags = []MetaTag{
+		{Property: "og:type", Content: "article"},
+		{Property: "og:title", Content: meta.Title},
+		{Property: "og:description", Content: meta.Description},
+		{Property: "article:published_time", Content: meta.PublishedTime.UTC().Format(time.RFC3339)},
+		{Property: "article:modified_time", Content: meta.ModifiedTime.UTC().Format(time.RFC3339)},
+	}
+	for _, tag := range meta.Tags {
+		meta.MetaTags = append(meta.MetaTags, MetaTag{Property: "article:tag", Content: tag})
+	}
+	if meta.Image != nil {
+		meta.MetaTags = append(meta.MetaTags,
+			MetaTag{Property: "og:image", Content: *meta.Image},
+			MetaTag{Property: "twitter:card", Content: "summary_large_image"},
+			MetaTag{Property: "twitter:image", Content: *meta.Image})
+	} else {
+		meta.MetaTags = append(meta.MetaTags, MetaTag{Property: "twitter:card", Content: "summary"})
+	}
+	meta.MetaTags = append(meta.MetaTags,
+		MetaTag{Property: "twitter:title", Content: meta.Title},
+		MetaTag{Property: "twitter:description", Content: meta.Description})
+
+	return meta
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"time"
+)
+
+// EngagementItemType 点赞、收藏和浏览对应的内容类型
+type EngagementItemType string
+
+const (
+	EngagementItemDocument EngagementItemType = "document" // 文档
+	EngagementItemMedia    EngagementItemType = "media"    // 视频或音频
+)
+
+// IsValid 判断内容类型是否有效
+func (t EngagementItemType) IsValid() bool {
+	return t == EngagementItemDocument || t == EngagementItemMedia
+}
+
+// PublicListSort 公开列表的排序方式
+type PublicListSort string
+
+const (
+	PublicListSortRecent   PublicListSort = "recent"   // 按时间降序
+	PublicListSortPopular  PublicListSort = "popular"  // 按点赞、收藏和浏览的加权总数降序
+	PublicListSortTrending PublicListSort = "trending" // 按热度降序，热度随发布时间衰减
+)
+
+// ParsePublicListSort 解析排序方式，无效值使用默认的按时间排序
+func ParsePublicListSort(value string) PublicListSort {
+	switch sort := PublicListSort(value); sort {
+	case PublicListSortPopular, PublicListSortTrending:
+		return sort
+	}
+	return PublicListSortRecent
+}
+
+// ItemLike 用户对内容的点赞，每个用户对同一内容只能点赞一次
+type ItemLike struct {
+	ID        string             `gorm:"primaryKey" json:"-"`
+	UserID    string             `gorm:"uniqueIndex:idx_item_like_user" json:"-"`
+	ItemType  EngagementItemType `gorm:"type:varchar(16);uniqueIndex:idx_item_like_user" json:"type"`
+	ItemID    string             `gorm:"uniqueIndex:idx_item_like_user" json:"itemId"`
+	CreatedAt time.Time          `json:"createdAt"`
+}
+
+// ItemBookmark 用户收藏的内容，每个用户对同一内容只能收藏一次
+type ItemBookmark struct {
+	ID        string             `gorm:"primaryKey" json:"-"`
+	UserID    string             `gorm:"uniqueIndex:idx_item_bookmark_user;index:idx_item_bookmark_recent,priority:1" json:"-"`
+	ItemType  EngagementItemType `gorm:"type:varchar(16);uniqueIndex:idx_item_bookmark_user" json:"type"`
+	ItemID    string             `gorm:"uniqueIndex:idx_item_bookmark_user" json:"itemId"`
+	CreatedAt time.Time          `gorm:"index:idx_item_bookmark_recent,priority:2" json:"createdAt"`
+}
+
+// ItemView 观看者最近一次被计入浏览数的时间，用于在时间窗口内对浏览去重。
+// ViewerKey为"user:<用户ID>"或"ip:<IP的哈希>"
+type ItemView struct {
+	ItemType  EngagementItemType `gorm:"type:varchar(16);primaryKey"`
+	ItemID    string             `gorm:"primaryKey"`
+	ViewerKey string             `gorm:"primaryKey"`
+	ViewedAt  time.Time
+}
+
+// EngagementStats 内容的点赞、收藏和浏览数量，随点赞、收藏和浏览同步更新
+type EngagementStats struct {
+	ItemType      EngagementItemType `gorm:"type:varchar(16);primaryKey"`
+	ItemID        string             `gorm:"primaryKey"`
+	LikeCount     int64              `gorm:"not null;default:0"`
+	BookmarkCount int64              `gorm:"not null;default:0"`
+	ViewCount     int64              `gorm:"not null;default:0"`
+}
+
+// EngagementCounts 公开列表和详情中展示的互动数量
+type EngagementCounts struct {
+	LikeCount     int64 `json:"likeCount"`
+	BookmarkCount int64 `json:"bookmarkCount"`
+	ViewCount     int64 `json:"viewCount"`
+}
+
+// Counts 将EngagementStats转换为EngagementCounts
+func (s *EngagementStats) Counts() EngagementCounts {
+	if s == nil {
+		return EngagementCounts{}
+	}
+	return EngagementCounts{
+		LikeCount:     s.LikeCount,
+		BookmarkCount: s.BookmarkCount,
+		ViewCount:     s.ViewCount,
+	}
+}
+
+// EngagementState 内容的互动数量以及当前用户是否已点赞、收藏
+type EngagementState struct {
+	EngagementCounts
+	Liked      bool `json:"liked"`
+	Bookmarked bool `json:"bookmarked"`
+}
+
+// BookmarkItem 收藏列表项，包含内容的摘要信息
+type BookmarkItem struct {
+	Type         EngagementItemType `json:"type"`
+	ItemID       string             `json:"itemId"`
+	MediaType    MediaType          `json:"mediaType,omitempty"` // 媒体类型，仅媒体有效
+	Title        string             `json:"title"`
+	Thumbnail    *string            `json:"thumbnail,omitempty"` // 文档的图标或媒体缩略图
+	BookmarkedAt time.Time          `json:"bookmarkedAt"`
+}
+
+// BookmarkItemFromDocument 使用文档信息生成收藏列表项
+func (b *ItemBookmark) BookmarkItemFromDocument(doc *Document) BookmarkItem {
+	item := BookmarkItem{
+		Type:         b.ItemType,
+		ItemID:       b.ItemID,
+		Title:        doc.Title,
+		BookmarkedAt: b.CreatedAt,
+	}
+	if doc.IconImage != nil {
+		item.Thumbnail = &doc.IconImage.URL
+	}
+	return item
+}
+
+// BookmarkItemFromMedia 使用媒体信息生成收藏列表项
+func (b *ItemBookmark) BookmarkItemFromMedia(media *Media) BookmarkItem {
+	return BookmarkItem{
+		Type:         b.ItemType,
+		ItemID:       b.ItemID,
+		MediaType:    media.MediaType,
+		Title:        media.Title,
+		Thumbnail:    media.Thumbnail,
+		BookmarkedAt: b.CreatedAt,
+	}
+}
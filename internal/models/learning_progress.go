@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+)
+
+// ProgressItemType 学习进度对应的内容类型
+type ProgressItemType string
+
+const (
+	ProgressItemDocument ProgressItemType = "document" // 文档，进度为滚动位置
+	ProgressItemMedia    ProgressItemType = "media"    // 视频或音频，进度为播放位置
+)
+
+// IsValid 判断进度类型是否有效
+func (t ProgressItemType) IsValid() bool {
+	return t == ProgressItemDocument || t == ProgressItemMedia
+}
+
+// LearningProgress 用户对文档或媒体的学习进度，每个用户的每个内容只有一条记录
+type LearningProgress struct {
+	ID             string           `gorm:"primaryKey" json:"-"`
+	UserID         string           `gorm:"uniqueIndex:idx_learning_progress_item;index:idx_learning_progress_recent,priority:1" json:"-"`
+	ItemType       ProgressItemType `gorm:"type:varchar(16);uniqueIndex:idx_learning_progress_item" json:"type"`
+	ItemID         string           `gorm:"uniqueIndex:idx_learning_progress_item" json:"itemId"`
+	Position       float64          `gorm:"not null;default:0" json:"position"` // 文档为滚动位置（0~1），媒体为播放位置（秒）
+	Completed      bool             `gorm:"not null;default:false" json:"completed"`
+	CompletedAt    *time.Time       `json:"completedAt,omitempty"`
+	LastAccessedAt time.Time        `gorm:"index:idx_learning_progress_recent,priority:2" json:"lastAccessedAt"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	UpdatedAt      time.Time        `json:"updatedAt"`
+}
+
+// ProgressUpdate 上报的学习进度，nil字段表示不修改
+type ProgressUpdate struct {
+	Position  *float64 `json:"position"`
+	Completed *bool    `json:"completed"`
+}
+
+// ContinueLearningItem “继续学习”列表项，包含内容的摘要信息和学习进度
+type ContinueLearningItem struct {
+	Type           ProgressItemType `json:"type"`
+	ItemID         string           `json:"itemId"`
+	MediaType      MediaType        `json:"mediaType,omitempty"` // 媒体类型，仅媒体有效
+	Title          string           `json:"title"`
+	Thumbnail      *string          `json:"thumbnail,omitempty"` // 文档的图标或媒体缩略图
+	Duration       string           `json:"duration,omitempty"`  // 媒体时长，格式如"25:30"
+	Position       float64          `json:"position"`
+	Percent        float64          `json:"percent"` // 完成百分比（0~100），媒体时长未知时为0
+	LastAccessedAt time.Time        `json:"lastAccessedAt"`
+}
+
+// ContinueItemFromDocument 使用文档信息生成“继续学习”列表项
+func (p *LearningProgress) ContinueItemFromDocument(doc *Document) ContinueLearningItem {
+	item := ContinueLearningItem{
+		Type:           p.ItemType,
+		ItemID:         p.ItemID,
+		Title:          doc.Title,
+		Position:       p.Position,
+		Percent:        p.Position * 100,
+		LastAccessedAt: p.LastAccessedAt,
+	}
+	if doc.IconImage != nil {
+		item.Thumbnail = &doc.IconImage.URL
+	}
+	return item
+}
+
+// ContinueItemFromMedia 使用媒体信息生成“继续学习”列表项
+func (p *LearningProgress) ContinueItemFromMedia(media *Media) ContinueLearningItem {
+	item := ContinueLearningItem{
+		Type:           p.ItemType,
+		ItemID:         p.ItemID,
+		MediaType:      media.MediaType,
+		Title:          media.Title,
+		Thumbnail:      media.Thumbnail,
+		Position:       p.Position,
+		LastAccessedAt: p.LastAccessedAt,
+	}
+	if media.Meta != nil && media.Meta.Duration != nil && *media.Meta.Duration > 0 {
+		item.Duration = formatDuration(*media.Meta.Duration)
+		item.Percent = p.Position / float64(*media.Meta.Duration) * 100
+		if item.Percent > 100 {
+			item.Percent = 100
+		}
+	}
+	return item
+}
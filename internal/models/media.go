@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -69,8 +71,10 @@ type Media struct {
 	ContentType string          `json:"contentType"`                // MIME类型
 	MediaType   MediaType       `gorm:"index" json:"mediaType"`     // 媒体类型
 	Status      MediaStatus     `gorm:"default:'uploading'" json:"status"`
-	Thumbnail   *string         `json:"thumbnail,omitempty"` // 缩略图URL
-	Preview     *string         `json:"preview,omitempty"`   // 预览图URL
+	StatusError *string         `gorm:"type:text" json:"statusError,omitempty"` // 处理失败的原因，状态为error时有效
+	Thumbnail   *string         `json:"thumbnail,omitempty"`                    // 缩略图URL
+	Preview     *string         `json:"preview,omitempty"`                      // 预览图URL
+	PlaylistURL *string         `json:"playlistUrl,omitempty"`                  // HLS主播放列表URL，视频转码完成后有效
 	Meta        *MediaMeta      `gorm:"type:jsonb" json:"meta,omitempty"`
 	Category    string          `gorm:"index" json:"category"` // 分类
 	CreatedAt   time.Time       `json:"createdAt"`
@@ -87,6 +91,7 @@ type PublicVideoList struct {
 	Duration    string    `json:"duration,omitempty"` // 时长，格式如"25:30"
 	Category    string    `json:"category"`           // 分类
 	UploadTime  time.Time `json:"uploadTime"`
+	EngagementCounts
 }
 
 // PublicAudioList 公开音频列表项模型
@@ -95,6 +100,7 @@ type PublicAudioList struct {
 	Title      string    `json:"title"`
 	Duration   string    `json:"duration,omitempty"` // 时长，格式如"25:30"
 	UploadTime time.Time `json:"uploadTime"`         // 上传时间，格式如"2024-01-15"
+	EngagementCounts
 }
 
 // AudioDetail 音频详情模型
@@ -110,6 +116,7 @@ type VideoDetail struct {
 	Title       string     `json:"title"`
 	Description *string    `json:"description,omitempty"`
 	MediaUrl    string     `json:"mediaUrl"`           // 改名为 mediaUrl 匹配前端
+	HLSUrl      *string    `json:"hlsUrl,omitempty"`   // HLS自适应码率播放列表URL，转码完成前为空
 	Preview     *string    `json:"preview,omitempty"`  // 预览图URL
 	Duration    string     `json:"duration,omitempty"` // 时长，格式如"25:30"
 	UploadTime  time.Time  `json:"uploadTime"`         // 上传时间，格式如"2024-01-15"
@@ -136,6 +143,16 @@ func (m *Media) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// ProcessedKeyPrefix 返回处理媒体时生成的文件（HLS、预览图和缩略图）在存储中的键前缀，位于原文件键去掉扩展名后的目录下
+func (m *Media) ProcessedKeyPrefix() string {
+	return strings.TrimSuffix(m.FileKey, path.Ext(m.FileKey)) + "/"
+}
+
+// HLSKeyPrefix 返回HLS文件在存储中的键前缀
+func (m *Media) HLSKeyPrefix() string {
+	return m.ProcessedKeyPrefix() + "hls/"
+}
+
 // FormatDuration 将秒数转换为 "MM:SS" 格式
 func formatDuration(seconds int64) string {
 	if seconds == 0 {
@@ -200,6 +217,7 @@ func (m *Media) ToVideoDetail() VideoDetail {
 		Title:       m.Title,
 		Description: m.Description,
 		MediaUrl:    m.FileURL, // 映射到 mediaUrl
+		HLSUrl:      m.PlaylistURL,
 		Preview:     m.Preview,
 		Duration:    duration,
 		UploadTime:  m.CreatedAt,
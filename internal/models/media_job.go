@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+)
+
+// MediaJobType 媒体处理任务类型
+type MediaJobType string
+
+const (
+	MediaJobProcess MediaJobType = "process" // 上传后的处理，如提取视频预览图和HLS转码
+)
+
+// MediaJobStatus 媒体处理任务状态
+type MediaJobStatus string
+
+const (
+	MediaJobPending MediaJobStatus = "pending" // 等待执行或等待重试
+	MediaJobRunning MediaJobStatus = "running" // 执行中
+	MediaJobDone    MediaJobStatus = "done"    // 已完成
+	MediaJobFailed  MediaJobStatus = "failed"  // 重试次数用尽后失败
+)
+
+// MediaJob 持久化的媒体处理任务，由进程内的工作协程从数据库领取执行，失败后按退避时间重试
+type MediaJob struct {
+	ID          string         `gorm:"primaryKey" json:"id"`
+	MediaID     string         `gorm:"index" json:"mediaId"`
+	Type        MediaJobType   `gorm:"type:varchar(32)" json:"type"`
+	Status      MediaJobStatus `gorm:"type:varchar(16);index:idx_media_job_queue,priority:1" json:"status"`
+	Attempts    int            `gorm:"not null;default:0" json:"attempts"`    // 已开始执行的次数
+	MaxAttempts int            `gorm:"not null;default:5" json:"maxAttempts"` // 最大尝试次数
+	RunAt       time.Time      `gorm:"index:idx_media_job_queue,priority:2" json:"runAt"`
+	LockedAt    *time.Time     `json:"-"` // 开始执行的时间，用于发现进程崩溃后遗留的任务
+	LastError   *string        `gorm:"type:text" json:"lastError,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+}
+
+// MediaProcessingStatus 媒体处理状态，供上传者轮询
+type MediaProcessingStatus struct {
+	ID          string      `json:"id"`
+	Status      MediaStatus `json:"status"`
+	StatusError *string     `json:"statusError,omitempty"`
+	Attempts    int         `json:"attempts"`              // 处理任务已尝试的次数
+	MaxAttempts int         `json:"maxAttempts"`           // 处理任务的最大尝试次数
+	LastError   *string     `json:"lastError,omitempty"`   // 最近一次尝试失败的原因
+	NextRetryAt *time.Time  `json:"nextRetryAt,omitempty"` // 失败后下一次重试的时间
+	Thumbnail   *string     `json:"thumbnail,omitempty"`
+	Preview     *string     `json:"preview,omitempty"`
+	HLSUrl      *string     `json:"hlsUrl,omitempty"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+// ToMediaProcessingStatus 根据媒体及其最近的处理任务生成处理状态，job可以为nil
+func (m *Media) ToMediaProcessingStatus(job *MediaJob) MediaProcessingStatus {
+	status := MediaProcessingStatus{
+		ID:          m.ID,
+		Status:      m.Status,
+		StatusError: m.StatusError,
+		Thumbnail:   m.Thumbnail,
+		Preview:     m.Preview,
+		HLSUrl:      m.PlaylistURL,
+		UpdatedAt:   m.UpdatedAt,
+	}
+	if job != nil {
+		status.Attempts = job.Attempts
+		status.MaxAttempts = job.MaxAttempts
+		status.LastError = job.LastError
+		if job.Status == MediaJobPending && job.Attempts > 0 {
+			runAt := job.RunAt
+			status.NextRetryAt = &runAt
+		}
+	}
+	return status
+}
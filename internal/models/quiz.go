@@ -0,0 +1,240 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// QuizTargetType 测验所附加的内容类型
+type QuizTargetType string
+
+const (
+	QuizTargetDocument QuizTargetType = "document"
+	QuizTargetVideo    QuizTargetType = "video"
+)
+
+// IsValid 判断测验附加的内容类型是否有效
+func (t QuizTargetType) IsValid() bool {
+	return t == QuizTargetDocument || t == QuizTargetVideo
+}
+
+// QuestionType 测验题目类型
+type QuestionType string
+
+const (
+	QuestionSingleChoice   QuestionType = "single"   // 单选题
+	QuestionMultipleChoice QuestionType = "multiple" // 多选题
+	QuestionShortAnswer    QuestionType = "short"    // 简答题，与任一参考答案一致即为正确
+)
+
+// QuizOption 选择题的选项
+type QuizOption struct {
+	ID      string `json:"id"`
+	Text    string `json:"text"`
+	Correct bool   `json:"correct"`
+}
+
+// QuizQuestion 测验题目
+type QuizQuestion struct {
+	ID              string       `json:"id"`
+	Type            QuestionType `json:"type"`
+	Prompt          string       `json:"prompt"`
+	Options         []QuizOption `json:"options,omitempty"`
+	AcceptedAnswers []string     `json:"acceptedAnswers,omitempty"` // 简答题的参考答案，比较时忽略大小写和多余空白
+	Explanation     string       `json:"explanation,omitempty"`     // 提交后展示的答案解析
+	Points          int          `json:"points"`
+}
+
+// QuizQuestions 以JSON数组形式存储的测验题目
+type QuizQuestions []QuizQuestion
+
+// Value 实现driver.Valuer接口
+func (q QuizQuestions) Value() (driver.Value, error) {
+	if q == nil {
+		return json.Marshal([]QuizQuestion{})
+	}
+	return json.Marshal([]QuizQuestion(q))
+}
+
+// Scan 实现sql.Scanner接口
+func (q *QuizQuestions) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, q)
+}
+
+// Quiz 附加在文档或视频上的测验，每个内容最多一个测验
+type Quiz struct {
+	ID         string         `gorm:"primaryKey" json:"id"`
+	OwnerID    string         `gorm:"index" json:"ownerId"`
+	TargetType QuizTargetType `gorm:"type:varchar(16);uniqueIndex:idx_quiz_target" json:"targetType"`
+	TargetID   string         `gorm:"uniqueIndex:idx_quiz_target" json:"targetId"`
+	Title      string         `json:"title"`
+	PassScore  int            `gorm:"not null;default:60" json:"passScore"` // 及格分数线（得分百分比）
+	// MaxAttempts 每个学习者最多提交的次数，0表示不限。
+	// 答案和解析只在及格后返回，用完作答次数不会公开答案
+	MaxAttempts int           `gorm:"not null;default:0" json:"maxAttempts"`
+	Questions   QuizQuestions `gorm:"type:jsonb" json:"questions"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	UpdatedAt   time.Time     `json:"updatedAt"`
+}
+
+// LearnerQuizQuestion 面向学习者的题目，不包含答案
+type LearnerQuizQuestion struct {
+	ID      string              `json:"id"`
+	Type    QuestionType        `json:"type"`
+	Prompt  string              `json:"prompt"`
+	Options []LearnerQuizOption `json:"options,omitempty"`
+	Points  int                 `json:"points"`
+}
+
+// LearnerQuizOption 面向学习者的选项，不包含是否正确
+type LearnerQuizOption struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// LearnerQuiz 面向学习者的测验
+type LearnerQuiz struct {
+	ID          string                `json:"id"`
+	TargetType  QuizTargetType        `json:"targetType"`
+	TargetID    string                `json:"targetId"`
+	Title       string                `json:"title"`
+	PassScore   int                   `json:"passScore"`
+	MaxScore    int                   `json:"maxScore"`
+	MaxAttempts int                   `json:"maxAttempts"` // 0表示不限
+	Questions   []LearnerQuizQuestion `json:"questions"`
+}
+
+// MaxScore 测验的满分
+func (q *Quiz) MaxScore() int {
+	total := 0
+	for _, question := range q.Questions {
+		total += question.Points
+	}
+	return total
+}
+
+// ToLearnerQuiz 将Quiz转换为不包含答案的LearnerQuiz
+func (q *Quiz) ToLearnerQuiz() LearnerQuiz {
+	questions := make([]LearnerQuizQuestion, len(q.Questions))
+	for i, question := range q.Questions {
+		var options []LearnerQuizOption
+		for _, option := range question.Options {
+			options = append(options, LearnerQuizOption{ID: option.ID, Text: option.Text})
+		}
+		questions[i] = LearnerQuizQuestion{
+			ID:      question.ID,
+			Type:    question.Type,
+			Prompt:  question.Prompt,
+			Options: options,
+			Points:  question.Points,
+		}
+	}
+
+	return LearnerQuiz{
+		ID:          q.ID,
+		TargetType:  q.TargetType,
+		TargetID:    q.TargetID,
+		Title:       q.Title,
+		PassScore:   q.PassScore,
+		MaxScore:    q.MaxScore(),
+		MaxAttempts: q.MaxAttempts,
+		Questions:   questions,
+	}
+}
+
+// QuizAnswer 学习者对一道题目的作答及评分结果
+type QuizAnswer struct {
+	QuestionID string   `json:"questionId"`
+	OptionIDs  []string `json:"optionIds,omitempty"` // 选择题选中的选项
+	Text       string   `json:"text,omitempty"`      // 简答题的回答
+	Correct    bool     `json:"correct"`
+	Points     int      `json:"points"` // 本题得分
+}
+
+// QuizAnswers 以JSON数组形式存储的作答记录
+type QuizAnswers []QuizAnswer
+
+// Value 实现driver.Valuer接口
+func (a QuizAnswers) Value() (driver.Value, error) {
+	if a == nil {
+		return json.Marshal([]QuizAnswer{})
+	}
+	return json.Marshal([]QuizAnswer(a))
+}
+
+// Scan 实现sql.Scanner接口
+func (a *QuizAnswers) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, a)
+}
+
+// QuizAttempt 学习者提交的一次测验作答
+type QuizAttempt struct {
+	ID        string      `gorm:"primaryKey" json:"id"`
+	QuizID    string      `gorm:"index" json:"quizId"`
+	UserID    string      `gorm:"index" json:"-"`
+	Answers   QuizAnswers `gorm:"type:jsonb" json:"answers"`
+	Score     int         `json:"score"`
+	MaxScore  int         `json:"maxScore"`
+	Percent   float64     `json:"percent"`
+	Passed    bool        `json:"passed"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+// QuestionFeedback 提交后返回的单题反馈，正确答案和解析只在答案公开后返回
+type QuestionFeedback struct {
+	QuestionID       string   `json:"questionId"`
+	Correct          bool     `json:"correct"`
+	Points           int      `json:"points"`
+	CorrectOptionIDs []string `json:"correctOptionIds,omitempty"`
+	AcceptedAnswers  []string `json:"acceptedAnswers,omitempty"`
+	Explanation      string   `json:"explanation,omitempty"`
+}
+
+// QuizAttemptResult 测验评分结果
+type QuizAttemptResult struct {
+	QuizAttempt
+	Feedback        []QuestionFeedback `json:"feedback"`
+	AnswersRevealed bool               `json:"answersRevealed"`        // 是否返回了正确答案和解析
+	AttemptsLeft    *int               `json:"attemptsLeft,omitempty"` // 剩余作答次数，不限次数时为空
+}
+
+// QuestionStats 单道题目的作答统计
+type QuestionStats struct {
+	QuestionID   string         `json:"questionId"`
+	Prompt       string         `json:"prompt"`
+	Answered     int            `json:"answered"`
+	CorrectCount int            `json:"correctCount"`
+	CorrectRate  float64        `json:"correctRate"`            // 正确率（0~100）
+	OptionCounts map[string]int `json:"optionCounts,omitempty"` // 选择题每个选项被选中的次数
+}
+
+// QuizStats 测验的作答统计
+type QuizStats struct {
+	QuizID         string          `json:"quizId"`
+	Attempts       int             `json:"attempts"`
+	Learners       int             `json:"learners"`
+	AveragePercent float64         `json:"averagePercent"` // 平均得分百分比
+	PassRate       float64         `json:"passRate"`       // 及格率（0~100）
+	LastAttemptAt  *time.Time      `json:"lastAttemptAt,omitempty"`
+	Questions      []QuestionStats `json:"questions"`
+}
+
+// QuizInput 创建或更新测验的内容
+type QuizInput struct {
+	TargetType  QuizTargetType `json:"targetType"` // 仅创建时有效
+	TargetID    string         `json:"targetId"`   // 仅创建时有效
+	Title       string         `json:"title"`
+	PassScore   *int           `json:"passScore"`   // 为空时使用默认值60
+	MaxAttempts int            `json:"maxAttempts"` // 每个学习者最多提交的次数，0表示不限
+	Questions   []QuizQuestion `json:"questions"`
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"time"
+)
+
+// UploadSessionStatus 分片上传会话状态
+type UploadSessionStatus string
+
+const (
+	UploadSessionActive    UploadSessionStatus = "active"    // 上传中
+	UploadSessionCompleted UploadSessionStatus = "completed" // 已合并分片并创建媒体
+	UploadSessionAborted   UploadSessionStatus = "aborted"   // 已取消或过期
+)
+
+// UploadSession 可断点续传的分片上传会话，对应R2上的一个S3分片上传。
+// 除最后一个分片外每个分片的大小都是PartSize，分片编号从1开始。
+// 每次上传分片后顺延过期时间，过期后由清理任务取消。
+// Direct为true时客户端使用预签名地址直接上传到R2，StorageUploadID为空表示整个文件一次上传
+type UploadSession struct {
+	ID              string              `gorm:"primaryKey" json:"id"`
+	UploaderID      string              `gorm:"index" json:"-"`
+	MediaType       MediaType           `gorm:"type:varchar(16)" json:"mediaType"`
+	Title           string              `json:"title"`
+	Description     *string             `json:"description,omitempty"`
+	Category        string              `json:"category"`
+	FileName        string              `json:"fileName"`
+	ContentType     string              `json:"contentType"`
+	FileSize        int64               `json:"fileSize"` // 文件总大小（字节）
+	PartSize        int64               `json:"partSize"` // 分片大小（字节）
+	FileKey         string              `json:"-"`        // 合并后文件在存储中的键
+	StorageUploadID string              `json:"-"`        // S3分片上传ID
+	Direct          bool                `gorm:"not null;default:false" json:"direct"`
+	Status          UploadSessionStatus `gorm:"type:varchar(16);index:idx_upload_session_expiry,priority:1" json:"status"`
+	MediaID         *string             `json:"mediaId,omitempty"` // 完成后创建的媒体ID
+	ExpiresAt       time.Time           `gorm:"index:idx_upload_session_expiry,priority:2" json:"expiresAt"`
+	CreatedAt       time.Time           `json:"createdAt"`
+	UpdatedAt       time.Time           `json:"updatedAt"`
+}
+
+// UploadPart 已上传的分片，重新上传同一编号的分片会覆盖之前的记录
+type UploadPart struct {
+	SessionID  string    `gorm:"primaryKey" json:"-"`
+	PartNumber int       `gorm:"primaryKey;autoIncrement:false" json:"partNumber"`
+	Size       int64     `json:"size"`
+	Checksum   string    `gorm:"type:varchar(64)" json:"checksum"` // 分片内容的SHA-256（十六进制）
+	ETag       string    `json:"-"`                                // 存储返回的ETag，合并分片时使用
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// UploadSessionInit 创建分片上传会话的参数
+type UploadSessionInit struct {
+	MediaType   MediaType `json:"mediaType"` // video或audio
+	FileName    string    `json:"fileName"`
+	FileSize    int64     `json:"fileSize"`
+	ContentType string    `json:"contentType"` // 为空时根据文件名推断
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+	Multipart   bool      `json:"multipart"` // 直传时是否分片上传，文件超过单次上传的大小限制时总是分片上传
+}
+
+// PresignedURL 预签名的上传地址，客户端使用PUT请求并携带Headers中的请求头直接上传到存储，
+// 请求体必须恰好为Size字节
+type PresignedURL struct {
+	PartNumber int               `json:"partNumber"`
+	URL        string            `json:"url"`
+	Method     string            `json:"method"`
+	Headers    map[string]string `json:"headers"`
+	Size       int64             `json:"size"`
+	ExpiresAt  time.Time         `json:"expiresAt"`
+}
+
+// DirectUploadDetail 直传会话，整个文件一次上传时包含预签名的上传地址，
+// 分片上传时通过预签名分片接口获取每个分片的地址
+type DirectUploadDetail struct {
+	UploadSessionDetail
+	UploadURL *PresignedURL `json:"uploadUrl,omitempty"`
+}
+
+// UploadSessionDetail 分片上传会话及上传进度，客户端根据它续传缺少的分片
+type UploadSessionDetail struct {
+	UploadSession
+	TotalParts    int          `json:"totalParts"`
+	Offset        int64        `json:"offset"` // 从文件开头连续上传完成的字节数
+	UploadedParts []UploadPart `json:"uploadedParts"`
+}
+
+// TotalParts 返回文件的分片总数
+func (s *UploadSession) TotalParts() int {
+	if s.PartSize <= 0 {
+		return 0
+	}
+	return int((s.FileSize + s.PartSize - 1) / s.PartSize)
+}
+
+// PartLength 返回指定编号的分片应有的大小，编号超出范围时返回0
+func (s *UploadSession) PartLength(partNumber int) int64 {
+	total := s.TotalParts()
+	if partNumber < 1 || partNumber > total {
+		return 0
+	}
+	if partNumber < total {
+		return s.PartSize
+	}
+	return s.FileSize - s.PartSize*int64(total-1)
+}
+
+// ToUploadSessionDetail 根据已上传的分片生成上传进度，parts需按分片编号升序排列
+func (s *UploadSession) ToUploadSessionDetail(parts []UploadPart) UploadSessionDetail {
+	detail := UploadSessionDetail{
+		UploadSession: *s,
+		TotalParts:    s.TotalParts(),
+		UploadedParts: parts,
+	}
+	if detail.UploadedParts == nil {
+		detail.UploadedParts = []UploadPart{}
+	}
+
+	// 断点为第一个缺少的分片的起始位置
+	for i, part := range parts {
+		if part.PartNumber != i+1 {
+			break
+		}
+		detail.Offset += part.Size
+	}
+	return detail
+}
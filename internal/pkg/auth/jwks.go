@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// 公钥集默认缓存时间（响应中没有Cache-Control时使用）
+	defaultJWKSCacheTTL = time.Hour
+	// 遇到未知kid时强制刷新的最小间隔，防止伪造kid导致频繁请求
+	minJWKSRefreshInterval = time.Minute
+)
+
+// jwk 单个JSON Web Key
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// jwkSet JSON Web Key Set
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// KeySet 缓存的RSA公钥集合，支持从本地文件或URL加载，并在密钥轮换时自动刷新
+type KeySet struct {
+	url        string
+	file       string
+	httpClient *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]*rsa.PublicKey
+	expiresAt   time.Time
+	lastRefresh time.Time
+}
+
+// NewKeySet 创建公钥集，file不为空时从本地文件加载，否则从url加载
+func NewKeySet(url, file string) (*KeySet, error) {
+	if url == "" && file == "" {
+		return nil, errors.New("either JWKS url or JWKS file must be provided")
+	}
+	return &KeySet{
+		url:        url,
+		file:       file,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		keys:       make(map[string]*rsa.PublicKey),
+	}, nil
+}
+
+// Key 根据kid获取公钥，缓存过期或kid未知时刷新公钥集
+func (s *KeySet) Key(kid string) (*rsa.PublicKey, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	expired := time.Now().After(s.expiresAt)
+	canRefresh := time.Since(s.lastRefresh) >= minJWKSRefreshInterval
+	s.mu.RUnlock()
+
+	if ok && !expired {
+		return key, nil
+	}
+
+	// 缓存过期，或kid未知（可能发生了密钥轮换）
+	if expired || canRefresh {
+		if err := s.Refresh(); err != nil {
+			// 刷新失败时仍可使用旧的公钥
+			if ok {
+				return key, nil
+			}
+			return nil, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok = s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("no public key found for kid %q", kid)
+	}
+	return key, nil
+}
+
+// Refresh 重新加载公钥集
+func (s *KeySet) Refresh() error {
+	var (
+		data []byte
+		ttl  = defaultJWKSCacheTTL
+		err  error
+	)
+
+	if s.file != "" {
+		data, err = os.ReadFile(s.file)
+		if err != nil {
+			return fmt.Errorf("failed to read JWKS file: %w", err)
+		}
+	} else {
+		data, ttl, err = s.fetch()
+		if err != nil {
+			return err
+		}
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	s.keys = keys
+	s.expiresAt = now.Add(ttl)
+	s.lastRefresh = now
+	s.mu.Unlock()
+
+	return nil
+}
+
+// fetch 从URL下载公钥集，返回内容和缓存时间
+func (s *KeySet) fetch() ([]byte, time.Duration, error) {
+	resp, err := s.httpClient.Get(s.url)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read JWKS response: %w", err)
+	}
+
+	return data, parseMaxAge(resp.Header.Get("Cache-Control")), nil
+}
+
+// parseMaxAge 从Cache-Control中解析max-age
+func parseMaxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if !strings.HasPrefix(directive, "max-age=") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+		if err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return defaultJWKSCacheTTL
+}
+
+// parseJWKS 解析JWKS中的RSA公钥
+func parseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var set jwkSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || k.Kid == "" {
+			continue
+		}
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus for kid %q: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent for kid %q: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no usable RSA keys")
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"betalyr-learning-server/internal/config"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 允许的时钟偏差
+const clockSkew = time.Minute
+
+// 验证失败时返回的错误
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrTokenNotYetValid = errors.New("token not yet valid")
+	ErrMissingSubject   = errors.New("token has no user identifier")
+)
+
+// DefaultVerifier 全局JWT验证器，未配置时为nil
+var DefaultVerifier *Verifier
+
+// Claims 已验证令牌中的声明
+type Claims map[string]interface{}
+
+// UserID 从声明中提取用户ID
+// Firebase通常使用uid或sub字段作为用户ID，也可能在user_id字段中存储，最后使用email作为备选
+func (c Claims) UserID() string {
+	for _, key := range []string{"uid", "sub", "user_id", "email"} {
+		if v, ok := c[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Verifier 使用JWKS公钥验证RS256签名的JWT，并校验iss/aud/exp/nbf
+type Verifier struct {
+	keys     *KeySet
+	issuer   string
+	audience string
+}
+
+// NewVerifier 创建新的JWT验证器
+func NewVerifier(keys *KeySet, issuer, audience string) (*Verifier, error) {
+	if issuer == "" || audience == "" {
+		return nil, errors.New("token issuer and audience must be configured")
+	}
+	return &Verifier{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+	}, nil
+}
+
+// InitializeVerifier 根据配置初始化全局JWT验证器
+func InitializeVerifier(cfg *config.Config) error {
+	keys, err := NewKeySet(cfg.Auth.JWKSURL, cfg.Auth.JWKSFile)
+	if err != nil {
+		return err
+	}
+
+	verifier, err := NewVerifier(keys, cfg.Auth.Issuer, cfg.Auth.Audience)
+	if err != nil {
+		return err
+	}
+
+	// 预先加载公钥，尽早发现配置错误
+	if err := keys.Refresh(); err != nil {
+		return err
+	}
+
+	DefaultVerifier = verifier
+	return nil
+}
+
+// jwtHeader JWT头部
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verify 验证令牌并返回其中的声明
+func (v *Verifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	// 解析头部
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if header.Alg != "RS256" {
+		return nil, ErrUnsupportedAlg
+	}
+
+	// 验证签名
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	key, err := v.keys.Key(header.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	// 解析声明
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// validateClaims 校验iss/aud/exp/nbf
+func (v *Verifier) validateClaims(claims Claims) error {
+	if iss, _ := claims["iss"].(string); iss != v.issuer {
+		return ErrInvalidIssuer
+	}
+
+	if !containsAudience(claims["aud"], v.audience) {
+		return ErrInvalidAudience
+	}
+
+	now := time.Now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return ErrTokenExpired
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return ErrTokenExpired
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok {
+		if now.Add(clockSkew).Before(time.Unix(int64(nbf), 0)) {
+			return ErrTokenNotYetValid
+		}
+	}
+
+	// Firebase令牌的签发时间也不能晚于当前时间
+	if iat, ok := claims["iat"].(float64); ok {
+		if now.Add(clockSkew).Before(time.Unix(int64(iat), 0)) {
+			return ErrTokenNotYetValid
+		}
+	}
+
+	if claims.UserID() == "" {
+		return ErrMissingSubject
+	}
+
+	return nil
+}
+
+// containsAudience 检查aud声明（字符串或字符串数组）是否包含期望值
+func containsAudience(aud interface{}, expected string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == expected
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
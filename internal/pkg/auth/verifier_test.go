@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://securetoken.google.com/test-project"
+	testAudience = "test-project"
+	testKid      = "test-key"
+)
+
+// newTestVerifier 生成RSA密钥对，将公钥写入JWKS文件并创建验证器
+func newTestVerifier(t *testing.T) (*Verifier, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	set := jwkSet{Keys: []jwk{{
+		Kid: testKid,
+		Kty: "RSA",
+		Alg: "RS256",
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("failed to marshal JWKS: %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatalf("failed to write JWKS file: %v", err)
+	}
+
+	keys, err := NewKeySet("", file)
+	if err != nil {
+		t.Fatalf("failed to create key set: %v", err)
+	}
+	if err := keys.Refresh(); err != nil {
+		t.Fatalf("failed to load JWKS file: %v", err)
+	}
+	verifier, err := NewVerifier(keys, testIssuer, testAudience)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+	return verifier, key
+}
+
+// validClaims 返回一组可以通过验证的声明
+func validClaims() map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss": testIssuer,
+		"aud": testAudience,
+		"sub": "user-1",
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+}
+
+// signToken 使用给定的头部和声明生成RS256签名的令牌
+func signToken(t *testing.T, key *rsa.PrivateKey, header map[string]string, claims map[string]interface{}) string {
+	t.Helper()
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		t.Fatalf("failed to marshal header: %v", err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("failed to marshal claims: %v", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifyValidToken(t *testing.T) {
+	verifier, key := newTestVerifier(t)
+
+	token := signToken(t, key, map[string]string{"alg": "RS256", "kid": testKid, "typ": "JWT"}, validClaims())
+	claims, err := verifier.Verify(token)
+	if err != nil {
+		t.Fatalf("expected token to be valid, got %v", err)
+	}
+	if claims.UserID() != "user-1" {
+		t.Errorf("expected user ID %q, got %q", "user-1", claims.UserID())
+	}
+}
+
+func TestVerifyAudienceArray(t *testing.T) {
+	verifier, key := newTestVerifier(t)
+
+	claims := validClaims()
+	claims["aud"] = []string{"other-project", testAudience}
+	token := signToken(t, key, map[string]string{"alg": "RS256", "kid": testKid}, claims)
+	if _, err := verifier.Verify(token); err != nil {
+		t.Fatalf("expected token to be valid, got %v", err)
+	}
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	verifier, key := newTestVerifier(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	header := map[string]string{"alg": "RS256", "kid": testKid}
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		token  func() string
+		expect error
+	}{
+		{
+			name: "signed by another key",
+			token: func() string {
+				return signToken(t, otherKey, header, validClaims())
+			},
+			expect: ErrInvalidSignature,
+		},
+		{
+			name: "tampered payload",
+			token: func() string {
+				valid := signToken(t, key, header, validClaims())
+				claims := validClaims()
+				claims["sub"] = "someone-else"
+				forged := signToken(t, otherKey, header, claims)
+				// 使用原令牌的签名拼接篡改后的声明
+				return forged[:strings.LastIndex(forged, ".")] + valid[strings.LastIndex(valid, "."):]
+			},
+			expect: ErrInvalidSignature,
+		},
+		{
+			name: "alg none",
+			token: func() string {
+				token := signToken(t, key, map[string]string{"alg": "none", "kid": testKid}, validClaims())
+				return token[:strings.LastIndex(token, ".")+1]
+			},
+			expect: ErrUnsupportedAlg,
+		},
+		{
+			name: "alg HS256",
+			token: func() string {
+				return signToken(t, key, map[string]string{"alg": "HS256", "kid": testKid}, validClaims())
+			},
+			expect: ErrUnsupportedAlg,
+		},
+		{
+			name: "unknown kid",
+			token: func() string {
+				return signToken(t, key, map[string]string{"alg": "RS256", "kid": "unknown"}, validClaims())
+			},
+			expect: ErrInvalidSignature,
+		},
+		{
+			name: "wrong issuer",
+			token: func() string {
+				claims := validClaims()
+				claims["iss"] = "https://securetoken.google.com/other-project"
+				return signToken(t, key, header, claims)
+			},
+			expect: ErrInvalidIssuer,
+		},
+		{
+			name: "wrong audience",
+			token: func() string {
+				claims := validClaims()
+				claims["aud"] = "other-project"
+				return signToken(t, key, header, claims)
+			},
+			expect: ErrInvalidAudience,
+		},
+		{
+			name: "expired",
+			token: func() string {
+				claims := validClaims()
+				claims["exp"] = now.Add(-time.Hour).Unix()
+				return signToken(t, key, header, claims)
+			},
+			expect: ErrTokenExpired,
+		},
+		{
+			name: "missing exp",
+			token: func() string {
+				claims := validClaims()
+				delete(claims, "exp")
+				return signToken(t, key, header, claims)
+			},
+			expect: ErrTokenExpired,
+		},
+		{
+			name: "nbf in the future",
+			token: func() string {
+				claims := validClaims()
+				claims["nbf"] = now.Add(time.Hour).Unix()
+				return signToken(t, key, header, claims)
+			},
+			expect: ErrTokenNotYetValid,
+		},
+		{
+			name: "iat in the future",
+			token: func() string {
+				claims := validClaims()
+				claims["iat"] = now.Add(time.Hour).Unix()
+				return signToken(t, key, header, claims)
+			},
+			expect: ErrTokenNotYetValid,
+		},
+		{
+			name: "missing subject",
+			token: func() string {
+				claims := validClaims()
+				delete(claims, "sub")
+				return signToken(t, key, header, claims)
+			},
+			expect: ErrMissingSubject,
+		},
+		{
+			name: "malformed",
+			token: func() string {
+				return "not-a-jwt"
+			},
+			expect: ErrMalformedToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(tt.token())
+			if !errors.Is(err, tt.expect) {
+				t.Errorf("expected %v, got %v", tt.expect, err)
+			}
+		})
+	}
+}
+
+func TestVerifyAllowsClockSkew(t *testing.T) {
+	verifier, key := newTestVerifier(t)
+
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-clockSkew / 2).Unix()
+	claims["iat"] = time.Now().Add(clockSkew / 2).Unix()
+	token := signToken(t, key, map[string]string{"alg": "RS256", "kid": testKid}, claims)
+	if _, err := verifier.Verify(token); err != nil {
+		t.Fatalf("expected token within clock skew to be valid, got %v", err)
+	}
+}
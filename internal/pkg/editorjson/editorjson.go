@@ -0,0 +1,206 @@
+// Package editorjson 提供对编辑器(TipTap/ProseMirror)JSON文档树的遍历工具
+package editorjson
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Node 编辑器节点，如 {"type": "paragraph", "content": [...]}
+type Node map[string]interface{}
+
+// Type 返回节点类型
+func (n Node) Type() string {
+	t, _ := n["type"].(string)
+	return t
+}
+
+// Text 返回文本节点的文本
+func (n Node) Text() string {
+	t, _ := n["text"].(string)
+	return t
+}
+
+// Attrs 返回节点属性
+func (n Node) Attrs() map[string]interface{} {
+	attrs, _ := n["attrs"].(map[string]interface{})
+	return attrs
+}
+
+// Attr 返回字符串类型的节点属性
+func (n Node) Attr(key string) string {
+	switch v := n.Attrs()[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// Children 返回子节点
+func (n Node) Children() []Node {
+	raw, _ := n["content"].([]interface{})
+	children := make([]Node, 0, len(raw))
+	for _, item := range raw {
+		if m, ok := item.(map[string]interface{}); ok {
+			children = append(children, Node(m))
+		}
+	}
+	return children
+}
+
+// Marks 返回文本节点的标记(bold、italic、link等)
+func (n Node) Marks() []Node {
+	raw, _ := n["marks"].([]interface{})
+	marks := make([]Node, 0, len(raw))
+	for _, item := range raw {
+		if m, ok := item.(map[string]interface{}); ok {
+			marks = append(marks, Node(m))
+		}
+	}
+	return marks
+}
+
+// IsTextBlock 判断节点是否直接包含行内文本
+func (n Node) IsTextBlock() bool {
+	for _, child := range n.Children() {
+		if child.Type() == "text" || child.Type() == "hardBreak" {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk 深度优先遍历节点树，fn返回false时不再进入该节点的子节点
+func Walk(n Node, fn func(Node) bool) {
+	if n == nil || !fn(n) {
+		return
+	}
+	for _, child := range n.Children() {
+		Walk(child, fn)
+	}
+}
+
+// FindByID 查找id属性等于id的节点，不存在时返回nil
+func FindByID(doc map[string]interface{}, id string) Node {
+	var found Node
+	Walk(Node(doc), func(node Node) bool {
+		if found != nil {
+			return false
+		}
+		if node.Attr("id") == id {
+			found = node
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// InlineText 返回节点内所有文本的拼接
+func InlineText(n Node) string {
+	var sb strings.Builder
+	Walk(n, func(node Node) bool {
+		switch node.Type() {
+		case "text":
+			sb.WriteString(node.Text())
+		case "hardBreak":
+			sb.WriteString("\n")
+		}
+		return true
+	})
+	return sb.String()
+}
+
+// Blocks 按文本块提取纯文本，每个段落、标题、代码块等对应一项
+func Blocks(doc map[string]interface{}) []string {
+	var blocks []string
+	Walk(Node(doc), func(node Node) bool {
+		if node.IsTextBlock() {
+			if text := strings.TrimSpace(InlineText(node)); text != "" {
+				blocks = append(blocks, text)
+			}
+			return false
+		}
+		return true
+	})
+	return blocks
+}
+
+// PlainText 提取文档的纯文本，文本块之间以换行分隔
+func PlainText(doc map[string]interface{}) string {
+	return strings.Join(Blocks(doc), "\n")
+}
+
+// Excerpt 提取文档开头的段落文本作为摘要，标题和代码块除外，
+// 空白合并为单个空格，超过maxRunes个字符时截断并以省略号结尾
+func Excerpt(doc map[string]interface{}, maxRunes int) string {
+	var words []string
+	length := 0
+	Walk(Node(doc), func(node Node) bool {
+		if length > maxRunes {
+			return false
+		}
+		switch node.Type() {
+		case "heading", "codeBlock":
+			return false
+		}
+		if node.IsTextBlock() {
+			for _, word := range strings.Fields(InlineText(node)) {
+				words = append(words, word)
+				length += len([]rune(word)) + 1
+			}
+			return false
+		}
+		return true
+	})
+
+	runes := []rune(strings.Join(words, " "))
+	if len(runes) <= maxRunes {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:maxRunes-1])) + "…"
+}
+
+// 标签节点类型，以及文本中的话题标签(#tag)
+var (
+	tagNodeTypes = map[string]bool{"tag": true, "hashtag": true}
+	hashtagRegex = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/#])#([\p{L}\p{N}_-]+)`)
+)
+
+// Tags 从文档中提取标签：标签节点的label/name/id属性，以及文本中的#话题标签（代码中的除外）
+func Tags(doc map[string]interface{}) []string {
+	var tags []string
+	Walk(Node(doc), func(node Node) bool {
+		switch {
+		case tagNodeTypes[node.Type()]:
+			for _, key := range []string{"label", "name", "id"} {
+				if v := node.Attr(key); v != "" {
+					tags = append(tags, v)
+					break
+				}
+			}
+			return false
+		case node.Type() == "codeBlock":
+			return false
+		case node.Type() == "text" && !hasMark(node, "code"):
+			for _, match := range hashtagRegex.FindAllStringSubmatch(node.Text(), -1) {
+				tags = append(tags, match[1])
+			}
+		}
+		return true
+	})
+	return tags
+}
+
+// hasMark 判断文本节点是否带有指定标记
+func hasMark(n Node, markType string) bool {
+	for _, mark := range n.Marks() {
+		if mark.Type() == markType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+// Package export 将编辑器(TipTap/ProseMirror)JSON文档渲染为Markdown、HTML和PDF
+package export
+
+import (
+	"betalyr-learning-server/internal/pkg/editorjson"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format 导出格式
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+	FormatPDF      Format = "pdf"
+)
+
+// ParseFormat 解析导出格式，支持md/markdown、html/htm、pdf
+func ParseFormat(s string) (Format, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "md", "markdown":
+		return FormatMarkdown, true
+	case "html", "htm":
+		return FormatHTML, true
+	case "pdf":
+		return FormatPDF, true
+	}
+	return "", false
+}
+
+// Extension 返回格式对应的文件扩展名
+func (f Format) Extension() string {
+	switch f {
+	case FormatMarkdown:
+		return ".md"
+	case FormatHTML:
+		return ".html"
+	case FormatPDF:
+		return ".pdf"
+	}
+	return ""
+}
+
+// ContentType 返回格式对应的MIME类型
+func (f Format) ContentType() string {
+	switch f {
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatPDF:
+		return "application/pdf"
+	}
+	return "application/octet-stream"
+}
+
+// Document 待导出的文档
+type Document struct {
+	Title     string
+	IconURL   string
+	CoverURL  string
+	Content   map[string]interface{} // 编辑器JSON，可以为nil
+	Tags      []string
+	UpdatedAt time.Time
+}
+
+// 文件名中不允许出现的字符
+var unsafeFilenameChars = regexp.MustCompile(`[\\/:*?"<>|\x00-\x1f\s]+`)
+
+// Filename 根据标题生成安全的文件名（不含扩展名）
+func Filename(title string) string {
+	name := strings.TrimSpace(unsafeFilenameChars.ReplaceAllString(title, " "))
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		name = "Untitled"
+	}
+	if r := []rune(name); len(r) > 100 {
+		name = string(r[:100])
+	}
+	return name
+}
+
+// safeURL 只允许http、https、mailto链接以及相对地址，其他协议（如javascript:）返回空字符串
+func safeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return u.String()
+	}
+	return ""
+}
+
+// safeImageURL 图片只允许http和https地址
+func safeImageURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.String()
+	}
+	return ""
+}
+
+// imageSrc 返回图片节点的地址，兼容src和url属性
+func imageSrc(n editorjson.Node) string {
+	if src := n.Attr("src"); src != "" {
+		return src
+	}
+	return n.Attr("url")
+}
+
+// headingLevel 返回标题级别，限制在1-6之间
+func headingLevel(n editorjson.Node) int {
+	level, _ := strconv.Atoi(n.Attr("level"))
+	if level < 1 {
+		return 1
+	}
+	if level > 6 {
+		return 6
+	}
+	return level
+}
+
+// textRun 具有相同标记的一段连续文本
+type textRun struct {
+	text  string
+	marks []editorjson.Node
+	image editorjson.Node // 行内图片
+	br    bool            // 换行
+}
+
+// inlineRuns 将文本块的子节点转换为文本段，相邻且标记相同的文本会被合并
+func inlineRuns(n editorjson.Node) []textRun {
+	var runs []textRun
+	for _, child := range n.Children() {
+		switch child.Type() {
+		case "text":
+			marks := child.Marks()
+			if last := len(runs) - 1; last >= 0 && runs[last].image == nil && !runs[last].br &&
+				sameMarks(runs[last].marks, marks) {
+				runs[last].text += child.Text()
+				continue
+			}
+			runs = append(runs, textRun{text: child.Text(), marks: marks})
+		case "hardBreak":
+			runs = append(runs, textRun{br: true})
+		case "image":
+			runs = append(runs, textRun{image: child})
+		default:
+			// 未知的行内节点（如mention、tag）按纯文本处理
+			if text := editorjson.InlineText(child); text != "" {
+				runs = append(runs, textRun{text: text})
+			} else if label := child.Attr("label"); label != "" {
+				runs = append(runs, textRun{text: label})
+			}
+		}
+	}
+	return runs
+}
+
+// sameMarks 判断两组标记是否相同
+func sameMarks(a, b []editorjson.Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Type() != b[i].Type() || a[i].Attr("href") != b[i].Attr("href") {
+			return false
+		}
+	}
+	return true
+}
+
+// findMark 查找指定类型的标记
+func findMark(marks []editorjson.Node, markType string) editorjson.Node {
+	for _, mark := range marks {
+		if mark.Type() == markType {
+			return mark
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package export
+
+import (
+	"betalyr-learning-server/internal/pkg/editorjson"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 代码块语言名只允许字母、数字和少量符号
+var languagePattern = regexp.MustCompile(`^[A-Za-z0-9_+#.-]{1,32}$`)
+
+// 导出HTML的内联样式
+const htmlStyle = `body{margin:0;background:#fff;color:#1f2328;font:16px/1.7 -apple-system,BlinkMacSystemFont,"Segoe UI","PingFang SC","Hiragino Sans GB","Microsoft YaHei",sans-serif}
+article{max-width:760px;margin:0 auto;padding:32px 24px}
+.cover{width:100%;max-height:320px;object-fit:cover;border-radius:8px}
+.icon{width:72px;height:72px;object-fit:cover;margin-top:16px}
+h1,h2,h3,h4,h5,h6{line-height:1.3;margin:1.4em 0 .6em}
+.tags span{display:inline-block;margin-right:8px;color:#57606a}
+img{max-width:100%}
+pre{background:#f6f8fa;padding:12px 16px;border-radius:6px;overflow:auto}
+code{font-family:SFMono-Regular,Consolas,"Liberation Mono",Menlo,monospace;font-size:.9em}
+:not(pre)>code{background:#f6f8fa;padding:.1em .3em;border-radius:4px}
+blockquote{margin:0;padding-left:16px;border-left:4px solid #d0d7de;color:#57606a}
+table{border-collapse:collapse}
+th,td{border:1px solid #d0d7de;padding:6px 12px}
+ul.task-list{list-style:none;padding-left:1.2em}
+hr{border:0;border-top:1px solid #d0d7de}`
+
+// HTML 将文档渲染为独立的HTML页面。
+// 所有文本都会被转义，只输出白名单中的标签和属性，链接和图片只允许安全的协议
+func HTML(doc Document) []byte {
+	var sb strings.Builder
+	title := html.EscapeString(doc.Title)
+
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	sb.WriteString("<title>" + title + "</title>\n")
+	sb.WriteString("<style>\n" + htmlStyle + "\n</style>\n</head>\n<body>\n<article>\n")
+
+	if src := safeImageURL(doc.CoverURL); src != "" {
+		sb.WriteString("<img class=\"cover\" src=\"" + html.EscapeString(src) + "\" alt=\"\">\n")
+	}
+	if src := safeImageURL(doc.IconURL); src != "" {
+		sb.WriteString("<img class=\"icon\" src=\"" + html.EscapeString(src) + "\" alt=\"\">\n")
+	}
+	sb.WriteString("<h1>" + title + "</h1>\n")
+
+	if len(doc.Tags) > 0 {
+		sb.WriteString("<p class=\"tags\">")
+		for _, tag := range doc.Tags {
+			sb.WriteString("<span>#" + html.EscapeString(tag) + "</span>")
+		}
+		sb.WriteString("</p>\n")
+	}
+
+	if doc.Content != nil {
+		htmlBlocks(&sb, editorjson.Node(doc.Content).Children())
+	}
+
+	sb.WriteString("</article>\n</body>\n</html>\n")
+	return []byte(sb.String())
+}
+
+// HTMLFragment 只渲染文档正文，不含页面结构、标题和样式，用于嵌入订阅源等场景
+func HTMLFragment(content map[string]interface{}) string {
+	if content == nil {
+		return ""
+	}
+	var sb strings.Builder
+	htmlBlocks(&sb, editorjson.Node(content).Children())
+	return sb.String()
+}
+
+// htmlBlocks 渲染块级节点列表
+func htmlBlocks(sb *strings.Builder, nodes []editorjson.Node) {
+	for _, n := range nodes {
+		htmlBlock(sb, n)
+	}
+}
+
+// htmlBlock 渲染单个块级节点
+func htmlBlock(sb *strings.Builder, n editorjson.Node) {
+	switch n.Type() {
+	case "paragraph":
+		sb.WriteString("<p>")
+		htmlInline(sb, n)
+		sb.WriteString("</p>\n")
+	case "heading":
+		tag := "h" + strconv.Itoa(headingLevel(n))
+		sb.WriteString("<" + tag + ">")
+		htmlInline(sb, n)
+		sb.WriteString("</" + tag + ">\n")
+	case "blockquote":
+		sb.WriteString("<blockquote>\n")
+		htmlBlocks(sb, n.Children())
+		sb.WriteString("</blockquote>\n")
+	case "codeBlock":
+		sb.WriteString("<pre><code")
+		if lang := n.Attr("language"); languagePattern.MatchString(lang) {
+			sb.WriteString(" class=\"language-" + html.EscapeString(lang) + "\"")
+		}
+		sb.WriteString(">" + html.EscapeString(editorjson.InlineText(n)) + "</code></pre>\n")
+	case "horizontalRule":
+		sb.WriteString("<hr>\n")
+	case "image":
+		if img := htmlImage(n); img != "" {
+			sb.WriteString("<p>" + img + "</p>\n")
+		}
+	case "bulletList":
+		sb.WriteString("<ul>\n")
+		htmlListItems(sb, n)
+		sb.WriteString("</ul>\n")
+	case "orderedList":
+		sb.WriteString("<ol")
+		if start, err := strconv.Atoi(n.Attr("start")); err == nil && start != 1 {
+			sb.WriteString(" start=\"" + strconv.Itoa(start) + "\"")
+		}
+		sb.WriteString(">\n")
+		htmlListItems(sb, n)
+		sb.WriteString("</ol>\n")
+	case "taskList":
+		sb.WriteString("<ul class=\"task-list\">\n")
+		htmlListItems(sb, n)
+		sb.WriteString("</ul>\n")
+	case "table":
+		htmlTable(sb, n)
+	default:
+		if n.IsTextBlock() {
+			sb.WriteString("<p>")
+			htmlInline(sb, n)
+			sb.WriteString("</p>\n")
+			return
+		}
+		htmlBlocks(sb, n.Children())
+	}
+}
+
+// htmlListItems 渲染列表项，任务列表项带有只读的复选框
+func htmlListItems(sb *strings.Builder, n editorjson.Node) {
+	for _, item := range n.Children() {
+		sb.WriteString("<li>")
+		if item.Type() == "taskItem" {
+			if item.Attrs()["checked"] == true {
+				sb.WriteString("<input type=\"checkbox\" checked disabled> ")
+			} else {
+				sb.WriteString("<input type=\"checkbox\" disabled> ")
+			}
+		}
+		htmlBlocks(sb, item.Children())
+		sb.WriteString("</li>\n")
+	}
+}
+
+// htmlTable 渲染表格，支持colspan和rowspan
+func htmlTable(sb *strings.Builder, n editorjson.Node) {
+	sb.WriteString("<table>\n")
+	for _, row := range n.Children() {
+		sb.WriteString("<tr>")
+		for _, cell := range row.Children() {
+			tag := "td"
+			if cell.Type() == "tableHeader" {
+				tag = "th"
+			}
+			sb.WriteString("<" + tag)
+			for _, attr := range []string{"colspan", "rowspan"} {
+				if v, err := strconv.Atoi(cell.Attr(attr)); err == nil && v > 1 {
+					sb.WriteString(" " + attr + "=\"" + strconv.Itoa(v) + "\"")
+				}
+			}
+			sb.WriteString(">")
+			htmlBlocks(sb, cell.Children())
+			sb.WriteString("</" + tag + ">")
+		}
+		sb.WriteString("</tr>\n")
+	}
+	sb.WriteString("</table>\n")
+}
+
+// htmlImage 渲染图片标签，不安全的地址返回空字符串
+func htmlImage(n editorjson.Node) string {
+	src := safeImageURL(imageSrc(n))
+	if src == "" {
+		return ""
+	}
+	img := "<img src=\"" + html.EscapeString(src) + "\" alt=\"" + html.EscapeString(n.Attr("alt")) + "\""
+	if title := n.Attr("title"); title != "" {
+		img += " title=\"" + html.EscapeString(title) + "\""
+	}
+	return img + ">"
+}
+
+// 标记对应的HTML标签，按嵌套顺序排列
+var htmlMarkTags = []struct {
+	mark string
+	tag  string
+}{
+	{"bold", "strong"},
+	{"italic", "em"},
+	{"underline", "u"},
+	{"strike", "s"},
+	{"highlight", "mark"},
+	{"subscript", "sub"},
+	{"superscript", "sup"},
+	{"code", "code"},
+}
+
+// htmlInline 渲染文本块中的行内内容
+func htmlInline(sb *strings.Builder, n editorjson.Node) {
+	for _, run := range inlineRuns(n) {
+		switch {
+		case run.br:
+			sb.WriteString("<br>")
+		case run.image != nil:
+			sb.WriteString(htmlImage(run.image))
+		default:
+			text := html.EscapeString(run.text)
+			for i := len(htmlMarkTags) - 1; i >= 0; i-- {
+				if findMark(run.marks, htmlMarkTags[i].mark) != nil {
+					tag := htmlMarkTags[i].tag
+					text = "<" + tag + ">" + text + "</" + tag + ">"
+				}
+			}
+			if link := findMark(run.marks, "link"); link != nil {
+				if href := safeURL(link.Attr("href")); href != "" {
+					text = "<a href=\"" + html.EscapeString(href) + "\" rel=\"noopener noreferrer\">" + text + "</a>"
+				}
+			}
+			sb.WriteString(text)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package export
+
+import (
+	"betalyr-learning-server/internal/pkg/editorjson"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// 需要转义的Markdown特殊字符
+	markdownEscaper = strings.NewReplacer(
+		`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+		`<`, `\<`, `>`, `\>`, `|`, `\|`, `~`, `\~`, `#`, `\#`,
+	)
+	// 行首会被解析为列表或分隔线的字符
+	markdownLineStart = regexp.MustCompile(`(?m)^(\s*)([-+=]|\d+[.)])`)
+)
+
+// Markdown 将文档渲染为Markdown
+func Markdown(doc Document) []byte {
+	var parts []string
+
+	if src := safeImageURL(doc.CoverURL); src != "" {
+		parts = append(parts, "![cover]("+mdURL(src)+")")
+	}
+	if src := safeImageURL(doc.IconURL); src != "" {
+		parts = append(parts, "![icon]("+mdURL(src)+")")
+	}
+	parts = append(parts, "# "+escapeMarkdown(singleLine(doc.Title)))
+
+	if len(doc.Tags) > 0 {
+		tags := make([]string, len(doc.Tags))
+		for i, tag := range doc.Tags {
+			tags[i] = "\\#" + escapeMarkdown(tag)
+		}
+		parts = append(parts, strings.Join(tags, " "))
+	}
+
+	if doc.Content != nil {
+		if body := mdBlocks(editorjson.Node(doc.Content).Children()); body != "" {
+			parts = append(parts, body)
+		}
+	}
+
+	return []byte(strings.Join(parts, "\n\n") + "\n")
+}
+
+// mdBlocks 渲染块级节点列表，块之间以空行分隔
+func mdBlocks(nodes []editorjson.Node) string {
+	parts := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		if s := mdBlock(n); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// mdBlock 渲染单个块级节点
+func mdBlock(n editorjson.Node) string {
+	switch n.Type() {
+	case "paragraph":
+		return markdownLineStart.ReplaceAllString(mdInline(n), `$1\$2`)
+	case "heading":
+		return strings.Repeat("#", headingLevel(n)) + " " + singleLine(mdInline(n))
+	case "blockquote":
+		return prefixLines(mdBlocks(n.Children()), "> ", ">")
+	case "codeBlock":
+		code := strings.TrimRight(editorjson.InlineText(n), "\n")
+		fence := "```"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		lang := n.Attr("language")
+		if !languagePattern.MatchString(lang) {
+			lang = ""
+		}
+		return fence + lang + "\n" + code + "\n" + fence
+	case "horizontalRule":
+		return "---"
+	case "image":
+		return mdImage(n)
+	case "bulletList", "orderedList", "taskList":
+		return mdList(n)
+	case "table":
+		return mdTable(n)
+	}
+
+	if n.IsTextBlock() {
+		return mdInline(n)
+	}
+	return mdBlocks(n.Children())
+}
+
+// mdList 渲染列表，有序列表从start属性开始编号
+func mdList(n editorjson.Node) string {
+	start := 1
+	if v, err := strconv.Atoi(n.Attr("start")); err == nil {
+		start = v
+	}
+
+	items := n.Children()
+	lines := make([]string, 0, len(items))
+	for i, item := range items {
+		var marker string
+		switch {
+		case n.Type() == "orderedList":
+			marker = strconv.Itoa(start+i) + ". "
+		case item.Type() == "taskItem" && item.Attrs()["checked"] == true:
+			marker = "- [x] "
+		case item.Type() == "taskItem":
+			marker = "- [ ] "
+		default:
+			marker = "- "
+		}
+
+		body := mdBlocks(item.Children())
+		indent := strings.Repeat(" ", len(marker))
+		if n.Type() == "taskList" {
+			indent = "  "
+		}
+		// 后续行缩进到列表标记之后
+		if first, rest, found := strings.Cut(body, "\n"); found {
+			body = first + "\n" + prefixLines(rest, indent, "")
+		}
+		lines = append(lines, strings.TrimRight(marker, " ")+prefixSpace(body))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mdTable 渲染表格，第一行作为表头
+func mdTable(n editorjson.Node) string {
+	var rows [][]string
+	cols := 0
+	for _, row := range n.Children() {
+		var cells []string
+		for _, cell := range row.Children() {
+			text := strings.ReplaceAll(singleLine(mdBlocks(cell.Children())), "\n", " ")
+			cells = append(cells, text)
+		}
+		if len(cells) > cols {
+			cols = len(cells)
+		}
+		rows = append(rows, cells)
+	}
+	if len(rows) == 0 || cols == 0 {
+		return ""
+	}
+
+	var lines []string
+	for i, cells := range rows {
+		for len(cells) < cols {
+			cells = append(cells, "")
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", cols))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mdImage 渲染图片，不安全的地址会被忽略
+func mdImage(n editorjson.Node) string {
+	src := safeImageURL(imageSrc(n))
+	if src == "" {
+		return ""
+	}
+	return "![" + escapeMarkdown(singleLine(n.Attr("alt"))) + "](" + mdURL(src) + ")"
+}
+
+// mdInline 渲染文本块中的行内内容
+func mdInline(n editorjson.Node) string {
+	var sb strings.Builder
+	for _, run := range inlineRuns(n) {
+		switch {
+		case run.br:
+			sb.WriteString("\\\n")
+		case run.image != nil:
+			sb.WriteString(mdImage(run.image))
+		default:
+			sb.WriteString(mdText(run))
+		}
+	}
+	return sb.String()
+}
+
+// mdText 渲染带标记的文本，首尾空白移到标记外部
+func mdText(run textRun) string {
+	trimmed := strings.TrimSpace(run.text)
+	if trimmed == "" {
+		return run.text
+	}
+	lead := run.text[:strings.Index(run.text, trimmed)]
+	trail := run.text[len(lead)+len(trimmed):]
+
+	var text string
+	if findMark(run.marks, "code") != nil {
+		fence := "`"
+		for strings.Contains(trimmed, fence) {
+			fence += "`"
+		}
+		text = fence + trimmed + fence
+		if strings.HasPrefix(trimmed, "`") || strings.HasSuffix(trimmed, "`") {
+			text = fence + " " + trimmed + " " + fence
+		}
+	} else {
+		text = escapeMarkdown(trimmed)
+		if findMark(run.marks, "italic") != nil {
+			text = "*" + text + "*"
+		}
+		if findMark(run.marks, "bold") != nil {
+			text = "**" + text + "**"
+		}
+		if findMark(run.marks, "strike") != nil {
+			text = "~~" + text + "~~"
+		}
+	}
+
+	if link := findMark(run.marks, "link"); link != nil {
+		if href := safeURL(link.Attr("href")); href != "" {
+			text = "[" + text + "](" + mdURL(href) + ")"
+		}
+	}
+
+	return lead + text + trail
+}
+
+// mdURL 编码链接中的括号，避免提前结束链接语法
+func mdURL(u string) string {
+	return strings.NewReplacer("(", "%28", ")", "%29", " ", "%20").Replace(u)
+}
+
+// escapeMarkdown 转义文本中的Markdown特殊字符
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// singleLine 将换行替换为空格
+func singleLine(s string) string {
+	return strings.TrimSpace(strings.NewReplacer("\\\n", " ", "\r", " ", "\n", " ").Replace(s))
+}
+
+// prefixSpace 非空文本前加一个空格
+func prefixSpace(s string) string {
+	if s == "" {
+		return ""
+	}
+	return " " + s
+}
+
+// prefixLines 为每一行添加前缀，空行使用emptyPrefix
+func prefixLines(s, prefix, emptyPrefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = emptyPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,474 @@
+package export
+
+import (
+	"betalyr-learning-server/internal/pkg/editorjson"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/safehttp"
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"go.uber.org/zap"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// 正文字号(pt)
+	pdfBaseSize = 11.0
+	// 行高与字号的比例
+	pdfLineSpacing = 1.5
+	// 列表和引用的缩进(mm)
+	pdfIndent = 7.0
+	// 下载图片的最大字节数
+	pdfMaxImageBytes = 10 << 20
+	// 图片解码后的最大像素数，避免高压缩比的小文件解码后占用大量内存
+	pdfMaxImagePixels = 25_000_000
+	// 一次导出最多下载的图片数量
+	pdfMaxImages = 50
+	// 一次导出下载图片的总时间
+	pdfImageTime = time.Minute
+	// 1pt对应的毫米数
+	ptToMM = 25.4 / 72
+)
+
+// 各级标题的字号(pt)
+var pdfHeadingSizes = [6]float64{20, 17, 15, 13, 12, 11}
+
+// errImageTooLarge 图片的像素数超过限制
+var errImageTooLarge = errors.New("image dimensions too large")
+
+// ImageBudget 限制一次导出下载图片的数量和总时间，批量导出的所有文档共用同一个预算。
+// 预算用完后的图片会被跳过，不能在多个协程中同时使用
+type ImageBudget struct {
+	remaining int
+	deadline  time.Time
+}
+
+// NewImageBudget 创建新的图片下载预算，从现在开始计时
+func NewImageBudget() *ImageBudget {
+	return &ImageBudget{
+		remaining: pdfMaxImages,
+		deadline:  time.Now().Add(pdfImageTime),
+	}
+}
+
+// take 占用一次下载，预算用完时返回false
+func (b *ImageBudget) take() (context.Context, context.CancelFunc, bool) {
+	if b.remaining <= 0 || !time.Now().Before(b.deadline) {
+		return nil, nil, false
+	}
+	b.remaining--
+	ctx, cancel := context.WithDeadline(context.Background(), b.deadline)
+	return ctx, cancel, true
+}
+
+// PDFOptions PDF渲染选项
+type PDFOptions struct {
+	// FontPath 支持中文的UTF-8 TrueType字体(.ttf)，为空或不存在时使用内置字体（只支持西文）
+	FontPath string
+	// Client 下载图片使用的HTTP客户端，为空时使用只能访问公网地址的默认客户端
+	Client *http.Client
+	// Images 图片下载预算，为空时每个PDF使用单独的预算
+	Images *ImageBudget
+}
+
+// pdfRenderer PDF渲染器
+type pdfRenderer struct {
+	pdf    *fpdf.Fpdf
+	family string
+	mono   string
+	utf8   bool
+	tr     func(string) string
+	client *http.Client
+	budget *ImageBudget
+	images int
+}
+
+// PDF 将文档渲染为A4大小的PDF
+func PDF(doc Document, opts PDFOptions) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.SetTitle(doc.Title, true)
+
+	r := &pdfRenderer{
+		pdf:    pdf,
+		family: "Helvetica",
+		mono:   "Courier",
+		tr:     pdf.UnicodeTranslatorFromDescriptor(""),
+		client: opts.Client,
+		budget: opts.Images,
+	}
+	if r.client == nil {
+		r.client = safehttp.NewClient(15 * time.Second)
+	}
+	if r.budget == nil {
+		r.budget = NewImageBudget()
+	}
+
+	if opts.FontPath != "" {
+		if font, err := os.ReadFile(opts.FontPath); err == nil {
+			pdf.AddUTF8FontFromBytes("export", "", font)
+			r.family, r.mono, r.utf8 = "export", "export", true
+			r.tr = func(s string) string { return s }
+		} else {
+			logger.Warn("PDF font not found, falling back to core fonts", zap.String("path", opts.FontPath), zap.Error(err))
+		}
+	}
+	if pdf.Err() {
+		return nil, pdf.Error()
+	}
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		r.setFont(r.family, "", 8)
+		pdf.SetTextColor(140, 140, 140)
+		pdf.CellFormat(0, 6, strconv.Itoa(pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	// 封面和图标
+	if doc.CoverURL != "" {
+		r.image(doc.CoverURL, 70)
+	}
+	if doc.IconURL != "" {
+		r.image(doc.IconURL, 16)
+	}
+
+	// 标题
+	r.setFont(r.family, "B", 22)
+	pdf.SetTextColor(31, 35, 40)
+	pdf.MultiCell(0, lineHeight(22), r.tr(doc.Title), "", "L", false)
+
+	// 标签
+	if len(doc.Tags) > 0 {
+		r.setFont(r.family, "", 9)
+		pdf.SetTextColor(87, 96, 106)
+		pdf.MultiCell(0, lineHeight(9), r.tr("#"+strings.Join(doc.Tags, "  #")), "", "L", false)
+	}
+	pdf.Ln(4)
+
+	if doc.Content != nil {
+		r.blocks(editorjson.Node(doc.Content).Children())
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// lineHeight 返回字号对应的行高(mm)
+func lineHeight(size float64) float64 {
+	return size * ptToMM * pdfLineSpacing
+}
+
+// setFont 设置字体。UTF-8字体只注册了常规字形，粗体和斜体会被忽略
+func (r *pdfRenderer) setFont(family, style string, size float64) {
+	if r.utf8 {
+		style = strings.ReplaceAll(strings.ReplaceAll(style, "B", ""), "I", "")
+	}
+	r.pdf.SetFont(family, style, size)
+}
+
+// resetText 恢复正文字体和颜色
+func (r *pdfRenderer) resetText() {
+	r.setFont(r.family, "", pdfBaseSize)
+	r.pdf.SetTextColor(31, 35, 40)
+}
+
+// blocks 渲染块级节点列表
+func (r *pdfRenderer) blocks(nodes []editorjson.Node) {
+	for _, n := range nodes {
+		r.block(n)
+	}
+}
+
+// block 渲染单个块级节点
+func (r *pdfRenderer) block(n editorjson.Node) {
+	pdf := r.pdf
+	switch n.Type() {
+	case "paragraph":
+		r.inline(n, pdfBaseSize, "")
+		pdf.Ln(lineHeight(pdfBaseSize) + 1.5)
+	case "heading":
+		size := pdfHeadingSizes[headingLevel(n)-1]
+		pdf.Ln(2)
+		r.inline(n, size, "B")
+		pdf.Ln(lineHeight(size) + 1)
+	case "blockquote":
+		left, _, _, _ := pdf.GetMargins()
+		pdf.SetLeftMargin(left + pdfIndent)
+		pdf.SetX(left + pdfIndent)
+		r.blocks(n.Children())
+		pdf.SetLeftMargin(left)
+		pdf.SetX(left)
+	case "codeBlock":
+		r.setFont(r.mono, "", 9.5)
+		pdf.SetTextColor(36, 41, 47)
+		pdf.SetFillColor(246, 248, 250)
+		code := strings.TrimRight(editorjson.InlineText(n), "\n")
+		pdf.MultiCell(0, lineHeight(9.5), r.tr(code), "", "L", true)
+		pdf.Ln(3)
+	case "horizontalRule":
+		left, _, right, _ := pdf.GetMargins()
+		width, _ := pdf.GetPageSize()
+		y := pdf.GetY() + 2
+		pdf.SetDrawColor(208, 215, 222)
+		pdf.Line(left, y, width-right, y)
+		pdf.SetY(y + 4)
+	case "image":
+		r.image(imageSrc(n), 0)
+	case "bulletList", "orderedList", "taskList":
+		r.list(n)
+	case "table":
+		r.table(n)
+	default:
+		if n.IsTextBlock() {
+			r.inline(n, pdfBaseSize, "")
+			pdf.Ln(lineHeight(pdfBaseSize) + 1.5)
+			return
+		}
+		r.blocks(n.Children())
+	}
+}
+
+// list 渲染列表，列表项内容缩进到标记之后
+func (r *pdfRenderer) list(n editorjson.Node) {
+	pdf := r.pdf
+	start := 1
+	if v, err := strconv.Atoi(n.Attr("start")); err == nil {
+		start = v
+	}
+
+	left, _, _, _ := pdf.GetMargins()
+	pdf.SetLeftMargin(left + pdfIndent)
+	for i, item := range n.Children() {
+		var marker string
+		switch {
+		case n.Type() == "orderedList":
+			marker = strconv.Itoa(start+i) + "."
+		case item.Type() == "taskItem" && item.Attrs()["checked"] == true:
+			marker = "[x]"
+		case item.Type() == "taskItem":
+			marker = "[ ]"
+		default:
+			marker = "•"
+		}
+
+		r.resetText()
+		pdf.SetX(left)
+		pdf.Write(lineHeight(pdfBaseSize), r.tr(marker))
+		pdf.SetX(left + pdfIndent)
+		if len(item.Children()) == 0 {
+			pdf.Ln(lineHeight(pdfBaseSize))
+		}
+		r.blocks(item.Children())
+	}
+	pdf.SetLeftMargin(left)
+	pdf.SetX(left)
+}
+
+// table 渲染表格，各列等宽，文本自动换行
+func (r *pdfRenderer) table(n editorjson.Node) {
+	pdf := r.pdf
+	rows := n.Children()
+	cols := 0
+	for _, row := range rows {
+		if len(row.Children()) > cols {
+			cols = len(row.Children())
+		}
+	}
+	if cols == 0 {
+		return
+	}
+
+	left, _, right, bottom := pdf.GetMargins()
+	pageWidth, pageHeight := pdf.GetPageSize()
+	colWidth := (pageWidth - left - right) / float64(cols)
+	h := lineHeight(10)
+
+	pdf.SetDrawColor(208, 215, 222)
+	pdf.SetTextColor(31, 35, 40)
+	for _, row := range rows {
+		cells := row.Children()
+		lines := make([][]string, len(cells))
+		maxLines := 1
+		for i, cell := range cells {
+			style := ""
+			if cell.Type() == "tableHeader" {
+				style = "B"
+			}
+			r.setFont(r.family, style, 10)
+			text := strings.Join(editorjson.Blocks(cell), "\n")
+			lines[i] = pdf.SplitText(text, colWidth-3)
+			if len(lines[i]) > maxLines {
+				maxLines = len(lines[i])
+			}
+		}
+
+		rowHeight := float64(maxLines)*h + 2
+		if pdf.GetY()+rowHeight > pageHeight-bottom {
+			pdf.AddPage()
+		}
+		y := pdf.GetY()
+		for i := 0; i < cols; i++ {
+			x := left + float64(i)*colWidth
+			pdf.Rect(x, y, colWidth, rowHeight, "D")
+			if i >= len(cells) {
+				continue
+			}
+			style := ""
+			if cells[i].Type() == "tableHeader" {
+				style = "B"
+			}
+			r.setFont(r.family, style, 10)
+			for j, line := range lines[i] {
+				pdf.SetXY(x+1.5, y+1+float64(j)*h)
+				pdf.CellFormat(colWidth-3, h, r.tr(line), "", 0, "L", false, 0, "")
+			}
+		}
+		pdf.SetXY(left, y+rowHeight)
+	}
+	pdf.Ln(3)
+}
+
+// inline 渲染文本块中的行内内容，baseStyle为整个文本块的字形（如标题使用粗体）
+func (r *pdfRenderer) inline(n editorjson.Node, size float64, baseStyle string) {
+	pdf := r.pdf
+	h := lineHeight(size)
+	for _, run := range inlineRuns(n) {
+		switch {
+		case run.br:
+			pdf.Ln(h)
+		case run.image != nil:
+			pdf.Ln(h)
+			r.image(imageSrc(run.image), 0)
+		default:
+			style := baseStyle
+			if findMark(run.marks, "bold") != nil && !strings.Contains(style, "B") {
+				style += "B"
+			}
+			if findMark(run.marks, "italic") != nil {
+				style += "I"
+			}
+			if findMark(run.marks, "underline") != nil {
+				style += "U"
+			}
+
+			family := r.family
+			pdf.SetTextColor(31, 35, 40)
+			if findMark(run.marks, "code") != nil {
+				family = r.mono
+				pdf.SetTextColor(207, 34, 46)
+			}
+
+			href := ""
+			if link := findMark(run.marks, "link"); link != nil {
+				href = safeURL(link.Attr("href"))
+			}
+
+			r.setFont(family, style, size)
+			if href != "" {
+				pdf.SetTextColor(9, 105, 218)
+				pdf.WriteLinkString(h, r.tr(run.text), href)
+			} else {
+				pdf.Write(h, r.tr(run.text))
+			}
+		}
+	}
+	r.resetText()
+}
+
+// image 下载并插入图片，maxHeight为0时不限制高度。图片无法下载或解码、或下载预算用完时跳过
+func (r *pdfRenderer) image(src string, maxHeight float64) {
+	pdf := r.pdf
+	src = safeImageURL(src)
+	if src == "" {
+		return
+	}
+
+	ctx, cancel, ok := r.budget.take()
+	if !ok {
+		logger.Warn("Image budget exhausted, skipping image in PDF", zap.String("url", src))
+		return
+	}
+	data, imageType, err := r.fetchImage(ctx, src)
+	cancel()
+	if err != nil {
+		logger.Warn("Failed to embed image in PDF", zap.String("url", src), zap.Error(err))
+		return
+	}
+
+	r.images++
+	name := "image" + strconv.Itoa(r.images)
+	opts := fpdf.ImageOptions{ImageType: imageType}
+	info := pdf.RegisterImageOptionsReader(name, opts, bytes.NewReader(data))
+	if info == nil || pdf.Err() {
+		return
+	}
+
+	left, _, right, bottom := pdf.GetMargins()
+	pageWidth, pageHeight := pdf.GetPageSize()
+	maxWidth := pageWidth - left - right
+	if maxHeight <= 0 {
+		maxHeight = (pageHeight - 40) * 0.6
+	}
+
+	w, h := info.Width(), info.Height()
+	if w > maxWidth {
+		h = h * maxWidth / w
+		w = maxWidth
+	}
+	if h > maxHeight {
+		w = w * maxHeight / h
+		h = maxHeight
+	}
+
+	if pdf.GetY()+h > pageHeight-bottom {
+		pdf.AddPage()
+	}
+	y := pdf.GetY()
+	pdf.ImageOptions(name, left, y, w, h, false, opts, 0, "")
+	pdf.SetXY(left, y+h+3)
+}
+
+// fetchImage 下载图片并重新编码为PDF支持的JPEG或PNG，像素数超过限制的图片不解码
+func (r *pdfRenderer) fetchImage(ctx context.Context, src string) ([]byte, string, error) {
+	data, err := safehttp.GetContext(ctx, r.client, src, pdfMaxImageBytes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > pdfMaxImagePixels {
+		return nil, "", errImageTooLarge
+	}
+
+	// 重新编码，避免渐进式JPEG、隔行PNG、WebP等PDF不支持的格式
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+		return buf.Bytes(), "JPG", err
+	}
+	err = png.Encode(&buf, img)
+	return buf.Bytes(), "PNG", err
+}
@@ -0,0 +1,229 @@
+// Package feed 生成RSS 2.0、Atom订阅源和XML站点地图
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Feed 订阅源
+type Feed struct {
+	Title       string
+	Description string
+	Link        string // 站点地址
+	SelfLink    string // 订阅源自身的地址
+	Author      string // 订阅源作者，Atom要求提供
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item 订阅源条目
+type Item struct {
+	ID         string // 全局唯一且不随链接变化的标识，如 urn:uuid:<ID>
+	Title      string
+	Link       string
+	Summary    string // 纯文本摘要
+	Content    string // HTML全文，为空时只输出摘要
+	Categories []string
+	Enclosure  *Enclosure
+	Published  time.Time
+	Updated    time.Time
+}
+
+// Enclosure 条目附带的媒体文件
+type Enclosure struct {
+	URL    string
+	Type   string
+	Length int64
+}
+
+// rss RSS 2.0根元素
+type rss struct {
+	XMLName        xml.Name   `xml:"rss"`
+	Version        string     `xml:"version,attr"`
+	AtomNamespace  string     `xml:"xmlns:atom,attr"`
+	ContentModules string     `xml:"xmlns:content,attr"`
+	Channel        rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	SelfLink      atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Description string        `xml:"description"`
+	Content     *cdata        `xml:"content:encoded,omitempty"`
+	Categories  []string      `xml:"category"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+// 生成器名称
+const generator = "betalyr-learning-server"
+
+// RSS 将订阅源渲染为RSS 2.0，全文放在content:encoded中
+func RSS(f Feed) ([]byte, error) {
+	channel := rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: f.Description,
+		SelfLink:    atomLink{Href: f.SelfLink, Rel: "self", Type: "application/rss+xml"},
+		Generator:   generator,
+		Items:       make([]rssItem, 0, len(f.Items)),
+	}
+	if !f.Updated.IsZero() {
+		channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, item := range f.Items {
+		entry := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{Value: item.ID},
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+			Description: item.Summary,
+			Categories:  item.Categories,
+		}
+		if item.Content != "" {
+			entry.Content = &cdata{Value: item.Content}
+		}
+		if item.Enclosure != nil {
+			entry.Enclosure = &rssEnclosure{URL: item.Enclosure.URL, Type: item.Enclosure.Type, Length: item.Enclosure.Length}
+		}
+		channel.Items = append(channel.Items, entry)
+	}
+
+	return marshal(rss{
+		Version:        "2.0",
+		AtomNamespace:  atomNamespace,
+		ContentModules: "http://purl.org/rss/1.0/modules/content/",
+		Channel:        channel,
+	})
+}
+
+// Atom命名空间
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// atom Atom根元素
+type atom struct {
+	XMLName  xml.Name    `xml:"feed"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   atomAuthor  `xml:"author"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// Atom 将订阅源渲染为Atom
+func Atom(f Feed) ([]byte, error) {
+	feed := atom{
+		Xmlns:    atomNamespace,
+		ID:       f.SelfLink,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+			{Href: f.SelfLink, Rel: "self", Type: "application/atom+xml"},
+		},
+		Author:  atomAuthor{Name: f.Author},
+		Entries: make([]atomEntry, 0, len(f.Items)),
+	}
+
+	for _, item := range f.Items {
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     item.Title,
+			Links:     []atomLink{{Href: item.Link, Rel: "alternate", Type: "text/html"}},
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Updated:   item.Updated.UTC().Format(time.RFC3339),
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Value: item.Summary}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Value: item.Content}
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		if item.Enclosure != nil {
+			entry.Links = append(entry.Links, atomLink{
+				Href:   item.Enclosure.URL,
+				Rel:    "enclosure",
+				Type:   item.Enclosure.Type,
+				Length: item.Enclosure.Length,
+			})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return marshal(feed)
+}
+
+// marshal 输出带XML声明的缩进XML
+func marshal(v interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
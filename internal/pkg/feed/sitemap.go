@@ -0,0 +1,48 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// MaxSitemapURLs 单个站点地图允许的最大URL数量
+const MaxSitemapURLs = 50000
+
+// SitemapURL 站点地图中的页面
+type SitemapURL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+// urlset 站点地图根元素
+type urlset struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Sitemap 将页面列表渲染为XML站点地图，超过MaxSitemapURLs的部分会被忽略
+func Sitemap(urls []SitemapURL) ([]byte, error) {
+	if len(urls) > MaxSitemapURLs {
+		urls = urls[:MaxSitemapURLs]
+	}
+
+	set := urlset{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		URLs:  make([]sitemapURL, 0, len(urls)),
+	}
+	for _, u := range urls {
+		entry := sitemapURL{Loc: u.Loc}
+		if !u.LastMod.IsZero() {
+			entry.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		}
+		set.URLs = append(set.URLs, entry)
+	}
+
+	return marshal(set)
+}
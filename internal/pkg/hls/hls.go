@@ -0,0 +1,203 @@
+package hls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ffmpeg_go "github.com/u2takey/ffmpeg-go"
+)
+
+// MasterPlaylist 主播放列表的文件名
+const MasterPlaylist = "master.m3u8"
+
+const (
+	// 每个分片的时长（秒）
+	segmentSeconds = 6
+	// 每种清晰度的播放列表和分片文件名
+	variantPlaylist = "index.m3u8"
+	segmentPattern  = "segment_%03d.ts"
+)
+
+const (
+	// 每种清晰度的转码时限为视频时长的transcodeSpeedFactor倍加上minTranscodeTimeout，
+	// 超时后终止ffmpeg，避免卡住的转码一直占用任务
+	transcodeSpeedFactor = 4
+	minTranscodeTimeout  = 10 * time.Minute
+	// 无法获知时长时使用的转码时限，也是按时长计算的上限
+	maxTranscodeTimeout = 6 * time.Hour
+)
+
+// ladder 可用的清晰度，按短边从低到高排列，码率单位为kbps
+var ladder = []struct {
+	size         int
+	videoBitrate int
+	audioBitrate int
+}{
+	{360, 800, 96},
+	{720, 2800, 128},
+	{1080, 5000, 192},
+}
+
+// Rendition 一种清晰度的转码参数
+type Rendition struct {
+	Name         string // 清晰度名称，同时作为子目录名，如"720p"
+	Width        int
+	Height       int
+	VideoBitrate int // 视频码率（kbps）
+	AudioBitrate int // 音频码率（kbps）
+}
+
+// maxrate 视频的峰值码率（kbps）
+func (r Rendition) maxrate() int {
+	return r.VideoBitrate * 107 / 100
+}
+
+// bandwidth 主播放列表中声明的峰值带宽（bit/s）
+func (r Rendition) bandwidth() int {
+	return (r.maxrate() + r.AudioBitrate) * 1000
+}
+
+// Renditions 根据源视频的分辨率选择要输出的清晰度。清晰度按短边计算，竖屏视频同样适用；
+// 不会放大源视频，源视频低于最低清晰度时按源分辨率输出一种
+func Renditions(width, height int) []Rendition {
+	short, long := height, width
+	portrait := width < height
+	if portrait {
+		short, long = width, height
+	}
+
+	var result []Rendition
+	for _, l := range ladder {
+		if l.size <= short {
+			result = append(result, newRendition(l.size, short, long, portrait, l.videoBitrate, l.audioBitrate))
+		}
+	}
+	if len(result) == 0 {
+		l := ladder[0]
+		result = append(result, newRendition(even(short), short, long, portrait, l.videoBitrate, l.audioBitrate))
+	}
+	return result
+}
+
+// newRendition 按源视频的宽高比计算长边，宽高都取偶数以满足H.264编码要求
+func newRendition(size, short, long int, portrait bool, videoBitrate, audioBitrate int) Rendition {
+	scaled := even(int(math.Round(float64(long) * float64(size) / float64(short))))
+	r := Rendition{
+		Name:         fmt.Sprintf("%dp", size),
+		Width:        scaled,
+		Height:       size,
+		VideoBitrate: videoBitrate,
+		AudioBitrate: audioBitrate,
+	}
+	if portrait {
+		r.Width, r.Height = size, scaled
+	}
+	return r
+}
+
+// even 向下取偶数，最小为2
+func even(n int) int {
+	if n < 2 {
+		return 2
+	}
+	return n &^ 1
+}
+
+// transcodeTimeout 根据视频时长（秒）计算每种清晰度的转码时限，时长未知时返回上限
+func transcodeTimeout(duration float64) time.Duration {
+	if duration <= 0 {
+		return maxTranscodeTimeout
+	}
+	timeout := minTranscodeTimeout + time.Duration(duration*transcodeSpeedFactor*float64(time.Second))
+	if timeout > maxTranscodeTimeout {
+		return maxTranscodeTimeout
+	}
+	return timeout
+}
+
+// Transcode 将视频转码为多种清晰度的HLS并写入outDir：每种清晰度一个子目录，
+// 包含播放列表和TS分片，主播放列表为outDir下的MasterPlaylist。
+// duration为探测到的视频时长（秒），用于计算每种清晰度的转码时限，超时后终止ffmpeg并返回错误
+func Transcode(input, outDir string, renditions []Rendition, duration float64) error {
+	if len(renditions) == 0 {
+		return fmt.Errorf("no renditions to transcode")
+	}
+	timeout := transcodeTimeout(duration)
+
+	for _, r := range renditions {
+		dir := filepath.Join(outDir, r.Name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		stream := ffmpeg_go.Input(input).
+			Output(filepath.Join(dir, variantPlaylist), ffmpeg_go.KwArgs{
+				"vf":        fmt.Sprintf("scale=%d:%d", r.Width, r.Height),
+				"c:v":       "libx264",
+				"preset":    "veryfast",
+				"profile:v": "main",
+				"pix_fmt":   "yuv420p",
+				"b:v":       fmt.Sprintf("%dk", r.VideoBitrate),
+				"maxrate":   fmt.Sprintf("%dk", r.maxrate()),
+				"bufsize":   fmt.Sprintf("%dk", r.VideoBitrate*3/2),
+				// 按分片时长强制插入关键帧，保证各清晰度的分片边界对齐，便于播放器切换
+				"force_key_frames":     fmt.Sprintf("expr:gte(t,n_forced*%d)", segmentSeconds),
+				"sc_threshold":         0,
+				"c:a":                  "aac",
+				"b:a":                  fmt.Sprintf("%dk", r.AudioBitrate),
+				"ac":                   2,
+				"f":                    "hls",
+				"hls_time":             segmentSeconds,
+				"hls_playlist_type":    "vod",
+				"hls_segment_filename": filepath.Join(dir, segmentPattern),
+			}).
+			OverWriteOutput()
+		if err := runWithTimeout(stream, timeout); err != nil {
+			return fmt.Errorf("transcode %s: %w", r.Name, err)
+		}
+	}
+
+	return os.WriteFile(filepath.Join(outDir, MasterPlaylist), []byte(masterPlaylist(renditions)), 0644)
+}
+
+// runWithTimeout 运行ffmpeg命令，超过timeout时终止进程并返回包装了context.DeadlineExceeded的错误
+func runWithTimeout(stream *ffmpeg_go.Stream, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(stream.Context, timeout)
+	defer cancel()
+	stream.Context = ctx
+
+	err := stream.Run()
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("ffmpeg killed after %s: %w", timeout, context.DeadlineExceeded)
+	}
+	return err
+}
+
+// masterPlaylist 生成引用各清晰度播放列表的主播放列表
+func masterPlaylist(renditions []Rendition) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, r := range renditions {
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d\n", r.bandwidth(), r.Width, r.Height)
+		b.WriteString(r.Name + "/" + variantPlaylist + "\n")
+	}
+	return b.String()
+}
+
+// ContentType 返回HLS输出文件的MIME类型
+func ContentType(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".m3u8":
+		return "application/vnd.apple.mpegurl"
+	case ".ts":
+		return "video/mp2t"
+	default:
+		return "application/octet-stream"
+	}
+}
@@ -0,0 +1,528 @@
+package importer
+
+import (
+	"betalyr-learning-server/internal/pkg/editorjson"
+	"bytes"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// 连续空白
+	whitespaceRun = regexp.MustCompile(`[ \t\r\n\f]+`)
+	// 代码块语言，如 class="language-go"
+	languageClass = regexp.MustCompile(`(?:^|\s)(?:language|lang)-([A-Za-z0-9_+#.-]+)`)
+)
+
+// 不需要导入的元素
+var skippedElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true,
+	atom.Template: true, atom.Iframe: true, atom.Object: true, atom.Embed: true,
+	atom.Svg: true, atom.Math: true, atom.Form: true, atom.Button: true,
+	atom.Select: true, atom.Textarea: true, atom.Canvas: true,
+}
+
+// 行内元素对应的标记
+var inlineMarks = map[atom.Atom]string{
+	atom.Strong: "bold", atom.B: "bold",
+	atom.Em: "italic", atom.I: "italic", atom.Cite: "italic",
+	atom.U: "underline", atom.Ins: "underline",
+	atom.S: "strike", atom.Del: "strike", atom.Strike: "strike",
+	atom.Code: "code", atom.Kbd: "code", atom.Samp: "code", atom.Tt: "code",
+	atom.Mark: "highlight",
+	atom.Sub:  "subscript", atom.Sup: "superscript",
+}
+
+// 块级元素，其他元素按行内元素处理
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Blockquote: true, atom.Pre: true, atom.Hr: true,
+	atom.Table: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Nav: true, atom.Figure: true,
+	atom.Figcaption: true, atom.Details: true, atom.Summary: true, atom.Dl: true, atom.Dt: true,
+	atom.Dd: true, atom.Address: true, atom.Body: true, atom.Html: true,
+}
+
+// FromHTML 将HTML转换为编辑器JSON文档，返回<title>或第一个一级标题作为标题
+func FromHTML(src []byte) (string, editorjson.Node, error) {
+	title, content, err := parseHTML(src)
+	if err != nil {
+		return "", nil, err
+	}
+	title, content = extractTitle(title, content)
+	return title, newDoc(content), nil
+}
+
+// parseHTML 解析HTML，返回<title>的文本和正文的块级节点
+func parseHTML(src []byte) (string, []editorjson.Node, error) {
+	root, err := html.Parse(bytes.NewReader(src))
+	if err != nil {
+		return "", nil, err
+	}
+
+	title := ""
+	if t := findElement(root, atom.Title); t != nil {
+		title = strings.TrimSpace(whitespaceRun.ReplaceAllString(textContent(t), " "))
+	}
+
+	body := findElement(root, atom.Body)
+	if body == nil {
+		body = root
+	}
+	return title, convertBlocks(body), nil
+}
+
+// extractTitle 没有标题时使用开头的一级标题；开头的一级标题与标题相同时将其删除，避免重复
+func extractTitle(title string, content []editorjson.Node) (string, []editorjson.Node) {
+	if len(content) == 0 || content[0].Type() != "heading" || content[0].Attr("level") != "1" {
+		return title, content
+	}
+	heading := strings.TrimSpace(editorjson.InlineText(content[0]))
+	if title == "" {
+		title = heading
+	}
+	if heading == title {
+		content = content[1:]
+	}
+	return title, content
+}
+
+// blockBuilder 收集块级节点，连续的行内内容会被合并为段落
+type blockBuilder struct {
+	blocks []editorjson.Node
+	inline []editorjson.Node
+}
+
+// flush 将已收集的行内内容生成段落
+func (b *blockBuilder) flush() {
+	if inline := trimInline(b.inline); len(inline) > 0 {
+		b.blocks = append(b.blocks, editorjson.Node{"type": "paragraph", "content": toList(inline)})
+	}
+	b.inline = nil
+}
+
+// addBlock 添加块级节点
+func (b *blockBuilder) addBlock(nodes ...editorjson.Node) {
+	b.flush()
+	b.blocks = append(b.blocks, nodes...)
+}
+
+// convertBlocks 将元素的子节点转换为块级节点列表
+func convertBlocks(parent *html.Node) []editorjson.Node {
+	b := &blockBuilder{}
+	for child := parent.FirstChild; child != nil; child = child.NextSibling {
+		convertNode(b, child, nil)
+	}
+	b.flush()
+	return b.blocks
+}
+
+// convertNode 转换单个节点，marks为继承的行内标记
+func convertNode(b *blockBuilder, n *html.Node, marks []editorjson.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if text := whitespaceRun.ReplaceAllString(n.Data, " "); text != "" {
+			b.inline = append(b.inline, textNode(text, marks))
+		}
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	if skippedElements[n.DataAtom] {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		b.inline = append(b.inline, editorjson.Node{"type": "hardBreak"})
+	case atom.Img:
+		if img := imageNode(n); img != nil {
+			b.addBlock(img)
+		}
+	case atom.Input:
+		// 任务列表的复选框在列表项中处理
+	case atom.A:
+		href := safeHref(attr(n, "href"))
+		childMarks := marks
+		if href != "" {
+			childMarks = withMark(marks, editorjson.Node{"type": "link", "attrs": map[string]interface{}{"href": href}})
+		}
+		convertChildren(b, n, childMarks)
+	case atom.P, atom.Dt, atom.Figcaption, atom.Summary, atom.Address:
+		b.flush()
+		convertChildren(b, n, marks)
+		b.flush()
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		inline, extra := convertInline(n)
+		heading := editorjson.Node{"type": "heading", "attrs": map[string]interface{}{"level": float64(level)}}
+		if len(inline) > 0 {
+			heading["content"] = toList(inline)
+		}
+		b.addBlock(heading)
+		b.addBlock(extra...)
+	case atom.Ul, atom.Ol:
+		if list := listNode(n); list != nil {
+			b.addBlock(list)
+		}
+	case atom.Li:
+		// 不在列表中的列表项按普通块处理
+		b.addBlock(convertBlocks(n)...)
+	case atom.Blockquote:
+		if content := convertBlocks(n); len(content) > 0 {
+			b.addBlock(editorjson.Node{"type": "blockquote", "content": toList(content)})
+		}
+	case atom.Pre:
+		b.addBlock(codeBlockNode(n))
+	case atom.Hr:
+		b.addBlock(editorjson.Node{"type": "horizontalRule"})
+	case atom.Table:
+		if table := tableNode(n); table != nil {
+			b.addBlock(table)
+		}
+	default:
+		if blockElements[n.DataAtom] {
+			b.flush()
+			convertChildren(b, n, marks)
+			b.flush()
+			return
+		}
+		if mark, ok := inlineMarks[n.DataAtom]; ok {
+			marks = withMark(marks, editorjson.Node{"type": mark})
+		}
+		convertChildren(b, n, marks)
+	}
+}
+
+// convertChildren 依次转换子节点
+func convertChildren(b *blockBuilder, n *html.Node, marks []editorjson.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		convertNode(b, child, marks)
+	}
+}
+
+// convertInline 转换只能包含行内内容的元素（如标题），其中的图片等块级内容通过extra返回
+func convertInline(n *html.Node) (inline []editorjson.Node, extra []editorjson.Node) {
+	b := &blockBuilder{}
+	convertChildren(b, n, nil)
+	// 标题中的块级内容之前的行内内容已被flush为段落，合并回标题
+	for _, block := range b.blocks {
+		if block.Type() == "paragraph" {
+			if len(inline) > 0 {
+				inline = append(inline, editorjson.Node{"type": "hardBreak"})
+			}
+			inline = append(inline, block.Children()...)
+			continue
+		}
+		extra = append(extra, block)
+	}
+	if len(b.inline) > 0 && len(inline) > 0 {
+		inline = append(inline, editorjson.Node{"type": "hardBreak"})
+	}
+	inline = trimInline(append(inline, b.inline...))
+	return inline, extra
+}
+
+// listNode 转换列表，包含复选框的列表转换为任务列表
+func listNode(n *html.Node) editorjson.Node {
+	var items []editorjson.Node
+	isTask := false
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+
+		checkbox := findCheckbox(li)
+		if checkbox != nil {
+			isTask = true
+		}
+
+		content := convertBlocks(li)
+		// 列表项的第一个子节点必须是段落
+		if len(content) == 0 || content[0].Type() != "paragraph" {
+			content = append([]editorjson.Node{{"type": "paragraph"}}, content...)
+		}
+
+		item := editorjson.Node{"type": "listItem", "content": toList(content)}
+		if checkbox != nil {
+			_, checked := attrValue(checkbox, "checked")
+			item["attrs"] = map[string]interface{}{"checked": checked}
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	if isTask {
+		for _, item := range items {
+			item["type"] = "taskItem"
+			if _, ok := item["attrs"]; !ok {
+				item["attrs"] = map[string]interface{}{"checked": false}
+			}
+		}
+		return editorjson.Node{"type": "taskList", "content": toList(items)}
+	}
+
+	if n.DataAtom == atom.Ol {
+		list := editorjson.Node{"type": "orderedList", "content": toList(items)}
+		start := 1
+		if v, err := strconv.Atoi(attr(n, "start")); err == nil {
+			start = v
+		}
+		list["attrs"] = map[string]interface{}{"start": float64(start)}
+		return list
+	}
+	return editorjson.Node{"type": "bulletList", "content": toList(items)}
+}
+
+// findCheckbox 查找列表项开头的复选框（不进入嵌套列表）
+func findCheckbox(li *html.Node) *html.Node {
+	var found *html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil && found == nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.DataAtom == atom.Ul || c.DataAtom == atom.Ol {
+				continue
+			}
+			if c.DataAtom == atom.Input && strings.EqualFold(attr(c, "type"), "checkbox") {
+				found = c
+				return
+			}
+			walk(c)
+		}
+	}
+	walk(li)
+	return found
+}
+
+// codeBlockNode 转换<pre>代码块，语言从pre或code的class中获取
+func codeBlockNode(n *html.Node) editorjson.Node {
+	code := textContent(n)
+	code = strings.TrimSuffix(code, "\n")
+
+	lang := ""
+	for _, el := range []*html.Node{n, findElement(n, atom.Code)} {
+		if el == nil {
+			continue
+		}
+		if m := languageClass.FindStringSubmatch(attr(el, "class")); m != nil {
+			lang = m[1]
+			break
+		}
+	}
+
+	block := editorjson.Node{"type": "codeBlock", "attrs": map[string]interface{}{"language": nil}}
+	if lang != "" {
+		block["attrs"] = map[string]interface{}{"language": lang}
+	}
+	if code != "" {
+		block["content"] = toList([]editorjson.Node{{"type": "text", "text": code}})
+	}
+	return block
+}
+
+// tableNode 转换表格，thead、tbody、tfoot中的行按顺序合并
+func tableNode(n *html.Node) editorjson.Node {
+	var rows []editorjson.Node
+	var walk func(el *html.Node)
+	walk = func(el *html.Node) {
+		for c := el.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(c)
+			case atom.Tr:
+				if row := tableRowNode(c); row != nil {
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return nil
+	}
+	return editorjson.Node{"type": "table", "content": toList(rows)}
+}
+
+// tableRowNode 转换表格行
+func tableRowNode(tr *html.Node) editorjson.Node {
+	var cells []editorjson.Node
+	for c := tr.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || (c.DataAtom != atom.Td && c.DataAtom != atom.Th) {
+			continue
+		}
+		cellType := "tableCell"
+		if c.DataAtom == atom.Th {
+			cellType = "tableHeader"
+		}
+
+		content := convertBlocks(c)
+		if len(content) == 0 {
+			content = []editorjson.Node{{"type": "paragraph"}}
+		}
+
+		attrs := map[string]interface{}{"colspan": float64(1), "rowspan": float64(1)}
+		for _, key := range []string{"colspan", "rowspan"} {
+			if v, err := strconv.Atoi(attr(c, key)); err == nil && v > 1 && v <= 100 {
+				attrs[key] = float64(v)
+			}
+		}
+		cells = append(cells, editorjson.Node{"type": cellType, "attrs": attrs, "content": toList(content)})
+	}
+	if len(cells) == 0 {
+		return nil
+	}
+	return editorjson.Node{"type": "tableRow", "content": toList(cells)}
+}
+
+// imageNode 转换图片，没有地址的图片返回nil
+func imageNode(n *html.Node) editorjson.Node {
+	src := strings.TrimSpace(attr(n, "src"))
+	if src == "" {
+		return nil
+	}
+	attrs := map[string]interface{}{"src": src, "alt": attr(n, "alt"), "title": attr(n, "title")}
+	return editorjson.Node{"type": "image", "attrs": attrs}
+}
+
+// textNode 创建带标记的文本节点
+func textNode(text string, marks []editorjson.Node) editorjson.Node {
+	node := editorjson.Node{"type": "text", "text": text}
+	if len(marks) > 0 {
+		node["marks"] = toList(marks)
+	}
+	return node
+}
+
+// withMark 返回追加了标记的新列表，已存在同类型标记时不重复添加
+func withMark(marks []editorjson.Node, mark editorjson.Node) []editorjson.Node {
+	for _, m := range marks {
+		if m.Type() == mark.Type() {
+			return marks
+		}
+	}
+	result := make([]editorjson.Node, len(marks), len(marks)+1)
+	copy(result, marks)
+	return append(result, mark)
+}
+
+// trimInline 去掉行内内容首尾以及换行前后的空白，删除空文本节点
+func trimInline(nodes []editorjson.Node) []editorjson.Node {
+	result := make([]editorjson.Node, 0, len(nodes))
+	atLineStart := true
+	for _, n := range nodes {
+		if n.Type() == "text" {
+			text := n.Text()
+			if atLineStart {
+				text = strings.TrimLeft(text, " ")
+			}
+			if text == "" {
+				continue
+			}
+			n["text"] = text
+			atLineStart = strings.HasSuffix(text, " ")
+		} else {
+			if n.Type() == "hardBreak" && len(result) > 0 {
+				trimTrailingSpace(&result)
+			}
+			atLineStart = n.Type() == "hardBreak"
+		}
+		result = append(result, n)
+	}
+
+	trimTrailingSpace(&result)
+	// 删除末尾多余的换行
+	for len(result) > 0 && result[len(result)-1].Type() == "hardBreak" {
+		result = result[:len(result)-1]
+		trimTrailingSpace(&result)
+	}
+	return result
+}
+
+// trimTrailingSpace 去掉最后一个文本节点末尾的空白
+func trimTrailingSpace(nodes *[]editorjson.Node) {
+	for len(*nodes) > 0 {
+		last := (*nodes)[len(*nodes)-1]
+		if last.Type() != "text" {
+			return
+		}
+		text := strings.TrimRight(last.Text(), " ")
+		if text != "" {
+			last["text"] = text
+			return
+		}
+		*nodes = (*nodes)[:len(*nodes)-1]
+	}
+}
+
+// safeHref 只保留http、https、mailto链接和相对地址
+func safeHref(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || raw == "" {
+		return ""
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return raw
+	}
+	return ""
+}
+
+// findElement 深度优先查找第一个指定类型的元素
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// textContent 返回元素内的所有文本
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		if n.Type == html.ElementNode && n.DataAtom == atom.Br {
+			sb.WriteString("\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// attr 返回元素的属性值
+func attr(n *html.Node, key string) string {
+	v, _ := attrValue(n, key)
+	return v
+}
+
+// attrValue 返回元素的属性值以及属性是否存在
+func attrValue(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
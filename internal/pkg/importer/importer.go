@@ -0,0 +1,206 @@
+// Package importer 将Markdown、HTML和纯文本文件转换为编辑器(TipTap/ProseMirror)JSON文档
+package importer
+
+import (
+	"betalyr-learning-server/internal/pkg/editorjson"
+	"bytes"
+	"errors"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"gopkg.in/yaml.v2"
+)
+
+// ErrUnsupportedFormat 不支持的文件格式
+var ErrUnsupportedFormat = errors.New("unsupported file format")
+
+// Result 导入结果
+type Result struct {
+	Title   string
+	Tags    []string
+	Content map[string]interface{}
+}
+
+// Options 导入选项
+type Options struct {
+	// ResolveImage 处理文档中的图片地址，返回新的地址；返回false时删除该图片
+	ResolveImage func(src string) (string, bool)
+}
+
+// Supported 判断文件扩展名是否支持导入
+func Supported(filename string) bool {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".md", ".markdown", ".html", ".htm", ".txt":
+		return true
+	}
+	return false
+}
+
+// Import 按扩展名转换文件，没有标题时使用去掉扩展名的文件名
+func Import(filename string, data []byte, opts Options) (*Result, error) {
+	if !utf8.Valid(data) {
+		return nil, errors.New("file is not valid UTF-8 text")
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var (
+		result *Result
+		err    error
+	)
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".md", ".markdown":
+		result, err = FromMarkdown(data)
+	case ".html", ".htm":
+		var title string
+		var doc editorjson.Node
+		title, doc, err = FromHTML(data)
+		result = &Result{Title: title, Content: doc}
+	case ".txt":
+		result = FromText(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Title == "" {
+		base := path.Base(filename)
+		result.Title = strings.TrimSpace(strings.TrimSuffix(base, path.Ext(base)))
+	}
+	if opts.ResolveImage != nil {
+		resolveImages(result.Content, opts.ResolveImage)
+	}
+	return result, nil
+}
+
+// frontMatter Markdown文件头部的YAML元数据
+type frontMatter struct {
+	Title string      `yaml:"title"`
+	Tags  interface{} `yaml:"tags"`
+}
+
+// FromMarkdown 转换Markdown(GFM)，支持YAML front matter中的title和tags
+func FromMarkdown(src []byte) (*Result, error) {
+	meta, body := splitFrontMatter(src)
+
+	// 原始HTML不会原样输出，HTML转换只保留白名单中的结构，因此允许Markdown中内嵌HTML
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(gmhtml.WithUnsafe()),
+	)
+	var buf bytes.Buffer
+	if err := md.Convert(body, &buf); err != nil {
+		return nil, err
+	}
+
+	title, content, err := parseHTML(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	// Markdown没有<title>，front matter中的标题优先
+	title, content = extractTitle(meta.Title, content)
+
+	result := &Result{Title: title, Content: newDoc(content)}
+	switch tags := meta.Tags.(type) {
+	case string:
+		for _, tag := range strings.Split(tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				result.Tags = append(result.Tags, tag)
+			}
+		}
+	case []interface{}:
+		for _, tag := range tags {
+			if s, ok := tag.(string); ok && strings.TrimSpace(s) != "" {
+				result.Tags = append(result.Tags, strings.TrimSpace(s))
+			}
+		}
+	}
+	return result, nil
+}
+
+// splitFrontMatter 拆分front matter和正文，解析失败时按正文处理
+func splitFrontMatter(src []byte) (frontMatter, []byte) {
+	var meta frontMatter
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return meta, src
+	}
+	end := strings.Index(text[4:], "\n---")
+	if end < 0 {
+		return meta, src
+	}
+	rest := text[4+end+4:]
+	if rest != "" && rest[0] != '\n' {
+		return meta, src
+	}
+	if err := yaml.Unmarshal([]byte(text[4:4+end]), &meta); err != nil {
+		return frontMatter{}, src
+	}
+	return meta, []byte(rest)
+}
+
+// FromText 转换纯文本，空行分隔段落，段落内的换行保留为换行
+func FromText(src []byte) *Result {
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	var blocks []editorjson.Node
+	for _, para := range strings.Split(text, "\n\n") {
+		para = strings.Trim(para, "\n")
+		if strings.TrimSpace(para) == "" {
+			continue
+		}
+		var inline []editorjson.Node
+		for i, line := range strings.Split(para, "\n") {
+			if i > 0 {
+				inline = append(inline, editorjson.Node{"type": "hardBreak"})
+			}
+			if line != "" {
+				inline = append(inline, editorjson.Node{"type": "text", "text": line})
+			}
+		}
+		blocks = append(blocks, editorjson.Node{"type": "paragraph", "content": toList(inline)})
+	}
+	return &Result{Content: newDoc(blocks)}
+}
+
+// resolveImages 处理文档中的所有图片，删除后为空的容器会补上空段落
+func resolveImages(n editorjson.Node, resolve func(string) (string, bool)) {
+	children := n.Children()
+	if len(children) == 0 {
+		return
+	}
+	kept := make([]editorjson.Node, 0, len(children))
+	for _, child := range children {
+		if child.Type() == "image" {
+			src, ok := resolve(child.Attr("src"))
+			if !ok {
+				continue
+			}
+			child.Attrs()["src"] = src
+		}
+		resolveImages(child, resolve)
+		kept = append(kept, child)
+	}
+	if len(kept) == 0 && n.Type() != "doc" {
+		kept = append(kept, editorjson.Node{"type": "paragraph"})
+	}
+	n["content"] = toList(kept)
+}
+
+// newDoc 创建文档根节点
+func newDoc(blocks []editorjson.Node) editorjson.Node {
+	return editorjson.Node{"type": "doc", "content": toList(blocks)}
+}
+
+// toList 将节点转换为JSON数组，元素为map以便editorjson遍历
+func toList(nodes []editorjson.Node) []interface{} {
+	list := make([]interface{}, len(nodes))
+	for i, n := range nodes {
+		list[i] = map[string]interface{}(n)
+	}
+	return list
+}
@@ -0,0 +1,326 @@
+// Package jsonpatch 实现RFC 6902 JSON Patch，用于协作编辑时对文档状态应用增量修改
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Operation 单个JSON Patch操作
+type Operation struct {
+	Op    string
+	Path  string
+	From  string
+	Value interface{}
+	// HasValue 是否提供了value成员，用于区分缺少value和值为null
+	HasValue bool
+}
+
+// operationJSON Operation的JSON表示
+type operationJSON struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// UnmarshalJSON 解析操作并记录是否提供了value成员
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var raw operationJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*o = Operation{Op: raw.Op, Path: raw.Path, From: raw.From}
+	if raw.Value != nil {
+		o.HasValue = true
+		if err := json.Unmarshal(raw.Value, &o.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalJSON 输出操作，提供了value或需要value的操作即使值为null也输出value成员
+func (o Operation) MarshalJSON() ([]byte, error) {
+	raw := operationJSON{Op: o.Op, Path: o.Path, From: o.From}
+	if o.HasValue || requiresValue(o.Op) {
+		value, err := json.Marshal(o.Value)
+		if err != nil {
+			return nil, err
+		}
+		raw.Value = value
+	}
+	return json.Marshal(raw)
+}
+
+// requiresValue 判断操作是否必须提供value成员
+func requiresValue(op string) bool {
+	return op == "add" || op == "replace" || op == "test"
+}
+
+// ErrTestFailed test操作校验失败
+var ErrTestFailed = errors.New("jsonpatch: test operation failed")
+
+// Apply 对文档应用一组操作，返回新文档；任何操作失败时原文档保持不变
+func Apply(doc interface{}, ops []Operation) (interface{}, error) {
+	result := DeepCopy(doc)
+	var err error
+	for i, op := range ops {
+		result, err = applyOne(result, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return result, nil
+}
+
+// applyOne 应用单个操作
+func applyOne(doc interface{}, op Operation) (interface{}, error) {
+	if requiresValue(op.Op) && !op.HasValue {
+		return nil, errors.New("missing value")
+	}
+
+	switch op.Op {
+	case "add":
+		return add(doc, op.Path, DeepCopy(op.Value))
+	case "remove":
+		doc, _, err := remove(doc, op.Path)
+		return doc, err
+	case "replace":
+		doc, _, err := remove(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, DeepCopy(op.Value))
+	case "move":
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, errors.New("cannot move a value into one of its children")
+		}
+		doc, value, err := remove(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, value)
+	case "copy":
+		value, err := get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, DeepCopy(value))
+	case "test":
+		value, err := get(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(normalize(value), normalize(op.Value)) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unsupported operation %q", op.Op)
+	}
+}
+
+// parsePointer 解析JSON Pointer
+func parsePointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex 解析数组下标，allowEnd为true时允许"-"或等于长度的下标（表示追加）
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if idx > length || (idx == length && !allowEnd) {
+		return 0, fmt.Errorf("array index %d out of range", idx)
+	}
+	return idx, nil
+}
+
+// get 获取指针所指的值
+func get(doc interface{}, path string) (interface{}, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found", path)
+			}
+			current = value
+		case []interface{}:
+			idx, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			current = node[idx]
+		default:
+			return nil, fmt.Errorf("path %q not found", path)
+		}
+	}
+	return current, nil
+}
+
+// add 在指针位置添加值，返回（可能被替换的）根文档
+func add(doc interface{}, path string, value interface{}) (interface{}, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return update(doc, tokens, func(parent interface{}, last string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[last] = value
+			return node, nil
+		case []interface{}:
+			idx, err := arrayIndex(last, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[idx+1:], node[idx:])
+			node[idx] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("cannot add to non-container at %q", path)
+		}
+	})
+}
+
+// remove 删除指针位置的值，返回新的根文档和被删除的值
+func remove(doc interface{}, path string) (interface{}, interface{}, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+	var removed interface{}
+	result, err := update(doc, tokens, func(parent interface{}, last string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			value, ok := node[last]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found", path)
+			}
+			removed = value
+			delete(node, last)
+			return node, nil
+		case []interface{}:
+			idx, err := arrayIndex(last, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[idx]
+			return append(node[:idx], node[idx+1:]...), nil
+		default:
+			return nil, fmt.Errorf("path %q not found", path)
+		}
+	})
+	return result, removed, err
+}
+
+// update 定位到父节点并调用fn修改，由于数组修改可能产生新切片，需要逐级写回
+func update(doc interface{}, tokens []string, fn func(parent interface{}, last string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+
+	token := tokens[0]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("path segment %q not found", token)
+		}
+		newChild, err := update(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = newChild
+		return node, nil
+	case []interface{}:
+		idx, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		newChild, err := update(node[idx], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[idx] = newChild
+		return node, nil
+	default:
+		return nil, fmt.Errorf("path segment %q not found", token)
+	}
+}
+
+// DeepCopy 深拷贝由JSON解码得到的值
+func DeepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = DeepCopy(item)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, item := range v {
+			s[i] = DeepCopy(item)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// normalize 统一数值类型，便于test操作比较
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = normalize(item)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, item := range v {
+			s[i] = normalize(item)
+		}
+		return s
+	default:
+		return v
+	}
+}
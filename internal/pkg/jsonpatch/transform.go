@@ -0,0 +1,250 @@
+package jsonpatch
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrConflict 操作与并发修改冲突，无法调整为可以在并发修改之后应用的操作
+var ErrConflict = errors.New("jsonpatch: operation conflicts with a concurrent change")
+
+// effectKind 操作对文档结构的影响
+type effectKind int
+
+const (
+	effectInsert effectKind = iota // 在位置插入值，数组中其后的元素后移
+	effectDelete                   // 删除位置的值，数组中其后的元素前移
+	effectChange                   // 替换位置的值
+)
+
+// effect 操作在某个位置上的影响
+type effect struct {
+	kind effectKind
+	path []string
+}
+
+// Transform 将基于旧状态的ops调整为可以在applied之后应用的操作，applied是在同一旧状态之后已经应用的操作。
+// 数组中插入或删除元素后，ops中位于其后的下标会相应调整。以下情况视为冲突并返回ErrConflict：
+// ops修改的位置已被applied删除，或位于applied替换的值之中；ops删除或替换了包含applied修改的值。
+// 路径中的非负整数视为数组下标，适用于对象的键不会是数字的文档（如编辑器JSON）
+func Transform(ops []Operation, applied []Operation) ([]Operation, error) {
+	result := make([]Operation, len(ops))
+	copy(result, ops)
+
+	for _, a := range applied {
+		concurrent, err := effects(a)
+		if err != nil {
+			return nil, err
+		}
+
+		for k := range result {
+			op := &result[k]
+			if err := checkOverwrite(*op, concurrent); err != nil {
+				return nil, err
+			}
+			if op.Path, err = adjustPointer(op.Path, concurrent); err != nil {
+				return nil, err
+			}
+			if op.Op == "move" || op.Op == "copy" {
+				if op.From, err = adjustPointer(op.From, concurrent); err != nil {
+					return nil, err
+				}
+			}
+
+			// 将并发修改调整到该操作之后，用于调整同一补丁中的后续操作
+			own, err := effects(*op)
+			if err != nil {
+				return nil, err
+			}
+			concurrent = adjustEffects(concurrent, own)
+		}
+	}
+	return result, nil
+}
+
+// effects 返回操作对文档结构的影响，test操作没有影响
+func effects(op Operation) ([]effect, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add", "copy":
+		return []effect{{kind: effectInsert, path: path}}, nil
+	case "remove":
+		return []effect{{kind: effectDelete, path: path}}, nil
+	case "replace":
+		return []effect{{kind: effectChange, path: path}}, nil
+	case "move":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		return []effect{{kind: effectDelete, path: from}, {kind: effectInsert, path: path}}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// checkOverwrite 检查操作是否删除或替换了包含并发修改的值
+func checkOverwrite(op Operation, concurrent []effect) error {
+	var target string
+	switch op.Op {
+	case "remove", "replace":
+		target = op.Path
+	case "move":
+		target = op.From
+	case "add":
+		// 添加对象的键会覆盖原有的值，添加数组元素不会
+		tokens, err := parsePointer(op.Path)
+		if err != nil {
+			return err
+		}
+		if len(tokens) > 0 && !isArrayToken(tokens[len(tokens)-1]) {
+			target = op.Path
+		} else if len(tokens) > 0 {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	tokens, err := parsePointer(target)
+	if err != nil {
+		return err
+	}
+	for _, e := range concurrent {
+		if len(e.path) > len(tokens) && hasPrefix(e.path, tokens) {
+			return ErrConflict
+		}
+	}
+	return nil
+}
+
+// adjustPointer 根据并发修改依次调整JSON Pointer
+func adjustPointer(pointer string, concurrent []effect) (string, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range concurrent {
+		var ok bool
+		if tokens, ok = adjustPath(tokens, e); !ok {
+			return "", ErrConflict
+		}
+	}
+	return formatPointer(tokens), nil
+}
+
+// adjustEffects 根据操作自身的影响调整并发修改，已被该操作删除的位置不再影响后续操作
+func adjustEffects(concurrent []effect, own []effect) []effect {
+	adjusted := make([]effect, 0, len(concurrent))
+	for _, e := range concurrent {
+		path, ok := e.path, true
+		for _, o := range own {
+			if path, ok = adjustPath(path, o); !ok {
+				break
+			}
+		}
+		if ok {
+			adjusted = append(adjusted, effect{kind: e.kind, path: path})
+		}
+	}
+	return adjusted
+}
+
+// adjustPath 根据修改e调整路径，需要调整时返回新切片，不修改原路径。
+// 路径所在的数组元素被删除，或路径位于被删除、替换的值之中时返回false
+func adjustPath(path []string, e effect) ([]string, bool) {
+	n := len(e.path)
+	if n == 0 {
+		// 整个文档被替换，只有根路径本身不受影响
+		return path, len(path) == 0
+	}
+	parent, last := e.path[:n-1], e.path[n-1]
+	if len(path) < n || !hasPrefix(path, parent) {
+		return path, true
+	}
+
+	token := path[n-1]
+	i, lastIsIndex := parseIndex(last)
+	j, tokenIsIndex := parseIndex(token)
+	if lastIsIndex && tokenIsIndex && e.kind != effectChange {
+		switch {
+		case e.kind == effectInsert && j >= i:
+			return withToken(path, n-1, strconv.Itoa(j+1)), true
+		case e.kind == effectDelete && j == i:
+			return path, false
+		case e.kind == effectDelete && j > i:
+			return withToken(path, n-1, strconv.Itoa(j-1)), true
+		}
+		return path, true
+	}
+	if last == "-" && e.kind == effectInsert {
+		// 追加到数组末尾不影响已有元素的下标
+		return path, true
+	}
+
+	if token != last {
+		return path, true
+	}
+	// 同一位置由Apply校验（如删除不存在的键），位于其中的位置已被删除或替换
+	return path, len(path) == n
+}
+
+// parseIndex 解析数组下标
+func parseIndex(token string) (int, bool) {
+	if !isArrayToken(token) || token == "-" {
+		return 0, false
+	}
+	idx, err := strconv.Atoi(token)
+	return idx, err == nil
+}
+
+// isArrayToken 判断路径片段是否为数组下标或"-"
+func isArrayToken(token string) bool {
+	if token == "-" {
+		return true
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return false
+	}
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPrefix 判断路径是否以prefix开头
+func hasPrefix(path, prefix []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// withToken 返回替换了第i个片段的新路径
+func withToken(path []string, i int, token string) []string {
+	result := make([]string, len(path))
+	copy(result, path)
+	result[i] = token
+	return result
+}
+
+// formatPointer 将路径片段格式化为JSON Pointer
+func formatPointer(tokens []string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(t, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
@@ -1,9 +1,9 @@
 package middleware
 
 import (
+	"betalyr-learning-server/internal/pkg/auth"
 	"betalyr-learning-server/internal/pkg/logger"
-	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -45,99 +45,48 @@ func GetAuthType(c *gin.Context) (AuthType, bool) {
 	return authType.(AuthType), true
 }
 
-// 解析JWT令牌获取用户ID (支持Firebase认证)
-func parseJWTToken(token string) string {
+// 验证JWT令牌并获取用户ID (支持Firebase认证)
+func parseJWTToken(token string) (string, error) {
 	// 检查令牌格式，Firebase令牌格式为 "Bearer xxxxx.yyyyy.zzzzz"
 	if !strings.HasPrefix(token, "Bearer ") {
-		logger.Warn("Token format error, missing Bearer prefix")
-		return ""
+		return "", errors.New("token format error, missing Bearer prefix")
 	}
 
-	// 移除"Bearer "前缀
-	tokenOnly := strings.TrimPrefix(token, "Bearer ")
-
-	// 按点分割，获取三个部分
-	parts := strings.Split(tokenOnly, ".")
-	if len(parts) != 3 {
-		logger.Warn("Token format error, not a valid JWT format")
-		return ""
+	if auth.DefaultVerifier == nil {
+		return "", errors.New("JWT verifier is not configured")
 	}
 
-	// 解码payload部分（第二部分）
-	payload, err := base64UrlDecode(parts[1])
+	// 移除"Bearer "前缀后验证签名和声明
+	claims, err := auth.DefaultVerifier.Verify(strings.TrimPrefix(token, "Bearer "))
 	if err != nil {
-		logger.Error("Failed to decode JWT payload", zap.Error(err))
-		return ""
-	}
-
-	// 解析JSON
-	var claims map[string]interface{}
-	if err := json.Unmarshal(payload, &claims); err != nil {
-		logger.Error("Failed to parse JWT payload JSON", zap.Error(err))
-		return ""
-	}
-
-	// 从claims中提取用户ID
-	// Firebase通常使用uid或sub字段作为用户ID
-	if uid, ok := claims["uid"].(string); ok && uid != "" {
-		logger.Info("Extracted uid from Firebase JWT", zap.String("uid", uid))
-		return uid
-	}
-
-	if sub, ok := claims["sub"].(string); ok && sub != "" {
-		logger.Info("Extracted sub from Firebase JWT", zap.String("sub", sub))
-		return sub
-	}
-
-	// Firebase也可能在user_id字段中存储用户ID
-	if userId, ok := claims["user_id"].(string); ok && userId != "" {
-		logger.Info("Extracted user_id from Firebase JWT", zap.String("user_id", userId))
-		return userId
-	}
-
-	// 检查Firebase特有的字段
-	if identities, ok := claims["firebase"].(map[string]interface{}); ok {
-		if sign_in_provider, exists := identities["sign_in_provider"].(string); exists {
-			logger.Info("Detected Firebase authentication", zap.String("provider", sign_in_provider))
-		}
-	}
-
-	// 尝试使用email作为最后的备选
-	if email, ok := claims["email"].(string); ok && email != "" {
-		logger.Info("Using email as user ID", zap.String("email", email))
-		return email
+		return "", err
 	}
 
-	// 记录完整的claims以便调试
-	claimsJson, _ := json.Marshal(claims)
-	logger.Warn("No valid user identifier found in Firebase JWT", zap.String("claims", string(claimsJson)))
-	return ""
+	return claims.UserID(), nil
 }
 
-// base64URL解码
-func base64UrlDecode(str string) ([]byte, error) {
-	// 添加填充
-	padding := 4 - (len(str) % 4)
-	if padding < 4 {
-		str += strings.Repeat("=", padding)
-	}
-
-	// 替换URL安全字符
-	str = strings.ReplaceAll(str, "-", "+")
-	str = strings.ReplaceAll(str, "_", "/")
-
-	return base64.StdEncoding.DecodeString(str)
+// isWebSocketUpgrade 判断请求是否为WebSocket升级请求
+func isWebSocketUpgrade(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader("Upgrade"), "websocket")
 }
 
 // AuthChecker 是一个中间件，用于检查请求头中是否包含X-Virtual-User-ID或Authorization字段
 // 如果含有任一字段，提取用户ID并存储到上下文中
-// 如果两者都没有，则返回401 Unauthorized
+// 如果两者都没有，或提供的JWT令牌无效，则返回401 Unauthorized
 func AuthChecker() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 获取请求头中的认证信息
 		virtualUserId := c.GetHeader("X-Virtual-User-ID")
 		authorization := c.GetHeader("Authorization")
 
+		// 浏览器的WebSocket API无法设置请求头，允许通过查询参数传递认证信息
+		if virtualUserId == "" && authorization == "" && isWebSocketUpgrade(c) {
+			virtualUserId = c.Query("virtualUserId")
+			if token := c.Query("token"); token != "" {
+				authorization = "Bearer " + token
+			}
+		}
+
 		// 检查是否有任意一种认证方式
 		if virtualUserId == "" && authorization == "" {
 			logger.Warn("Request missing authentication",
@@ -157,28 +106,22 @@ func AuthChecker() gin.HandlerFunc {
 		var authType AuthType
 
 		if authorization != "" {
-			// 解析JWT令牌获取用户ID
-			userId = parseJWTToken(authorization)
-			if userId != "" {
-				authType = AuthTypeJWT
-			} else {
-				// JWT解析失败，尝试使用虚拟用户ID
-				if virtualUserId != "" {
-					userId = virtualUserId
-					authType = AuthTypeVirtual
-					logger.Info("JWT token invalid, using virtual user ID authentication",
-						zap.String("virtual_user_id", virtualUserId),
-						zap.String("path", c.Request.URL.Path),
-					)
-				} else {
-					// 两种认证方式都无效
-					c.JSON(http.StatusUnauthorized, gin.H{
-						"error": "Invalid authentication information provided",
-					})
-					c.Abort()
-					return
-				}
+			// 验证JWT令牌获取用户ID，令牌无效时不再回退到虚拟用户ID
+			jwtUserId, err := parseJWTToken(authorization)
+			if err != nil {
+				logger.Warn("JWT token rejected",
+					zap.Error(err),
+					zap.String("path", c.Request.URL.Path),
+					zap.String("ip", c.ClientIP()),
+				)
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": "Invalid authentication information provided",
+				})
+				c.Abort()
+				return
 			}
+			userId = jwtUserId
+			authType = AuthTypeJWT
 		} else if virtualUserId != "" {
 			// 只有虚拟用户ID
 			userId = virtualUserId
@@ -193,3 +136,17 @@ func AuthChecker() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalAuth 是一个可选的身份验证中间件
+// 请求没有提供任何认证信息时以匿名身份继续处理，GetUserID返回false
+// 提供了认证信息时与AuthChecker相同，无效的JWT令牌返回401 Unauthorized
+func OptionalAuth() gin.HandlerFunc {
+	check := AuthChecker()
+	return func(c *gin.Context) {
+		if c.GetHeader("X-Virtual-User-ID") == "" && c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		check(c)
+	}
+}
@@ -0,0 +1,218 @@
+package probe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	ffmpeg_go "github.com/u2takey/ffmpeg-go"
+)
+
+// ErrUnrecognized ffprobe无法识别文件格式，通常是损坏的文件或不是音视频文件
+var ErrUnrecognized = errors.New("unrecognized media file")
+
+// ffprobe的执行超时时间
+const probeTimeout = 2 * time.Minute
+
+// Result 媒体文件的探测结果
+type Result struct {
+	Duration float64      // 时长（秒）
+	Bitrate  int64        // 总比特率（bit/s）
+	Video    *VideoStream // 第一个视频流，音频文件的封面图不算视频流
+	Audio    *AudioStream // 第一个音频流
+}
+
+// VideoStream 视频流信息
+type VideoStream struct {
+	Codec       string
+	Width       int    // 显示宽度，带有旋转信息的视频（如手机竖拍）已交换宽高
+	Height      int    // 显示高度
+	FrameRate   string // 平均帧率，如"29.97"
+	AspectRatio string // 显示宽高比，如"16:9"
+}
+
+// AudioStream 音频流信息
+type AudioStream struct {
+	Codec      string
+	SampleRate int
+	Channels   int
+}
+
+// ffprobe -show_format -show_streams 的JSON输出
+type ffprobeOutput struct {
+	Format struct {
+		Duration string `json:"duration"`
+		BitRate  string `json:"bit_rate"`
+	} `json:"format"`
+	Streams []ffprobeStream `json:"streams"`
+}
+
+type ffprobeStream struct {
+	CodecType          string `json:"codec_type"`
+	CodecName          string `json:"codec_name"`
+	Width              int    `json:"width"`
+	Height             int    `json:"height"`
+	AvgFrameRate       string `json:"avg_frame_rate"`
+	RFrameRate         string `json:"r_frame_rate"`
+	DisplayAspectRatio string `json:"display_aspect_ratio"`
+	SampleRate         string `json:"sample_rate"`
+	Channels           int    `json:"channels"`
+	Duration           string `json:"duration"`
+	BitRate            string `json:"bit_rate"`
+	Disposition        struct {
+		AttachedPic int `json:"attached_pic"`
+	} `json:"disposition"`
+	Tags struct {
+		Rotate string `json:"rotate"`
+	} `json:"tags"`
+	SideDataList []struct {
+		Rotation float64 `json:"rotation"`
+	} `json:"side_data_list"`
+}
+
+// Probe 使用ffprobe探测媒体文件。ffprobe无法识别文件时返回包装了ErrUnrecognized的错误，
+// 其他错误（如找不到ffprobe）原样返回
+func Probe(path string) (*Result, error) {
+	output, err := ffmpeg_go.ProbeWithTimeout(path, probeTimeout, nil)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%w: %v", ErrUnrecognized, err)
+		}
+		return nil, err
+	}
+	return parse([]byte(output))
+}
+
+// parse 解析ffprobe的JSON输出
+func parse(data []byte) (*Result, error) {
+	var output ffprobeOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Duration: parseFloat(output.Format.Duration),
+		Bitrate:  parseInt(output.Format.BitRate),
+	}
+	for _, stream := range output.Streams {
+		switch stream.CodecType {
+		case "video":
+			if result.Video == nil && stream.Disposition.AttachedPic == 0 && stream.Width > 0 && stream.Height > 0 {
+				result.Video = videoStream(stream)
+				fillStreamTotals(result, stream)
+			}
+		case "audio":
+			if result.Audio == nil {
+				result.Audio = &AudioStream{
+					Codec:      stream.CodecName,
+					SampleRate: int(parseInt(stream.SampleRate)),
+					Channels:   stream.Channels,
+				}
+				fillStreamTotals(result, stream)
+			}
+		}
+	}
+	return result, nil
+}
+
+// fillStreamTotals 容器没有提供时长或比特率时（如部分WebM文件）使用流的值
+func fillStreamTotals(result *Result, stream ffprobeStream) {
+	if result.Duration == 0 {
+		result.Duration = parseFloat(stream.Duration)
+	}
+	if result.Bitrate == 0 {
+		result.Bitrate = parseInt(stream.BitRate)
+	}
+}
+
+// videoStream 转换视频流信息，旋转90度或270度的视频交换宽高和宽高比
+func videoStream(stream ffprobeStream) *VideoStream {
+	width, height := stream.Width, stream.Height
+	aspectRatio := stream.DisplayAspectRatio
+	if _, _, ok := parseRatio(aspectRatio, ":"); !ok {
+		aspectRatio = reduceRatio(width, height)
+	}
+
+	rotation, _ := strconv.Atoi(stream.Tags.Rotate)
+	for _, sideData := range stream.SideDataList {
+		if sideData.Rotation != 0 {
+			rotation = int(sideData.Rotation)
+		}
+	}
+	if rotation%180 != 0 {
+		width, height = height, width
+		if w, h, ok := parseRatio(aspectRatio, ":"); ok {
+			aspectRatio = fmt.Sprintf("%d:%d", h, w)
+		}
+	}
+
+	frameRate := formatFrameRate(stream.AvgFrameRate)
+	if frameRate == "" {
+		frameRate = formatFrameRate(stream.RFrameRate)
+	}
+
+	return &VideoStream{
+		Codec:       stream.CodecName,
+		Width:       width,
+		Height:      height,
+		FrameRate:   frameRate,
+		AspectRatio: aspectRatio,
+	}
+}
+
+// formatFrameRate 将"30000/1001"形式的帧率转换为最多两位小数的字符串，无效时返回空字符串
+func formatFrameRate(rate string) string {
+	num, den, ok := parseRatio(rate, "/")
+	if !ok {
+		return ""
+	}
+	value := math.Round(float64(num)/float64(den)*100) / 100
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// parseRatio 解析"a:b"或"a/b"形式的比例，两个值都必须为正数
+func parseRatio(ratio, sep string) (int, int, bool) {
+	a, b, found := strings.Cut(ratio, sep)
+	if !found {
+		return 0, 0, false
+	}
+	x, errX := strconv.Atoi(a)
+	y, errY := strconv.Atoi(b)
+	if errX != nil || errY != nil || x <= 0 || y <= 0 {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// reduceRatio 按最大公约数约分宽高比
+func reduceRatio(width, height int) string {
+	a, b := width, height
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return fmt.Sprintf("%d:%d", width/a, height/a)
+}
+
+// parseFloat 解析ffprobe输出的数字字符串，无效时返回0
+func parseFloat(value string) float64 {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || f < 0 {
+		return 0
+	}
+	return f
+}
+
+// parseInt 解析ffprobe输出的整数字符串，无效时返回0
+func parseInt(value string) int64 {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,63 @@
+// Package search 提供全文搜索使用的文本处理工具
+//
+// PostgreSQL内置的simple配置按空白和标点分词，无法切分中文。
+// 索引和查询前都将中日韩字符之间插入空格，使每个字符成为一个词元。
+package search
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 高亮片段的起止标记，使用控制字符避免与正文冲突
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+// 合并相邻高亮、删除分词时插入的空格
+var (
+	adjacentHighlight = regexp.MustCompile(HighlightStop + `(\s*)` + HighlightStart)
+	cjkSpace          = regexp.MustCompile(`([\p{Han}\p{Hiragana}\p{Katakana}\p{Hangul}][` + HighlightStart + HighlightStop + `]?) ([` + HighlightStart + HighlightStop + `]?[\p{Han}\p{Hiragana}\p{Katakana}\p{Hangul}])`)
+)
+
+// isCJK 判断字符是否为中日韩字符
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// Segment 在中日韩字符前后插入空格，其他文本保持不变
+func Segment(text string) string {
+	var sb strings.Builder
+	sb.Grow(len(text) * 2)
+
+	prevCJK := false
+	for i, r := range text {
+		cjk := isCJK(r)
+		if i > 0 && (cjk || prevCJK) && !unicode.IsSpace(r) {
+			sb.WriteRune(' ')
+		}
+		sb.WriteRune(r)
+		prevCJK = cjk
+	}
+
+	return sb.String()
+}
+
+// FormatSnippet 将ts_headline生成的片段转换为安全的HTML：
+// 转义正文，高亮标记替换为<mark>，并删除分词时插入的空格
+func FormatSnippet(raw string) string {
+	snippet := adjacentHighlight.ReplaceAllString(raw, "$1")
+	// 替换结果可能产生新的相邻匹配，重复两次即可覆盖
+	for i := 0; i < 2; i++ {
+		snippet = cjkSpace.ReplaceAllString(snippet, "$1$2")
+	}
+
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, HighlightStart, "<mark>")
+	snippet = strings.ReplaceAll(snippet, HighlightStop, "</mark>")
+
+	return snippet
+}
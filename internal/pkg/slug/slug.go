@@ -0,0 +1,93 @@
+// Package slug 根据标题生成用于URL的短名称
+//
+// 短名称只包含小写ASCII字母、数字和连字符。中文标题按拼音音译，
+// 带重音的拉丁字母去除重音，其他字符视为分隔符。
+package slug
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength 短名称的最大长度
+const MaxLength = 80
+
+var (
+	pinyinArgs = pinyin.NewArgs()
+	validSlug  = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+// Make 根据文本生成短名称，文本中没有可用字符时返回空字符串
+func Make(text string) string {
+	var sb strings.Builder
+	sep := false
+	word := func(w string) {
+		if sep && sb.Len() > 0 {
+			sb.WriteByte('-')
+		}
+		sep = false
+		sb.WriteString(w)
+	}
+
+	// 先兼容分解字符：全角字母转为半角，重音符号成为单独的组合字符
+	for _, r := range norm.NFKD.String(text) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word(string(unicode.ToLower(r)))
+		case unicode.Is(unicode.Mn, r):
+			// 忽略重音等组合字符
+		case unicode.Is(unicode.Han, r):
+			// 每个汉字的拼音作为单独的词
+			if py := pinyin.SinglePinyin(r, pinyinArgs); len(py) > 0 {
+				sep = true
+				word(py[0])
+			}
+			sep = true
+		default:
+			sep = true
+		}
+	}
+
+	return truncate(sb.String(), MaxLength)
+}
+
+// Valid 判断短名称是否有效
+func Valid(s string) bool {
+	return len(s) <= MaxLength && validSlug.MatchString(s)
+}
+
+// WithSuffix 在短名称后追加数字后缀用于去重，必要时截断原短名称以满足长度限制
+func WithSuffix(s string, n int) string {
+	suffix := "-" + strconv.Itoa(n)
+	return truncate(s, MaxLength-len(suffix)) + suffix
+}
+
+// HasBase 判断短名称是否由base生成，即等于base或为base加数字后缀
+func HasBase(s, base string) bool {
+	if s == base {
+		return true
+	}
+	i := strings.LastIndexByte(s, '-')
+	if i < 0 {
+		return false
+	}
+	n, err := strconv.Atoi(s[i+1:])
+	return err == nil && n > 0 && WithSuffix(base, n) == s
+}
+
+// truncate 将短名称截断到不超过max个字符，尽量在连字符处截断
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max]
+	if i := strings.LastIndexByte(s, '-'); i > 0 {
+		s = s[:i]
+	}
+	return strings.Trim(s, "-")
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"betalyr-learning-server/internal/database"
+	"betalyr-learning-server/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CourseRepository 定义课程仓库接口
+type CourseRepository interface {
+	FindByID(id string) (*models.Course, error)
+	Create(course *models.Course) error
+	Update(course *models.Course) error
+	ReplaceLessons(courseID string, lessons []models.CourseLesson) error
+	Delete(id string) error
+	GetCoursesByOwner(ownerID string) ([]models.Course, error)
+	GetPublishedCourses(page, limit int) ([]models.Course, error)
+	CountPublishedCourses() (int64, error)
+	UpdateOwnerID(oldOwnerID string, newOwnerID string) (int64, error)
+}
+
+// courseRepository 实现课程仓库接口
+type courseRepository struct {
+	db *gorm.DB
+}
+
+// NewCourseRepository 创建新的课程仓库实例
+func NewCourseRepository() CourseRepository {
+	return &courseRepository{
+		db: database.DB,
+	}
+}
+
+// preloadLessons 按顺序预加载课程章节
+func preloadLessons(db *gorm.DB) *gorm.DB {
+	return db.Preload("Lessons", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	})
+}
+
+// FindByID 根据ID查找课程及其章节
+func (r *courseRepository) FindByID(id string) (*models.Course, error) {
+	var course models.Course
+	result := preloadLessons(r.db).Where("id = ?", id).First(&course)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil // 未找到记录返回nil而不是错误
+		}
+		return nil, result.Error
+	}
+	return &course, nil
+}
+
+// Create 创建课程及其章节
+func (r *courseRepository) Create(course *models.Course) error {
+	return r.db.Create(course).Error
+}
+
+// Update 更新课程的基本信息，章节通过ReplaceLessons单独更新
+func (r *courseRepository) Update(course *models.Course) error {
+	return r.db.Omit(clause.Associations).Save(course).Error
+}
+
+// ReplaceLessons 在事务中用新的章节列表替换课程的所有章节
+func (r *courseRepository) ReplaceLessons(courseID string, lessons []models.CourseLesson) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("course_id = ?", courseID).Delete(&models.CourseLesson{}).Error; err != nil {
+			return err
+		}
+		if len(lessons) == 0 {
+			return nil
+		}
+		for i := range lessons {
+			lessons[i].ID = 0
+			lessons[i].CourseID = courseID
+		}
+		return tx.Create(&lessons).Error
+	})
+}
+
+// Delete 删除课程及其章节，不影响章节引用的文档和媒体
+func (r *courseRepository) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("course_id = ?", id).Delete(&models.CourseLesson{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Course{}).Error
+	})
+}
+
+// GetCoursesByOwner 获取用户的所有课程，按更新时间降序排序
+func (r *courseRepository) GetCoursesByOwner(ownerID string) ([]models.Course, error) {
+	var courses []models.Course
+	result := preloadLessons(r.db).
+		Where("owner_id = ?", ownerID).
+		Order("updated_at DESC").
+		Find(&courses)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return courses, nil
+}
+
+// GetPublishedCourses 分页获取公开的课程，按更新时间降序排序
+func (r *courseRepository) GetPublishedCourses(page, limit int) ([]models.Course, error) {
+	var courses []models.Course
+	offset := (page - 1) * limit
+
+	result := preloadLessons(r.db).
+		Where("is_public = ?", true).
+		Order("updated_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&courses)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return courses, nil
+}
+
+// CountPublishedCourses 获取公开课程的总数
+func (r *courseRepository) CountPublishedCourses() (int64, error) {
+	var count int64
+	result := r.db.Model(&models.Course{}).Where("is_public = ?", true).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// UpdateOwnerID 将旧用户的所有课程迁移给新用户
+func (r *courseRepository) UpdateOwnerID(oldOwnerID string, newOwnerID string) (int64, error) {
+	result := r.db.Model(&models.Course{}).
+		Where("owner_id = ?", oldOwnerID).
+		Update("owner_id", newOwnerID)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"betalyr-learning-server/internal/database"
+	"betalyr-learning-server/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DocumentCollaboratorRepository 定义文档协作者仓库接口
+type DocumentCollaboratorRepository interface {
+	Find(documentID, userID string) (*models.DocumentCollaborator, error)
+	ListByDocument(documentID string) ([]models.DocumentCollaborator, error)
+	ListByUser(userID string) ([]models.DocumentCollaborator, error)
+	Upsert(collaborator *models.DocumentCollaborator) error
+	Delete(documentID, userID string) error
+	DeleteByDocument(documentID string) error
+	UpdateUserID(oldUserID string, newUserID string) (int64, error)
+}
+
+// documentCollaboratorRepository 实现文档协作者仓库接口
+type documentCollaboratorRepository struct {
+	db *gorm.DB
+}
+
+// NewDocumentCollaboratorRepository 创建新的文档协作者仓库实例
+func NewDocumentCollaboratorRepository() DocumentCollaboratorRepository {
+	return &documentCollaboratorRepository{
+		db: database.DB,
+	}
+}
+
+// Find 查找用户在文档中的协作者记录
+func (r *documentCollaboratorRepository) Find(documentID, userID string) (*models.DocumentCollaborator, error) {
+	var collaborator models.DocumentCollaborator
+	result := r.db.Where("document_id = ? AND user_id = ?", documentID, userID).First(&collaborator)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil // 未找到记录返回nil而不是错误
+		}
+		return nil, result.Error
+	}
+	return &collaborator, nil
+}
+
+// ListByDocument 获取文档的所有协作者
+func (r *documentCollaboratorRepository) ListByDocument(documentID string) ([]models.DocumentCollaborator, error) {
+	var collaborators []models.DocumentCollaborator
+	result := r.db.Where("document_id = ?", documentID).Order("created_at ASC").Find(&collaborators)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return collaborators, nil
+}
+
+// ListByUser 获取用户参与协作的所有记录，按共享时间降序排序
+func (r *documentCollaboratorRepository) ListByUser(userID string) ([]models.DocumentCollaborator, error) {
+	var collaborators []models.DocumentCollaborator
+	result := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&collaborators)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return collaborators, nil
+}
+
+// Upsert 添加协作者，已存在时更新角色
+func (r *documentCollaboratorRepository) Upsert(collaborator *models.DocumentCollaborator) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "document_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "invited_by", "updated_at"}),
+	}).Create(collaborator).Error
+}
+
+// Delete 移除协作者
+func (r *documentCollaboratorRepository) Delete(documentID, userID string) error {
+	return r.db.Where("document_id = ? AND user_id = ?", documentID, userID).
+		Delete(&models.DocumentCollaborator{}).Error
+}
+
+// DeleteByDocument 移除文档的所有协作者
+func (r *documentCollaboratorRepository) DeleteByDocument(documentID string) error {
+	return r.db.Where("document_id = ?", documentID).Delete(&models.DocumentCollaborator{}).Error
+}
+
+// UpdateUserID 将协作者记录迁移到新的用户ID，新用户已是协作者的文档保留原有记录
+func (r *documentCollaboratorRepository) UpdateUserID(oldUserID string, newUserID string) (int64, error) {
+	var migrated int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.DocumentCollaborator{}).
+			Where("user_id = ?", oldUserID).
+			Where("document_id NOT IN (?)",
+				tx.Model(&models.DocumentCollaborator{}).Select("document_id").Where("user_id = ?", newUserID)).
+			Update("user_id", newUserID)
+		if result.Error != nil {
+			return result.Error
+		}
+		migrated = result.RowsAffected
+
+		// 删除无法迁移的重复记录
+		return tx.Where("user_id = ?", oldUserID).Delete(&models.DocumentCollaborator{}).Error
+	})
+	return migrated, err
+}
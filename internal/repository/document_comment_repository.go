@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"betalyr-learning-server/internal/database"
+	"betalyr-learning-server/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DocumentCommentRepository 定义文档评论仓库接口
+type DocumentCommentRepository interface {
+	FindByID(id string) (*models.DocumentComment, error)
+	Create(comment *models.DocumentComment) error
+	Update(comment *models.DocumentComment) error
+	Delete(id string) error
+	GetThreads(documentID string, includeHidden bool, page, limit int) ([]models.DocumentComment, int64, error)
+	GetReplies(parentIDs []string, includeHidden bool) ([]models.DocumentComment, error)
+	AddReport(report *models.CommentReport, hideThreshold int) (bool, error)
+	DeleteByDocument(documentID string) error
+	UpdateUserID(oldUserID string, newUserID string) (int64, error)
+}
+
+// documentCommentRepository 实现文档评论仓库接口
+type documentCommentRepository struct {
+	db *gorm.DB
+}
+
+// NewDocumentCommentRepository 创建新的文档评论仓库实例
+func NewDocumentCommentRepository() DocumentCommentRepository {
+	return &documentCommentRepository{
+		db: database.DB,
+	}
+}
+
+// FindByID 根据ID查找未删除的评论
+func (r *documentCommentRepository) FindByID(id string) (*models.DocumentComment, error) {
+	var comment models.DocumentComment
+	result := r.db.Where("id = ?", id).First(&comment)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil // 未找到记录返回nil而不是错误
+		}
+		return nil, result.Error
+	}
+	return &comment, nil
+}
+
+// Create 创建评论
+func (r *documentCommentRepository) Create(comment *models.DocumentComment) error {
+	return r.db.Create(comment).Error
+}
+
+// Update 更新评论
+func (r *documentCommentRepository) Update(comment *models.DocumentComment) error {
+	return r.db.Save(comment).Error
+}
+
+// Delete 删除评论，仍有回复的讨论串会以占位形式保留
+func (r *documentCommentRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&models.DocumentComment{}).Error
+}
+
+// threadsQuery 构建文档讨论串查询：未删除的第一条评论，以及已删除但仍有可见回复的第一条评论
+func (r *documentCommentRepository) threadsQuery(documentID string, includeHidden bool) *gorm.DB {
+	replies := `SELECT 1 FROM document_comments AS replies
+		WHERE replies.parent_id = document_comments.id AND replies.deleted_at IS NULL`
+	if !includeHidden {
+		replies += ` AND NOT replies.hidden`
+	}
+
+	query := r.db.Unscoped().Model(&models.DocumentComment{}).
+		Where("document_comments.document_id = ? AND document_comments.parent_id IS NULL", documentID).
+		Where("document_comments.deleted_at IS NULL OR EXISTS (" + replies + ")")
+	if !includeHidden {
+		query = query.Where("document_comments.hidden = ?", false)
+	}
+	return query
+}
+
+// GetThreads 分页获取文档的讨论串第一条评论，按创建时间升序排序，同时返回总数
+func (r *documentCommentRepository) GetThreads(documentID string, includeHidden bool, page, limit int) ([]models.DocumentComment, int64, error) {
+	var total int64
+	if err := r.threadsQuery(documentID, includeHidden).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var comments []models.DocumentComment
+	offset := (page - 1) * limit
+	result := r.threadsQuery(documentID, includeHidden).
+		Order("document_comments.created_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&comments)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	return comments, total, nil
+}
+
+// GetReplies 获取讨论串的回复，按创建时间升序排序
+func (r *documentCommentRepository) GetReplies(parentIDs []string, includeHidden bool) ([]models.DocumentComment, error) {
+	var replies []models.DocumentComment
+	if len(parentIDs) == 0 {
+		return replies, nil
+	}
+
+	query := r.db.Where("parent_id IN ?", parentIDs)
+	if !includeHidden {
+		query = query.Where("hidden = ?", false)
+	}
+	result := query.Order("created_at ASC").Find(&replies)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return replies, nil
+}
+
+// AddReport 保存举报并增加评论的举报次数，举报次数达到hideThreshold时自动隐藏评论。
+// 虚拟用户的举报只保存，不计入举报次数。同一用户重复举报时返回false
+func (r *documentCommentRepository) AddReport(report *models.CommentReport, hideThreshold int) (bool, error) {
+	added := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(report)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		added = true
+		if report.Virtual {
+			return nil
+		}
+
+		return tx.Model(&models.DocumentComment{}).
+			Where("id = ?", report.CommentID).
+			Updates(map[string]interface{}{
+				"report_count": gorm.Expr("report_count + 1"),
+				"hidden_at":    gorm.Expr("CASE WHEN NOT hidden AND report_count + 1 >= ? THEN NOW() ELSE hidden_at END", hideThreshold),
+				"hidden":       gorm.Expr("hidden OR report_count + 1 >= ?", hideThreshold),
+			}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return added, nil
+}
+
+// DeleteByDocument 永久删除文档的所有评论及举报记录
+func (r *documentCommentRepository) DeleteByDocument(documentID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("comment_id IN (?)",
+			tx.Unscoped().Model(&models.DocumentComment{}).Select("id").Where("document_id = ?", documentID)).
+			Delete(&models.CommentReport{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("document_id = ?", documentID).Delete(&models.DocumentComment{}).Error
+	})
+}
+
+// UpdateUserID 将旧用户的评论和举报迁移给新用户，返回迁移的评论数量
+func (r *documentCommentRepository) UpdateUserID(oldUserID string, newUserID string) (int64, error) {
+	var migrated int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&models.DocumentComment{}).
+			Where("author_id = ?", oldUserID).
+			Update("author_id", newUserID)
+		if result.Error != nil {
+			return result.Error
+		}
+		migrated = result.RowsAffected
+
+		if err := tx.Model(&models.CommentReport{}).
+			Where("reporter_id = ?", oldUserID).
+			Where("comment_id NOT IN (?)",
+				tx.Model(&models.CommentReport{}).Select("comment_id").Where("reporter_id = ?", newUserID)).
+			Update("reporter_id", newUserID).Error; err != nil {
+			return err
+		}
+
+		// 删除无法迁移的重复举报
+		return tx.Where("reporter_id = ?", oldUserID).Delete(&models.CommentReport{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return migrated, nil
+}
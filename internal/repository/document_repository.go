@@ -3,22 +3,70 @@ package repository
 import (
 	"betalyr-learning-server/internal/database"
 	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/search"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrVersionConflict 保存文档时版本号已被其他请求修改
+var ErrVersionConflict = errors.New("document version conflict")
+
+// liveSubtreeSQL 递归查询文档及其所有未删除子孙文档的ID
+const liveSubtreeSQL = `WITH RECURSIVE subtree AS (
+		SELECT id FROM documents WHERE id = ? AND deleted_at IS NULL
+		UNION
+		SELECT d.id FROM documents d JOIN subtree s ON d.parent_id = s.id WHERE d.deleted_at IS NULL
+	) SELECT id FROM subtree`
+
+// trashedSubtreeSQL 递归查询回收站中的文档及与其一起被删除的子孙文档的ID
+const trashedSubtreeSQL = `WITH RECURSIVE subtree AS (
+		SELECT id, deleted_at FROM documents WHERE id = ? AND deleted_at IS NOT NULL
+		UNION
+		SELECT d.id, d.deleted_at FROM documents d JOIN subtree s ON d.parent_id = s.id WHERE d.deleted_at = s.deleted_at
+	) SELECT id FROM subtree`
+
 // DocumentRepository 定义文档仓库接口
 type DocumentRepository interface {
 	FindByID(id string) (*models.Document, error)
+	FindByIDs(ids []string) ([]models.Document, error)
 	CheckDocExists(id string) (bool, error)
 	Create(doc *models.Document) error
 	Update(doc *models.Document) error
 	GetDocumentsByOwner(ownerID string) ([]models.Document, error)
-	Delete(id string) error
+	Delete(id string, expectedVersion int64) error
+	FindDeletedByID(id string) (*models.Document, error)
+	GetDeletedByOwner(ownerID string) ([]models.Document, error)
+	GetDeletedBefore(before time.Time, limit int) ([]models.Document, error)
+	Restore(id string) error
+	Purge(id string) ([]string, error)
+	GetTree(ownerID string) ([]models.Document, error)
+	GetChildren(ownerID string, parentID *string) ([]models.Document, error)
+	GetSubtreeIDs(id string) ([]string, error)
+	NextPosition(ownerID string, parentID *string) (int, error)
+	Move(id string, parentID *string, siblingIDs []string) error
+	UpdatePositions(ids []string) error
+	SetDescendantsPublic(id string, isPublic bool) (int64, error)
 	UpdateOwnerID(oldOwnerID string, newOwnerID string) (int64, error)
-	GetPublishedDocs(page, limit int) ([]models.Document, error)
-	CountPublishedDocs() (int64, error)
+	GetPublishedDocs(page, limit int, tag string, sort models.PublicListSort) ([]models.Document, error)
+	CountPublishedDocs(tag string) (int64, error)
+	GetFeedDocs(tag string, ownerID string, limit int) ([]models.Document, error)
+	GetSitemapDocs(limit int) ([]models.Document, error)
+	GetPublicTagCounts(limit int) ([]models.TagCount, error)
+	GetOwnerTags(ownerID string, prefix string, limit int) ([]models.TagCount, error)
+	SearchDocuments(query models.DocumentSearchQuery) ([]models.DocumentSearchResult, int64, error)
+	RebuildSearchIndex() (int64, error)
+	BackfillTags() (int64, error)
+	FindBySlug(slug string) (*models.Document, error)
+	FindSlugRedirect(slug string) (*models.DocumentSlugRedirect, error)
+	IsSlugTaken(slug string, exceptID string) (bool, error)
+	SetSlug(id string, slug string, custom bool) error
+	FindPublicWithoutSlug(afterID string, limit int) ([]models.Document, error)
 }
 
 // documentRepository 实现文档仓库接口
@@ -46,6 +94,19 @@ func (r *documentRepository) FindByID(id string) (*models.Document, error) {
 	return &doc, nil
 }
 
+// FindByIDs 根据ID列表批量查找文档，不存在的ID会被忽略
+func (r *documentRepository) FindByIDs(ids []string) ([]models.Document, error) {
+	var docs []models.Document
+	if len(ids) == 0 {
+		return docs, nil
+	}
+	result := r.db.Where("id IN ?", ids).Find(&docs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return docs, nil
+}
+
 // CheckDocExists 检查文档是否存在
 func (r *documentRepository) CheckDocExists(id string) (bool, error) {
 	var count int64
@@ -61,31 +122,266 @@ func (r *documentRepository) Create(doc *models.Document) error {
 	return r.db.Create(doc).Error
 }
 
-// Update 更新文档
+// Update 更新文档，仅当数据库中的版本号与doc.Version一致时才会写入，成功后版本号加一
 func (r *documentRepository) Update(doc *models.Document) error {
-	return r.db.Save(doc).Error
+	expectedVersion := doc.Version
+	doc.Version = expectedVersion + 1
+
+	// 层级、回收站状态和短名称由专门的方法维护，不随内容更新写入
+	result := r.db.Model(doc).
+		Where("version = ?", expectedVersion).
+		Select("*").
+		Omit("parent_id", "position", "deleted_at", "slug", "slug_custom").
+		Updates(doc)
+	if result.Error != nil {
+		doc.Version = expectedVersion
+		return result.Error
+	}
+
+	// 没有更新任何行，说明文档已被其他请求修改
+	if result.RowsAffected == 0 {
+		doc.Version = expectedVersion
+		return ErrVersionConflict
+	}
+
+	return nil
 }
 
-// GetDocumentsByOwner 获取用户的所有文档
+// GetDocumentsByOwner 获取用户的所有文档，按同级排序位置排序
 func (r *documentRepository) GetDocumentsByOwner(ownerID string) ([]models.Document, error) {
 	var docs []models.Document
-	result := r.db.Where("owner_id = ?", ownerID).Find(&docs)
+	result := r.db.Where("owner_id = ?", ownerID).Order("position ASC, created_at ASC").Find(&docs)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return docs, nil
 }
 
-// Delete 删除文档
-func (r *documentRepository) Delete(id string) error {
-	return r.db.Where("id = ?", id).Delete(&models.Document{}).Error
+// Delete 将文档及其所有子孙文档移入回收站（软删除），同一次删除的文档删除时间相同。
+// expectedVersion大于0时只有文档版本号仍为该值才会删除，否则返回ErrVersionConflict
+func (r *documentRepository) Delete(id string, expectedVersion int64) error {
+	if expectedVersion <= 0 {
+		return r.db.Exec(`UPDATE documents SET deleted_at = ? WHERE id IN (`+liveSubtreeSQL+`)`, time.Now(), id).Error
+	}
+
+	// 版本条件与删除在同一条语句中，避免检查版本后文档又被修改
+	result := r.db.Exec(`UPDATE documents SET deleted_at = ? WHERE id IN (`+liveSubtreeSQL+`)
+		AND EXISTS (SELECT 1 FROM documents WHERE id = ? AND version = ? AND deleted_at IS NULL)`,
+		time.Now(), id, id, expectedVersion)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// FindDeletedByID 根据ID查找回收站中的文档
+func (r *documentRepository) FindDeletedByID(id string) (*models.Document, error) {
+	var doc models.Document
+	result := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&doc)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &doc, nil
+}
+
+// GetDeletedByOwner 获取用户回收站中的文档，按删除时间降序排序
+func (r *documentRepository) GetDeletedByOwner(ownerID string) ([]models.Document, error) {
+	var docs []models.Document
+	result := r.db.Unscoped().
+		Where("owner_id = ? AND deleted_at IS NOT NULL", ownerID).
+		Order("deleted_at DESC").
+		Find(&docs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return docs, nil
+}
+
+// GetDeletedBefore 获取在before之前移入回收站的文档，最多返回limit个
+func (r *documentRepository) GetDeletedBefore(before time.Time, limit int) ([]models.Document, error) {
+	var docs []models.Document
+	result := r.db.Unscoped().
+		Select("id", "owner_id", "deleted_at").
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&docs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return docs, nil
+}
+
+// Restore 恢复回收站中的文档及与其一起删除的子孙文档，父文档已不存在时移动到顶层末尾
+func (r *documentRepository) Restore(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var doc models.Document
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&doc).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec(`UPDATE documents SET deleted_at = NULL WHERE id IN (`+trashedSubtreeSQL+`)`, id).Error; err != nil {
+			return err
+		}
+
+		if doc.ParentID == nil {
+			return nil
+		}
+		var parents int64
+		if err := tx.Model(&models.Document{}).Where("id = ?", *doc.ParentID).Count(&parents).Error; err != nil {
+			return err
+		}
+		if parents > 0 {
+			return nil
+		}
+
+		position, err := nextPosition(tx, doc.OwnerID, nil)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Document{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"parent_id": nil,
+			"position":  position,
+		}).Error
+	})
+}
+
+// Purge 永久删除回收站中的文档及与其一起删除的子孙文档，返回被删除的文档ID
+func (r *documentRepository) Purge(id string) ([]string, error) {
+	var ids []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(trashedSubtreeSQL, id).Scan(&ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		// 释放文档曾经使用过的短名称
+		if err := tx.Where("document_id IN ?", ids).Delete(&models.DocumentSlugRedirect{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Document{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetTree 获取用户的所有文档（不含正文），用于组装文档树
+func (r *documentRepository) GetTree(ownerID string) ([]models.Document, error) {
+	var docs []models.Document
+	result := r.db.
+		Select("id", "parent_id", "position", "title", "icon_image", "is_public", "created_at").
+		Where("owner_id = ?", ownerID).
+		Order("position ASC, created_at ASC").
+		Find(&docs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return docs, nil
+}
+
+// whereParent 添加父文档条件，parentID为空时查询顶层文档
+func whereParent(db *gorm.DB, parentID *string) *gorm.DB {
+	if parentID == nil {
+		return db.Where("parent_id IS NULL")
+	}
+	return db.Where("parent_id = ?", *parentID)
+}
+
+// GetChildren 获取用户在指定父文档下的直接子文档，按排序位置排序
+func (r *documentRepository) GetChildren(ownerID string, parentID *string) ([]models.Document, error) {
+	var docs []models.Document
+	result := whereParent(r.db.Select("id", "parent_id", "position", "title", "created_at").
+		Where("owner_id = ?", ownerID), parentID).
+		Order("position ASC, created_at ASC").
+		Find(&docs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return docs, nil
+}
+
+// GetSubtreeIDs 获取文档及其所有子孙文档的ID
+func (r *documentRepository) GetSubtreeIDs(id string) ([]string, error) {
+	var ids []string
+	if err := r.db.Raw(liveSubtreeSQL, id).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// NextPosition 获取在指定父文档下追加文档时使用的排序位置
+func (r *documentRepository) NextPosition(ownerID string, parentID *string) (int, error) {
+	return nextPosition(r.db, ownerID, parentID)
+}
+
+// nextPosition 返回同级文档最大排序位置加一
+func nextPosition(db *gorm.DB, ownerID string, parentID *string) (int, error) {
+	var position int
+	err := whereParent(db.Model(&models.Document{}).Where("owner_id = ?", ownerID), parentID).
+		Select("COALESCE(MAX(position), -1) + 1").
+		Scan(&position).Error
+	return position, err
+}
+
+// Move 将文档移动到新的父文档下，siblingIDs为移动后新父文档下所有子文档的顺序（包含被移动的文档）
+func (r *documentRepository) Move(id string, parentID *string, siblingIDs []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Document{}).Where("id = ?", id).UpdateColumn("parent_id", parentID).Error; err != nil {
+			return err
+		}
+		return updatePositions(tx, siblingIDs)
+	})
+}
+
+// UpdatePositions 按ids的顺序设置文档的排序位置
+func (r *documentRepository) UpdatePositions(ids []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return updatePositions(tx, ids)
+	})
+}
+
+// updatePositions 按ids的顺序设置排序位置，位置未变化的文档不会被更新
+func updatePositions(tx *gorm.DB, ids []string) error {
+	for i, id := range ids {
+		err := tx.Model(&models.Document{}).
+			Where("id = ? AND position <> ?", id, i).
+			UpdateColumn("position", i).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetDescendantsPublic 设置文档所有子孙文档（不含文档本身）的公开状态，返回更新的文档数量
+func (r *documentRepository) SetDescendantsPublic(id string, isPublic bool) (int64, error) {
+	result := r.db.Exec(`UPDATE documents
+		SET is_public = ?, updated_at = ?, version = version + 1
+		WHERE id IN (`+liveSubtreeSQL+`) AND id <> ? AND is_public IS DISTINCT FROM ?`,
+		isPublic, time.Now(), id, id, isPublic)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
 }
 
-// UpdateOwnerID 批量更新文档所有者ID
+// UpdateOwnerID 批量更新文档所有者ID，包括回收站中的文档
 func (r *documentRepository) UpdateOwnerID(oldOwnerID string, newOwnerID string) (int64, error) {
-	result := r.db.Model(&models.Document{}).
+	result := r.db.Unscoped().Model(&models.Document{}).
 		Where("owner_id = ?", oldOwnerID).
-		Update("owner_id", newOwnerID)
+		Updates(map[string]interface{}{
+			"owner_id": newOwnerID,
+			"version":  gorm.Expr("version + 1"),
+		})
 
 	if result.Error != nil {
 		return 0, result.Error
@@ -94,14 +390,28 @@ func (r *documentRepository) UpdateOwnerID(oldOwnerID string, newOwnerID string)
 	return result.RowsAffected, nil
 }
 
-// GetPublishedDocs 获取所有公开发布的文章，支持分页
-func (r *documentRepository) GetPublishedDocs(page, limit int) ([]models.Document, error) {
+// publishedDocsQuery 构建公开文档查询，tag不为空时只查询带有该标签的文档
+func (r *documentRepository) publishedDocsQuery(tag string) *gorm.DB {
+	query := r.db.Model(&models.Document{}).Where("is_public = ?", true)
+	if tag != "" {
+		query = query.Where("tags @> ?", tagFilter(tag))
+	}
+	return query
+}
+
+// tagFilter 构建jsonb包含查询的参数
+func tagFilter(tag string) string {
+	data, _ := json.Marshal([]string{tag})
+	return string(data)
+}
+
+// GetPublishedDocs 获取所有公开发布的文章，支持分页、按标签过滤和按热度排序
+func (r *documentRepository) GetPublishedDocs(page, limit int, tag string, sort models.PublicListSort) ([]models.Document, error) {
 	var docs []models.Document
 	offset := (page - 1) * limit
 
-	// 查询公开的文档，按更新时间降序排序
-	result := r.db.Where("is_public = ?", true).
-		Order("updated_at DESC").
+	// 查询公开的文档，默认按更新时间降序排序
+	result := orderByPublicSort(r.publishedDocsQuery(tag), "documents", models.EngagementItemDocument, sort, "updated_at").
 		Offset(offset).
 		Limit(limit).
 		Find(&docs)
@@ -113,12 +423,38 @@ func (r *documentRepository) GetPublishedDocs(page, limit int) ([]models.Documen
 	return docs, nil
 }
 
+// GetFeedDocs 获取订阅源中的公开文档，按更新时间降序排序，tag和ownerID不为空时分别按标签和作者过滤
+func (r *documentRepository) GetFeedDocs(tag string, ownerID string, limit int) ([]models.Document, error) {
+	var docs []models.Document
+	query := r.publishedDocsQuery(tag)
+	if ownerID != "" {
+		query = query.Where("owner_id = ?", ownerID)
+	}
+	result := query.Order("updated_at DESC").Limit(limit).Find(&docs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return docs, nil
+}
+
+// GetSitemapDocs 获取站点地图中的公开文档（只包含ID、短名称和更新时间），按更新时间降序排序
+func (r *documentRepository) GetSitemapDocs(limit int) ([]models.Document, error) {
+	var docs []models.Document
+	result := r.publishedDocsQuery("").
+		Select("id", "slug", "updated_at").
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&docs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return docs, nil
+}
+
 // CountPublishedDocs 统计所有公开文档的数量
-func (r *documentRepository) CountPublishedDocs() (int64, error) {
+func (r *documentRepository) CountPublishedDocs(tag string) (int64, error) {
 	var count int64
-	result := r.db.Model(&models.Document{}).
-		Where("is_public = ?", true).
-		Count(&count)
+	result := r.publishedDocsQuery(tag).Count(&count)
 
 	if result.Error != nil {
 		return 0, result.Error
@@ -126,3 +462,231 @@ func (r *documentRepository) CountPublishedDocs() (int64, error) {
 
 	return count, nil
 }
+
+// GetPublicTagCounts 统计公开文档中各标签的使用次数，按次数降序排序
+func (r *documentRepository) GetPublicTagCounts(limit int) ([]models.TagCount, error) {
+	var counts []models.TagCount
+	result := r.db.Raw(`SELECT tag, COUNT(*) AS count
+		FROM documents, jsonb_array_elements_text(documents.tags) AS tag
+		WHERE documents.is_public = ? AND documents.deleted_at IS NULL
+		GROUP BY tag
+		ORDER BY count DESC, tag ASC
+		LIMIT ?`, true, limit).
+		Scan(&counts)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return counts, nil
+}
+
+// GetOwnerTags 获取用户文档中以prefix开头的标签及使用次数，用于标签自动补全
+func (r *documentRepository) GetOwnerTags(ownerID string, prefix string, limit int) ([]models.TagCount, error) {
+	var counts []models.TagCount
+	result := r.db.Raw(`SELECT tag, COUNT(*) AS count
+		FROM documents, jsonb_array_elements_text(documents.tags) AS tag
+		WHERE documents.owner_id = ? AND documents.deleted_at IS NULL AND tag LIKE ?
+		GROUP BY tag
+		ORDER BY count DESC, tag ASC
+		LIMIT ?`, ownerID, escapeLike(prefix)+"%", limit).
+		Scan(&counts)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return counts, nil
+}
+
+// SearchDocuments 全文搜索文档，按相关度排序并生成高亮片段
+func (r *documentRepository) SearchDocuments(query models.DocumentSearchQuery) ([]models.DocumentSearchResult, int64, error) {
+	// 构建过滤条件
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("documents.search_vector @@ q AND documents.deleted_at IS NULL")
+		if query.PublicOnly {
+			db = db.Where("documents.is_public = ?", true)
+		}
+		if query.OwnerID != "" {
+			db = db.Where("documents.owner_id = ?", query.OwnerID)
+		}
+		if query.Tag != "" {
+			db = db.Where("documents.tags @> ?", tagFilter(query.Tag))
+		}
+		return db
+	}
+	from := "documents, websearch_to_tsquery('simple', ?) AS q"
+
+	var total int64
+	if err := filter(r.db.Table(from, query.Query)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	headlineOptions := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \"",
+		search.HighlightStart, search.HighlightStop)
+
+	var results []models.DocumentSearchResult
+	err := filter(r.db.Table(from, query.Query)).
+		Select(`documents.id, documents.owner_id, documents.title, documents.icon_image, documents.tags,
+			COALESCE(documents.is_public, false) AS is_public, documents.created_at, documents.updated_at,
+			ts_rank_cd(documents.search_vector, q) AS rank,
+			ts_headline('simple', documents.search_text, q, ?) AS snippet`, headlineOptions).
+		Order("rank DESC, documents.updated_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Scan(&results).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
+// RebuildSearchIndex 为尚未建立搜索索引的文档重新计算搜索文本，返回处理的文档数量
+func (r *documentRepository) RebuildSearchIndex() (int64, error) {
+	var docs []models.Document
+	var updated int64
+
+	result := r.db.Unscoped().Where("search_vector IS NULL").FindInBatches(&docs, 100, func(_ *gorm.DB, _ int) error {
+		for i := range docs {
+			docs[i].RefreshIndex()
+			err := r.db.Unscoped().Model(&docs[i]).UpdateColumns(map[string]interface{}{
+				"tags":         docs[i].Tags,
+				"search_title": docs[i].SearchTitle,
+				"search_text":  docs[i].SearchText,
+			}).Error
+			if err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+
+	return updated, result.Error
+}
+
+// BackfillTags 为标签持久化之前保存的文档补充标签，只检查没有标签、但有手动标签或
+// 内容中可能包含标签的文档，返回补充了标签的文档数量。之后保存的文档都会计算标签，
+// 因此全部检查完成后记录迁移已完成，之后启动时不再扫描
+func (r *documentRepository) BackfillTags() (int64, error) {
+	var migration models.DataMigration
+	err := r.db.Where("name = ?", models.DataMigrationDocumentTags).Limit(1).Find(&migration).Error
+	if err != nil || migration.Name != "" {
+		return 0, err
+	}
+
+	var docs []models.Document
+	var updated int64
+
+	result := r.db.Unscoped().
+		Where("tags IS NULL OR tags = '[]'::jsonb").
+		Where(`manual_tags <> '[]'::jsonb OR editor_json::text LIKE '%#%'
+			OR editor_json::text LIKE '%"type": "tag"%' OR editor_json::text LIKE '%"type": "hashtag"%'`).
+		FindInBatches(&docs, 100, func(_ *gorm.DB, _ int) error {
+			for i := range docs {
+				docs[i].RefreshTags()
+				if len(docs[i].Tags) == 0 {
+					continue
+				}
+				err := r.db.Unscoped().Model(&docs[i]).UpdateColumn("tags", docs[i].Tags).Error
+				if err != nil {
+					return err
+				}
+				updated++
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return updated, result.Error
+	}
+
+	err = r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.DataMigration{
+		Name:        models.DataMigrationDocumentTags,
+		CompletedAt: time.Now(),
+	}).Error
+	return updated, err
+}
+
+// FindBySlug 根据当前短名称查找文档
+func (r *documentRepository) FindBySlug(slug string) (*models.Document, error) {
+	var doc models.Document
+	result := r.db.Where("slug = ?", slug).First(&doc)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil // 未找到记录返回nil而不是错误
+		}
+		return nil, result.Error
+	}
+	return &doc, nil
+}
+
+// FindSlugRedirect 查找文档曾经使用过的短名称
+func (r *documentRepository) FindSlugRedirect(slug string) (*models.DocumentSlugRedirect, error) {
+	var redirect models.DocumentSlugRedirect
+	result := r.db.Where("slug = ?", slug).First(&redirect)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil // 未找到记录返回nil而不是错误
+		}
+		return nil, result.Error
+	}
+	return &redirect, nil
+}
+
+// IsSlugTaken 检查短名称是否已被其他文档使用，包括回收站中的文档和其他文档曾经使用过的短名称
+func (r *documentRepository) IsSlugTaken(slug string, exceptID string) (bool, error) {
+	var taken bool
+	err := r.db.Raw(`SELECT EXISTS (SELECT 1 FROM documents WHERE slug = ? AND id <> ?)
+		OR EXISTS (SELECT 1 FROM document_slug_redirects WHERE slug = ? AND document_id <> ?)`,
+		slug, exceptID, slug, exceptID).Scan(&taken).Error
+	return taken, err
+}
+
+// FindPublicWithoutSlug 按ID顺序获取ID大于afterID、还没有短名称的公开文档，用于补充短名称
+func (r *documentRepository) FindPublicWithoutSlug(afterID string, limit int) ([]models.Document, error) {
+	var docs []models.Document
+	result := r.db.Where("is_public = ? AND slug IS NULL AND id > ?", true, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&docs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return docs, nil
+}
+
+// SetSlug 设置文档的短名称，原短名称保留为重定向，不改变文档的版本号
+func (r *documentRepository) SetSlug(id string, slug string, custom bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var doc models.Document
+		if err := tx.Unscoped().Select("id", "slug").Where("id = ?", id).First(&doc).Error; err != nil {
+			return err
+		}
+
+		if doc.Slug != nil && *doc.Slug != slug {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "slug"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"document_id": id, "created_at": time.Now()}),
+			}).Create(&models.DocumentSlugRedirect{Slug: *doc.Slug, DocumentID: id}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// 重新使用以前的短名称时删除对应的重定向
+		if err := tx.Where("slug = ?", slug).Delete(&models.DocumentSlugRedirect{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Model(&models.Document{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"slug":        slug,
+			"slug_custom": custom,
+		}).Error
+	})
+}
+
+// escapeLike 转义LIKE模式中的特殊字符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"betalyr-learning-server/internal/database"
+	"betalyr-learning-server/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// DocumentRevisionRepository 定义文档修订版本仓库接口
+type DocumentRevisionRepository interface {
+	Create(rev *models.DocumentRevision) error
+	FindByID(documentID, id string) (*models.DocumentRevision, error)
+	FindLatest(documentID string) (*models.DocumentRevision, error)
+	ListByDocument(documentID string) ([]models.DocumentRevision, error)
+	DeleteByIDs(ids []string) error
+	DeleteByDocument(documentID string) error
+}
+
+// documentRevisionRepository 实现文档修订版本仓库接口
+type documentRevisionRepository struct {
+	db *gorm.DB
+}
+
+// NewDocumentRevisionRepository 创建新的文档修订版本仓库实例
+func NewDocumentRevisionRepository() DocumentRevisionRepository {
+	return &documentRevisionRepository{
+		db: database.DB,
+	}
+}
+
+// Create 创建修订版本
+func (r *documentRevisionRepository) Create(rev *models.DocumentRevision) error {
+	return r.db.Create(rev).Error
+}
+
+// FindByID 根据ID查找某个文档的修订版本
+func (r *documentRevisionRepository) FindByID(documentID, id string) (*models.DocumentRevision, error) {
+	var rev models.DocumentRevision
+	result := r.db.Where("id = ? AND document_id = ?", id, documentID).First(&rev)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil // 未找到记录返回nil而不是错误
+		}
+		return nil, result.Error
+	}
+	return &rev, nil
+}
+
+// FindLatest 获取文档最新的修订版本
+func (r *documentRevisionRepository) FindLatest(documentID string) (*models.DocumentRevision, error) {
+	var rev models.DocumentRevision
+	result := r.db.Where("document_id = ?", documentID).Order("created_at DESC").First(&rev)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &rev, nil
+}
+
+// ListByDocument 获取文档的所有修订版本（不包含内容），按时间降序排序
+func (r *documentRevisionRepository) ListByDocument(documentID string) ([]models.DocumentRevision, error) {
+	var revs []models.DocumentRevision
+	result := r.db.Select("id", "document_id", "author_id", "title", "size", "summary", "created_at").
+		Where("document_id = ?", documentID).
+		Order("created_at DESC").
+		Find(&revs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return revs, nil
+}
+
+// DeleteByIDs 批量删除修订版本
+func (r *documentRevisionRepository) DeleteByIDs(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Where("id IN ?", ids).Delete(&models.DocumentRevision{}).Error
+}
+
+// DeleteByDocument 删除文档的所有修订版本
+func (r *documentRevisionRepository) DeleteByDocument(documentID string) error {
+	return r.db.Where("document_id = ?", documentID).Delete(&models.DocumentRevision{}).Error
+}
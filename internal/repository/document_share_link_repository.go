@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"betalyr-learning-server/internal/database"
+	"betalyr-learning-server/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// DocumentShareLinkRepository 定义文档分享链接仓库接口
+type DocumentShareLinkRepository interface {
+	Create(link *models.DocumentShareLink) error
+	FindByToken(token string) (*models.DocumentShareLink, error)
+	ListByDocument(documentID string) ([]models.DocumentShareLink, error)
+	Delete(documentID, id string) (bool, error)
+	DeleteByDocument(documentID string) error
+}
+
+// documentShareLinkRepository 实现文档分享链接仓库接口
+type documentShareLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewDocumentShareLinkRepository 创建新的文档分享链接仓库实例
+func NewDocumentShareLinkRepository() DocumentShareLinkRepository {
+	return &documentShareLinkRepository{
+		db: database.DB,
+	}
+}
+
+// Create 创建分享链接
+func (r *documentShareLinkRepository) Create(link *models.DocumentShareLink) error {
+	return r.db.Create(link).Error
+}
+
+// FindByToken 根据token查找分享链接
+func (r *documentShareLinkRepository) FindByToken(token string) (*models.DocumentShareLink, error) {
+	var link models.DocumentShareLink
+	result := r.db.Where("token = ?", token).First(&link)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil // 未找到记录返回nil而不是错误
+		}
+		return nil, result.Error
+	}
+	return &link, nil
+}
+
+// ListByDocument 获取文档的所有分享链接，按创建时间降序排序
+func (r *documentShareLinkRepository) ListByDocument(documentID string) ([]models.DocumentShareLink, error) {
+	var links []models.DocumentShareLink
+	result := r.db.Where("document_id = ?", documentID).Order("created_at DESC").Find(&links)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return links, nil
+}
+
+// Delete 删除文档的某个分享链接，返回是否删除了记录
+func (r *documentShareLinkRepository) Delete(documentID, id string) (bool, error) {
+	result := r.db.Where("document_id = ? AND id = ?", documentID, id).Delete(&models.DocumentShareLink{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteByDocument 删除文档的所有分享链接
+func (r *documentShareLinkRepository) DeleteByDocument(documentID string) error {
+	return r.db.Where("document_id = ?", documentID).Delete(&models.DocumentShareLink{}).Error
+}
@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"betalyr-learning-server/internal/database"
+	"betalyr-learning-server/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 热门和趋势排序使用的分数：点赞、收藏、浏览的加权总数；趋势分数再除以(发布小时数+2)^trendingGravity
+const (
+	popularityScore = `(COALESCE(engagement_stats.like_count, 0) * 5 +
+		COALESCE(engagement_stats.bookmark_count, 0) * 3 +
+		COALESCE(engagement_stats.view_count, 0))`
+	trendingGravity = "1.5"
+)
+
+// EngagementRepository 定义点赞、收藏和浏览统计仓库接口
+type EngagementRepository interface {
+	// 点赞，已点赞时返回false
+	AddLike(like *models.ItemLike) (bool, error)
+	// 取消点赞，未点赞时返回false
+	RemoveLike(userID string, itemType models.EngagementItemType, itemID string) (bool, error)
+	// 收藏，已收藏时返回false
+	AddBookmark(bookmark *models.ItemBookmark) (bool, error)
+	// 取消收藏，未收藏时返回false
+	RemoveBookmark(userID string, itemType models.EngagementItemType, itemID string) (bool, error)
+	// 获取用户是否已点赞、收藏某个内容
+	GetUserState(userID string, itemType models.EngagementItemType, itemID string) (liked bool, bookmarked bool, err error)
+	// 分页获取用户的收藏，itemType为空时返回所有类型，按收藏时间降序排序
+	GetBookmarks(userID string, itemType models.EngagementItemType, page, limit int) ([]models.ItemBookmark, int64, error)
+	// 记录一次浏览，同一观看者在window内的重复浏览不计数，计数时返回true
+	RecordView(itemType models.EngagementItemType, itemID, viewerKey string, window time.Duration) (bool, error)
+	// 批量获取内容的互动统计，没有统计记录的内容不包含在结果中
+	GetStats(itemType models.EngagementItemType, itemIDs []string) (map[string]*models.EngagementStats, error)
+	// 删除内容的所有点赞、收藏、浏览记录和统计
+	DeleteByItem(itemType models.EngagementItemType, itemID string) error
+	// 将旧用户的点赞和收藏迁移给新用户，返回迁移的记录数量
+	UpdateUserID(oldUserID string, newUserID string) (int64, error)
+}
+
+// engagementRepository 实现点赞、收藏和浏览统计仓库接口
+type engagementRepository struct {
+	db *gorm.DB
+}
+
+// NewEngagementRepository 创建新的点赞、收藏和浏览统计仓库实例
+func NewEngagementRepository() EngagementRepository {
+	return &engagementRepository{
+		db: database.DB,
+	}
+}
+
+// AddLike 点赞并增加点赞数
+func (r *engagementRepository) AddLike(like *models.ItemLike) (bool, error) {
+	return r.addRecord(like, like.ItemType, like.ItemID, "like_count")
+}
+
+// RemoveLike 取消点赞并减少点赞数
+func (r *engagementRepository) RemoveLike(userID string, itemType models.EngagementItemType, itemID string) (bool, error) {
+	return r.removeRecord(&models.ItemLike{}, userID, itemType, itemID, "like_count")
+}
+
+// AddBookmark 收藏并增加收藏数
+func (r *engagementRepository) AddBookmark(bookmark *models.ItemBookmark) (bool, error) {
+	return r.addRecord(bookmark, bookmark.ItemType, bookmark.ItemID, "bookmark_count")
+}
+
+// RemoveBookmark 取消收藏并减少收藏数
+func (r *engagementRepository) RemoveBookmark(userID string, itemType models.EngagementItemType, itemID string) (bool, error) {
+	return r.removeRecord(&models.ItemBookmark{}, userID, itemType, itemID, "bookmark_count")
+}
+
+// addRecord 保存点赞或收藏记录，记录不存在时同时增加统计中column对应的数量
+func (r *engagementRepository) addRecord(record interface{}, itemType models.EngagementItemType, itemID, column string) (bool, error) {
+	added := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		added = true
+		return incrementStat(tx, itemType, itemID, column, 1)
+	})
+	if err != nil {
+		return false, err
+	}
+	return added, nil
+}
+
+// removeRecord 删除点赞或收藏记录，记录存在时同时减少统计中column对应的数量
+func (r *engagementRepository) removeRecord(model interface{}, userID string, itemType models.EngagementItemType, itemID, column string) (bool, error) {
+	removed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND item_type = ? AND item_id = ?", userID, itemType, itemID).Delete(model)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		removed = true
+		return incrementStat(tx, itemType, itemID, column, -1)
+	})
+	if err != nil {
+		return false, err
+	}
+	return removed, nil
+}
+
+// incrementStat 修改内容统计中column对应的数量，统计记录不存在时创建，数量不会小于0
+func incrementStat(tx *gorm.DB, itemType models.EngagementItemType, itemID, column string, delta int64) error {
+	initial := delta
+	if initial < 0 {
+		initial = 0
+	}
+	return tx.Exec(`INSERT INTO engagement_stats (item_type, item_id, `+column+`) VALUES (?, ?, ?)
+		ON CONFLICT (item_type, item_id) DO UPDATE
+		SET `+column+` = GREATEST(engagement_stats.`+column+` + ?, 0)`,
+		itemType, itemID, initial, delta).Error
+}
+
+// GetUserState 获取用户是否已点赞、收藏某个内容
+func (r *engagementRepository) GetUserState(userID string, itemType models.EngagementItemType, itemID string) (bool, bool, error) {
+	var likes, bookmarks int64
+	if err := r.db.Model(&models.ItemLike{}).
+		Where("user_id = ? AND item_type = ? AND item_id = ?", userID, itemType, itemID).
+		Count(&likes).Error; err != nil {
+		return false, false, err
+	}
+	if err := r.db.Model(&models.ItemBookmark{}).
+		Where("user_id = ? AND item_type = ? AND item_id = ?", userID, itemType, itemID).
+		Count(&bookmarks).Error; err != nil {
+		return false, false, err
+	}
+	return likes > 0, bookmarks > 0, nil
+}
+
+// GetBookmarks 分页获取用户的收藏，按收藏时间降序排序，同时返回总数
+func (r *engagementRepository) GetBookmarks(userID string, itemType models.EngagementItemType, page, limit int) ([]models.ItemBookmark, int64, error) {
+	query := r.db.Model(&models.ItemBookmark{}).Where("user_id = ?", userID)
+	if itemType != "" {
+		query = query.Where("item_type = ?", itemType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var bookmarks []models.ItemBookmark
+	offset := (page - 1) * limit
+	result := query.Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&bookmarks)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	return bookmarks, total, nil
+}
+
+// RecordView 记录一次浏览。观看者上次计数的时间早于window时更新时间并增加浏览数，
+// 依靠主键冲突时的条件更新保证并发请求只计数一次
+func (r *engagementRepository) RecordView(itemType models.EngagementItemType, itemID, viewerKey string, window time.Duration) (bool, error) {
+	counted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		view := &models.ItemView{
+			ItemType:  itemType,
+			ItemID:    itemID,
+			ViewerKey: viewerKey,
+			ViewedAt:  now,
+		}
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "item_type"}, {Name: "item_id"}, {Name: "viewer_key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"viewed_at"}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				gorm.Expr("item_views.viewed_at <= ?", now.Add(-window)),
+			}},
+		}).Create(view)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		counted = true
+		return incrementStat(tx, itemType, itemID, "view_count", 1)
+	})
+	if err != nil {
+		return false, err
+	}
+	return counted, nil
+}
+
+// GetStats 批量获取内容的互动统计
+func (r *engagementRepository) GetStats(itemType models.EngagementItemType, itemIDs []string) (map[string]*models.EngagementStats, error) {
+	stats := make(map[string]*models.EngagementStats, len(itemIDs))
+	if len(itemIDs) == 0 {
+		return stats, nil
+	}
+
+	var list []models.EngagementStats
+	result := r.db.Where("item_type = ? AND item_id IN ?", itemType, itemIDs).Find(&list)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	for i := range list {
+		stats[list[i].ItemID] = &list[i]
+	}
+	return stats, nil
+}
+
+// DeleteByItem 删除内容的所有点赞、收藏、浏览记录和统计
+func (r *engagementRepository) DeleteByItem(itemType models.EngagementItemType, itemID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.ItemLike{}, &models.ItemBookmark{}, &models.ItemView{}, &models.EngagementStats{}} {
+			if err := tx.Where("item_type = ? AND item_id = ?", itemType, itemID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateUserID 将旧用户的点赞和收藏迁移给新用户。两个用户都点赞或收藏过的内容只保留新用户的记录，
+// 并相应减少统计数量
+func (r *engagementRepository) UpdateUserID(oldUserID string, newUserID string) (int64, error) {
+	var migrated int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, target := range []struct{ table, column string }{
+			{"item_likes", "like_count"},
+			{"item_bookmarks", "bookmark_count"},
+		} {
+			table, column := target.table, target.column
+
+			// 重复记录会被删除，先减少对应的统计数量
+			if err := tx.Exec(`UPDATE engagement_stats SET `+column+` = GREATEST(`+column+` - 1, 0)
+				FROM `+table+` AS o, `+table+` AS n
+				WHERE o.user_id = ? AND n.user_id = ? AND o.item_type = n.item_type AND o.item_id = n.item_id
+					AND engagement_stats.item_type = o.item_type AND engagement_stats.item_id = o.item_id`,
+				oldUserID, newUserID).Error; err != nil {
+				return err
+			}
+
+			// 删除重复记录
+			if err := tx.Exec(`DELETE FROM `+table+` AS o USING `+table+` AS n
+				WHERE o.user_id = ? AND n.user_id = ? AND o.item_type = n.item_type AND o.item_id = n.item_id`,
+				oldUserID, newUserID).Error; err != nil {
+				return err
+			}
+
+			result := tx.Exec(`UPDATE `+table+` SET user_id = ? WHERE user_id = ?`, newUserID, oldUserID)
+			if result.Error != nil {
+				return result.Error
+			}
+			migrated += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return migrated, nil
+}
+
+// orderByPublicSort 按公开列表的排序方式排序，table为内容所在的表，timeColumn为按时间排序使用的列。
+// 热门和趋势排序会关联互动统计表
+func orderByPublicSort(query *gorm.DB, table string, itemType models.EngagementItemType, sort models.PublicListSort, timeColumn string) *gorm.DB {
+	recent := table + "." + timeColumn + " DESC"
+	switch sort {
+	case models.PublicListSortPopular:
+		return joinEngagementStats(query, table, itemType).
+			Order(popularityScore + " DESC, " + recent)
+	case models.PublicListSortTrending:
+		return joinEngagementStats(query, table, itemType).
+			Order(popularityScore + " / POWER(EXTRACT(EPOCH FROM NOW() - " + table + ".created_at) / 3600 + 2, " + trendingGravity + ") DESC, " + recent)
+	}
+	return query.Order(recent)
+}
+
+// joinEngagementStats 关联内容的互动统计，只选择内容表的列
+func joinEngagementStats(query *gorm.DB, table string, itemType models.EngagementItemType) *gorm.DB {
+	return query.Select(table+".*").
+		Joins("LEFT JOIN engagement_stats ON engagement_stats.item_type = ? AND engagement_stats.item_id = "+table+".id", itemType)
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"betalyr-learning-server/internal/database"
+	"betalyr-learning-server/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LearningProgressRepository 定义学习进度仓库接口
+type LearningProgressRepository interface {
+	Find(userID string, itemType models.ProgressItemType, itemID string) (*models.LearningProgress, error)
+	Upsert(progress *models.LearningProgress) error
+	GetByUser(userID string, itemType models.ProgressItemType) ([]models.LearningProgress, error)
+	GetInProgress(userID string, limit int) ([]models.LearningProgress, error)
+	Delete(userID string, itemType models.ProgressItemType, itemID string) error
+	UpdateUserID(oldUserID string, newUserID string) (int64, error)
+}
+
+// learningProgressRepository 实现学习进度仓库接口
+type learningProgressRepository struct {
+	db *gorm.DB
+}
+
+// NewLearningProgressRepository 创建新的学习进度仓库实例
+func NewLearningProgressRepository() LearningProgressRepository {
+	return &learningProgressRepository{
+		db: database.DB,
+	}
+}
+
+// Find 查找用户对某个内容的学习进度
+func (r *learningProgressRepository) Find(userID string, itemType models.ProgressItemType, itemID string) (*models.LearningProgress, error) {
+	var progress models.LearningProgress
+	result := r.db.Where("user_id = ? AND item_type = ? AND item_id = ?", userID, itemType, itemID).First(&progress)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil // 未找到记录返回nil而不是错误
+		}
+		return nil, result.Error
+	}
+	return &progress, nil
+}
+
+// Upsert 保存学习进度，同一用户同一内容已有记录时覆盖
+func (r *learningProgressRepository) Upsert(progress *models.LearningProgress) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "item_type"}, {Name: "item_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"position", "completed", "completed_at", "last_accessed_at", "updated_at"}),
+	}).Create(progress).Error
+}
+
+// GetByUser 获取用户的学习进度，itemType为空时返回所有类型，按最近访问时间降序排序
+func (r *learningProgressRepository) GetByUser(userID string, itemType models.ProgressItemType) ([]models.LearningProgress, error) {
+	var progress []models.LearningProgress
+	query := r.db.Where("user_id = ?", userID)
+	if itemType != "" {
+		query = query.Where("item_type = ?", itemType)
+	}
+	result := query.Order("last_accessed_at DESC").Find(&progress)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return progress, nil
+}
+
+// GetInProgress 获取用户最近访问且未完成的学习进度
+func (r *learningProgressRepository) GetInProgress(userID string, limit int) ([]models.LearningProgress, error) {
+	var progress []models.LearningProgress
+	result := r.db.Where("user_id = ? AND completed = ?", userID, false).
+		Order("last_accessed_at DESC").
+		Limit(limit).
+		Find(&progress)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return progress, nil
+}
+
+// Delete 删除用户对某个内容的学习进度
+func (r *learningProgressRepository) Delete(userID string, itemType models.ProgressItemType, itemID string) error {
+	return r.db.Where("user_id = ? AND item_type = ? AND item_id = ?", userID, itemType, itemID).
+		Delete(&models.LearningProgress{}).Error
+}
+
+// UpdateUserID 将旧用户的学习进度迁移给新用户。两个用户都有进度的内容会合并：
+// 任一方完成即视为完成，位置取最近访问的一方
+func (r *learningProgressRepository) UpdateUserID(oldUserID string, newUserID string) (int64, error) {
+	var migrated int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 合并两个用户都有记录的内容
+		if err := tx.Exec(`UPDATE learning_progresses AS n SET
+				position = CASE WHEN o.last_accessed_at > n.last_accessed_at THEN o.position ELSE n.position END,
+				last_accessed_at = GREATEST(n.last_accessed_at, o.last_accessed_at),
+				completed = n.completed OR o.completed,
+				completed_at = COALESCE(n.completed_at, o.completed_at),
+				updated_at = NOW()
+			FROM learning_progresses AS o
+			WHERE o.user_id = ? AND n.user_id = ? AND o.item_type = n.item_type AND o.item_id = n.item_id`,
+			oldUserID, newUserID).Error; err != nil {
+			return err
+		}
+
+		// 迁移新用户没有记录的内容
+		result := tx.Exec(`UPDATE learning_progresses AS o SET user_id = ?
+			WHERE o.user_id = ? AND NOT EXISTS (
+				SELECT 1 FROM learning_progresses AS n
+				WHERE n.user_id = ? AND n.item_type = o.item_type AND n.item_id = o.item_id
+			)`, newUserID, oldUserID, newUserID)
+		if result.Error != nil {
+			return result.Error
+		}
+		migrated = result.RowsAffected
+
+		// 删除已合并的重复记录
+		return tx.Where("user_id = ?", oldUserID).Delete(&models.LearningProgress{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return migrated, nil
+}
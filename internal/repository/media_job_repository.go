@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"betalyr-learning-server/internal/database"
+	"betalyr-learning-server/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// claimMediaJobSQL 领取一个到期的等待中任务并标记为执行中，SKIP LOCKED保证多个工作协程不会领取同一任务
+const claimMediaJobSQL = `UPDATE media_jobs
+	SET status = ?, attempts = attempts + 1, locked_at = ?, updated_at = ?
+	WHERE id = (
+		SELECT id FROM media_jobs
+		WHERE status = ? AND run_at <= ?
+		ORDER BY run_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING *`
+
+// ErrMediaJobNotRunning 任务已不处于本次领取的执行中状态，通常是执行超时后被重新领取或已结束
+var ErrMediaJobNotRunning = errors.New("media job is no longer running this attempt")
+
+// MediaJobRepository 定义媒体处理任务仓库接口。
+// Heartbeat、Complete、Retry和Fail只作用于仍处于执行中、且尝试次数等于attempt的任务，
+// 避免超时后被重新领取的任务被上一次执行的结果覆盖
+type MediaJobRepository interface {
+	// 创建等待执行的任务
+	Enqueue(job *models.MediaJob) error
+	// 在同一事务中创建媒体记录和它的等待执行任务
+	EnqueueWithMedia(media *models.Media, job *models.MediaJob) error
+	// 领取一个到期的任务，没有任务时返回nil
+	Claim(now time.Time) (*models.MediaJob, error)
+	// 刷新执行中任务的锁定时间，表示任务仍在执行
+	Heartbeat(id string, attempt int, now time.Time) error
+	// 将任务标记为已完成
+	Complete(id string, attempt int) error
+	// 将任务放回队列，在runAt之后重试
+	Retry(id string, attempt int, runAt time.Time, lastError string) error
+	// 将任务标记为失败，不再重试
+	Fail(id string, attempt int, lastError string) error
+	// 获取在lockedBefore之前开始执行且仍未结束的任务，通常是进程崩溃后遗留的任务
+	GetStale(lockedBefore time.Time) ([]models.MediaJob, error)
+	// 获取媒体最近创建的任务
+	FindLatestByMedia(mediaID string) (*models.MediaJob, error)
+	// 删除媒体的所有任务
+	DeleteByMedia(mediaID string) error
+}
+
+// mediaJobRepository 实现媒体处理任务仓库接口
+type mediaJobRepository struct {
+	db *gorm.DB
+}
+
+// NewMediaJobRepository 创建新的媒体处理任务仓库实例
+func NewMediaJobRepository() MediaJobRepository {
+	return &mediaJobRepository{
+		db: database.DB,
+	}
+}
+
+// Enqueue 创建等待执行的任务
+func (r *mediaJobRepository) Enqueue(job *models.MediaJob) error {
+	prepareMediaJob(job)
+	return r.db.Create(job).Error
+}
+
+// EnqueueWithMedia 在同一事务中创建媒体记录和任务，避免只创建了媒体记录时媒体永远停留在处理中
+func (r *mediaJobRepository) EnqueueWithMedia(media *models.Media, job *models.MediaJob) error {
+	prepareMediaJob(job)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(media).Error; err != nil {
+			return err
+		}
+		return tx.Create(job).Error
+	})
+}
+
+// prepareMediaJob 将新任务设为等待执行，未指定执行时间时立即执行
+func prepareMediaJob(job *models.MediaJob) {
+	job.Status = models.MediaJobPending
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+}
+
+// Claim 领取一个到期的任务，同时增加尝试次数
+func (r *mediaJobRepository) Claim(now time.Time) (*models.MediaJob, error) {
+	var job models.MediaJob
+	result := r.db.Raw(claimMediaJobSQL,
+		models.MediaJobRunning, now, now, models.MediaJobPending, now).Scan(&job)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if job.ID == "" {
+		return nil, nil // 没有到期的任务
+	}
+	return &job, nil
+}
+
+// Heartbeat 刷新执行中任务的锁定时间
+func (r *mediaJobRepository) Heartbeat(id string, attempt int, now time.Time) error {
+	return r.db.Model(&models.MediaJob{}).
+		Where("id = ? AND status = ? AND attempts = ?", id, models.MediaJobRunning, attempt).
+		Update("locked_at", now).Error
+}
+
+// Complete 将任务标记为已完成
+func (r *mediaJobRepository) Complete(id string, attempt int) error {
+	return r.finish(id, attempt, map[string]interface{}{
+		"status":     models.MediaJobDone,
+		"last_error": nil,
+	})
+}
+
+// Retry 将任务放回队列
+func (r *mediaJobRepository) Retry(id string, attempt int, runAt time.Time, lastError string) error {
+	return r.finish(id, attempt, map[string]interface{}{
+		"status":     models.MediaJobPending,
+		"run_at":     runAt,
+		"last_error": lastError,
+	})
+}
+
+// Fail 将任务标记为失败
+func (r *mediaJobRepository) Fail(id string, attempt int, lastError string) error {
+	return r.finish(id, attempt, map[string]interface{}{
+		"status":     models.MediaJobFailed,
+		"last_error": lastError,
+	})
+}
+
+// finish 结束一次执行，释放任务锁并更新任务状态。
+// 任务已不是本次领取的执行时返回ErrMediaJobNotRunning
+func (r *mediaJobRepository) finish(id string, attempt int, updates map[string]interface{}) error {
+	updates["locked_at"] = nil
+	result := r.db.Model(&models.MediaJob{}).
+		Where("id = ? AND status = ? AND attempts = ?", id, models.MediaJobRunning, attempt).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMediaJobNotRunning
+	}
+	return nil
+}
+
+// GetStale 获取执行超时的任务
+func (r *mediaJobRepository) GetStale(lockedBefore time.Time) ([]models.MediaJob, error) {
+	var jobs []models.MediaJob
+	result := r.db.
+		Where("status = ? AND locked_at < ?", models.MediaJobRunning, lockedBefore).
+		Find(&jobs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return jobs, nil
+}
+
+// FindLatestByMedia 获取媒体最近创建的任务
+func (r *mediaJobRepository) FindLatestByMedia(mediaID string) (*models.MediaJob, error) {
+	var job models.MediaJob
+	result := r.db.Where("media_id = ?", mediaID).Order("created_at DESC").First(&job)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil // 未找到记录返回nil而不是错误
+		}
+		return nil, result.Error
+	}
+	return &job, nil
+}
+
+// DeleteByMedia 删除媒体的所有任务
+func (r *mediaJobRepository) DeleteByMedia(mediaID string) error {
+	return r.db.Where("media_id = ?", mediaID).Delete(&models.MediaJob{}).Error
+}
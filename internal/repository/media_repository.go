@@ -5,39 +5,87 @@ import (
 	"betalyr-learning-server/internal/models"
 	"betalyr-learning-server/internal/pkg/logger"
 	"betalyr-learning-server/internal/storage"
+	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// ErrStorageUnavailable 未配置R2对象存储
+var ErrStorageUnavailable = errors.New("media storage is not configured")
+
 // MediaRepository 定义媒体存储库接口
 type MediaRepository interface {
 	// 文件存储相关操作
 	// 上传媒体文件，返回文件URL
 	UploadMedia(file io.Reader, fileSize int64, fileName, contentType string) (string, error)
+	// 上传文件到指定的文件键，返回文件URL
+	UploadMediaObject(fileKey string, file io.Reader, fileSize int64, contentType string) (string, error)
 	// 删除媒体文件
 	DeleteMedia(fileKey string) error
+	// 删除指定前缀下的所有文件
+	DeleteMediaPrefix(prefix string) error
+	// 创建分片上传，返回文件键和分片上传ID
+	CreateMultipartUpload(fileName, contentType string) (string, string, error)
+	// 上传一个分片，返回ETag
+	UploadPart(fileKey, uploadID string, partNumber int, data []byte) (string, error)
+	// 合并分片完成上传，返回文件URL
+	CompleteMultipartUpload(fileKey, uploadID string, parts []models.UploadPart) (string, error)
+	// 取消分片上传并删除已上传的分片
+	AbortMultipartUpload(fileKey, uploadID string) error
+	// 获取分片上传中已上传的分片，按分片编号升序排序
+	ListParts(fileKey, uploadID string) ([]models.UploadPart, error)
+	// 获取文件大小，文件不存在时第二个返回值为false
+	HeadMedia(fileKey string) (int64, bool, error)
+	// 生成新的文件键，用于客户端直传
+	NewFileKey(fileName, contentType string) string
+	// 返回文件键对应的公开访问URL
+	FileURL(fileKey string) string
+	// 生成直接上传整个文件的预签名PUT地址和请求必须携带的请求头，内容类型和大小包含在签名中
+	PresignPutObject(fileKey, contentType string, size int64, expires time.Duration) (string, map[string]string, error)
+	// 生成上传一个分片的预签名PUT地址和请求必须携带的请求头，分片大小包含在签名中
+	PresignUploadPart(fileKey, uploadID string, partNumber int, size int64, expires time.Duration) (string, map[string]string, error)
+	// 下载媒体文件写入w
+	DownloadMedia(fileKey string, w io.Writer) error
 
 	// 数据库相关操作
 	// 创建媒体记录
 	CreateMedia(media *models.Media) error
+	// 更新媒体记录，记录不存在（如已被删除）时返回false
+	UpdateMedia(media *models.Media) (bool, error)
 	// 根据ID获取媒体信息
 	GetMediaByID(id string) (*models.Media, error)
+	// 根据ID列表批量获取媒体信息，不存在的ID会被忽略
+	GetMediaByIDs(ids []string) ([]models.Media, error)
 	// 完全删除媒体（包括文件和数据库记录）
 	DeleteMediaCompletely(id string) error
 	// 获取视频列表
-	GetVideos(page, limit int) ([]models.Media, error)
+	GetVideos(page, limit int, sort models.PublicListSort) ([]models.Media, error)
 	// 获取音频列表
-	GetAudios(page, limit int) ([]models.Media, error)
+	GetAudios(page, limit int, sort models.PublicListSort) ([]models.Media, error)
+	// 获取订阅源中的视频，uploaderID不为空时只获取该用户上传的视频
+	GetFeedVideos(uploaderID string, limit int) ([]models.Media, error)
+	// 获取站点地图中的视频和音频（只包含ID、类型和更新时间）
+	GetSitemapMedia(limit int) ([]models.Media, error)
+	// 获取ID大于afterID且还没有元数据的已就绪视频和音频，按ID升序排序
+	GetMediaWithoutMeta(afterID string, limit int) ([]models.Media, error)
+	// 只更新媒体的元数据，不修改更新时间
+	UpdateMediaMeta(id string, meta *models.MediaMeta) error
 }
 
 // mediaRepository 实现媒体存储库接口
@@ -73,22 +121,46 @@ func generateInternalFileKey(fileType, originalFileName string) string {
 	return fmt.Sprintf("%s/%s", fileType, uniqueName)
 }
 
+// 根据MIME类型确定文件类型目录
+func mediaFileType(contentType string) string {
+	if strings.Contains(contentType, "audio") {
+		return "audio"
+	} else if strings.Contains(contentType, "video") {
+		return "video"
+	} else if strings.Contains(contentType, "image") {
+		return "image"
+	}
+	return "other"
+}
+
 // UploadMedia 上传媒体文件到R2
 func (r *mediaRepository) UploadMedia(file io.Reader, fileSize int64, fileName, contentType string) (string, error) {
+	if r.client == nil {
+		return "", ErrStorageUnavailable
+	}
 	ctx := context.Background()
 
-	// 根据MIME类型确定文件类型目录
-	fileType := "other"
-	if strings.Contains(contentType, "audio") {
-		fileType = "audio"
-	} else if strings.Contains(contentType, "video") {
-		fileType = "video"
-	} else if strings.Contains(contentType, "image") {
-		fileType = "image"
+	fileKey := generateInternalFileKey(mediaFileType(contentType), fileName)
+
+	fileURL, err := r.putObject(ctx, fileKey, file, fileSize, contentType)
+	if err != nil {
+		return "", err
 	}
+	logger.Info("Media uploaded successfully", zap.String("URL", fileURL), zap.String("contentType", contentType))
 
-	fileKey := generateInternalFileKey(fileType, fileName)
+	return fileURL, nil
+}
 
+// UploadMediaObject 上传文件到R2的指定文件键，已存在的文件会被覆盖
+func (r *mediaRepository) UploadMediaObject(fileKey string, file io.Reader, fileSize int64, contentType string) (string, error) {
+	if r.client == nil {
+		return "", ErrStorageUnavailable
+	}
+	return r.putObject(context.Background(), fileKey, file, fileSize, contentType)
+}
+
+// putObject 上传文件到R2并返回公共访问URL
+func (r *mediaRepository) putObject(ctx context.Context, fileKey string, file io.Reader, fileSize int64, contentType string) (string, error) {
 	// 创建PutObject请求
 	_, err := r.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:        aws.String(r.bucket),
@@ -105,10 +177,7 @@ func (r *mediaRepository) UploadMedia(file io.Reader, fileSize int64, fileName,
 	}
 
 	// 构建公共访问URL
-	fileURL := fmt.Sprintf("%s/%s", strings.TrimSuffix(r.publicURL, "/"), fileKey)
-	logger.Info("Media uploaded successfully", zap.String("URL", fileURL), zap.String("contentType", contentType))
-
-	return fileURL, nil
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(r.publicURL, "/"), fileKey), nil
 }
 
 // DeleteMedia 从R2删除媒体文件
@@ -132,6 +201,290 @@ func (r *mediaRepository) DeleteMedia(fileKey string) error {
 	return nil
 }
 
+// DeleteMediaPrefix 从R2删除指定前缀下的所有文件，如视频的HLS分片
+func (r *mediaRepository) DeleteMediaPrefix(prefix string) error {
+	if prefix == "" || prefix == "/" {
+		return fmt.Errorf("invalid prefix")
+	}
+	if r.client == nil {
+		return ErrStorageUnavailable
+	}
+
+	ctx := context.Background()
+	paginator := s3.NewListObjectsV2Paginator(r.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(r.bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	deleted := 0
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			logger.Error("Failed to list media objects", zap.Error(err), zap.String("prefix", prefix))
+			return err
+		}
+		if len(page.Contents) == 0 {
+			continue
+		}
+
+		// 每页最多1000个对象，与DeleteObjects的单次上限一致
+		objects := make([]types.ObjectIdentifier, 0, len(page.Contents))
+		for _, object := range page.Contents {
+			objects = append(objects, types.ObjectIdentifier{Key: object.Key})
+		}
+		_, err = r.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(r.bucket),
+			Delete: &types.Delete{Objects: objects, Quiet: true},
+		})
+		if err != nil {
+			logger.Error("Failed to delete media objects", zap.Error(err), zap.String("prefix", prefix))
+			return err
+		}
+		deleted += len(objects)
+	}
+
+	logger.Info("Media objects deleted successfully", zap.String("prefix", prefix), zap.Int("count", deleted))
+	return nil
+}
+
+// CreateMultipartUpload 在R2上创建分片上传
+func (r *mediaRepository) CreateMultipartUpload(fileName, contentType string) (string, string, error) {
+	if r.client == nil {
+		return "", "", ErrStorageUnavailable
+	}
+
+	fileKey := generateInternalFileKey(mediaFileType(contentType), fileName)
+	output, err := r.client.CreateMultipartUpload(context.Background(), &s3.CreateMultipartUploadInput{
+		Bucket:       aws.String(r.bucket),
+		Key:          aws.String(fileKey),
+		ContentType:  aws.String(contentType),
+		CacheControl: aws.String("public, max-age=31536000"), // 缓存1年
+	})
+	if err != nil {
+		logger.Error("Failed to create multipart upload", zap.Error(err), zap.String("fileKey", fileKey))
+		return "", "", err
+	}
+
+	return fileKey, aws.ToString(output.UploadId), nil
+}
+
+// UploadPart 上传一个分片，附带Content-MD5由存储校验传输过程中内容未被损坏
+func (r *mediaRepository) UploadPart(fileKey, uploadID string, partNumber int, data []byte) (string, error) {
+	if r.client == nil {
+		return "", ErrStorageUnavailable
+	}
+
+	sum := md5.Sum(data)
+	output, err := r.client.UploadPart(context.Background(), &s3.UploadPartInput{
+		Bucket:        aws.String(r.bucket),
+		Key:           aws.String(fileKey),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    int32(partNumber),
+		Body:          bytes.NewReader(data),
+		ContentLength: int64(len(data)),
+		ContentMD5:    aws.String(base64.StdEncoding.EncodeToString(sum[:])),
+	})
+	if err != nil {
+		logger.Error("Failed to upload part",
+			zap.Error(err),
+			zap.String("fileKey", fileKey),
+			zap.Int("partNumber", partNumber))
+		return "", err
+	}
+
+	return aws.ToString(output.ETag), nil
+}
+
+// CompleteMultipartUpload 按分片编号合并分片
+func (r *mediaRepository) CompleteMultipartUpload(fileKey, uploadID string, parts []models.UploadPart) (string, error) {
+	if r.client == nil {
+		return "", ErrStorageUnavailable
+	}
+
+	completed := make([]types.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completed = append(completed, types.CompletedPart{
+			ETag:       aws.String(part.ETag),
+			PartNumber: int32(part.PartNumber),
+		})
+	}
+
+	_, err := r.client.CompleteMultipartUpload(context.Background(), &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(r.bucket),
+		Key:             aws.String(fileKey),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		logger.Error("Failed to complete multipart upload", zap.Error(err), zap.String("fileKey", fileKey))
+		return "", err
+	}
+
+	fileURL := r.FileURL(fileKey)
+	logger.Info("Multipart upload completed", zap.String("URL", fileURL), zap.Int("parts", len(parts)))
+	return fileURL, nil
+}
+
+// AbortMultipartUpload 取消分片上传，分片上传已不存在时视为成功
+func (r *mediaRepository) AbortMultipartUpload(fileKey, uploadID string) error {
+	if r.client == nil {
+		return ErrStorageUnavailable
+	}
+
+	_, err := r.client.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(r.bucket),
+		Key:      aws.String(fileKey),
+		UploadId: aws.String(uploadID),
+	})
+	var notFound *types.NoSuchUpload
+	if err != nil && !errors.As(err, &notFound) {
+		logger.Error("Failed to abort multipart upload", zap.Error(err), zap.String("fileKey", fileKey))
+		return err
+	}
+	return nil
+}
+
+// ListParts 获取分片上传中已上传的分片，用于客户端直传分片后合并
+func (r *mediaRepository) ListParts(fileKey, uploadID string) ([]models.UploadPart, error) {
+	if r.client == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	var parts []models.UploadPart
+	paginator := s3.NewListPartsPaginator(r.client, &s3.ListPartsInput{
+		Bucket:   aws.String(r.bucket),
+		Key:      aws.String(fileKey),
+		UploadId: aws.String(uploadID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			logger.Error("Failed to list upload parts", zap.Error(err), zap.String("fileKey", fileKey))
+			return nil, err
+		}
+		for _, part := range page.Parts {
+			parts = append(parts, models.UploadPart{
+				PartNumber: int(part.PartNumber),
+				Size:       part.Size,
+				ETag:       aws.ToString(part.ETag),
+				CreatedAt:  aws.ToTime(part.LastModified),
+			})
+		}
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+	return parts, nil
+}
+
+// HeadMedia 获取文件大小
+func (r *mediaRepository) HeadMedia(fileKey string) (int64, bool, error) {
+	if r.client == nil {
+		return 0, false, ErrStorageUnavailable
+	}
+
+	output, err := r.client.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(fileKey),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return 0, false, nil // 文件不存在返回false而不是错误
+		}
+		logger.Error("Failed to head media", zap.Error(err), zap.String("fileKey", fileKey))
+		return 0, false, err
+	}
+	return output.ContentLength, true, nil
+}
+
+// NewFileKey 按内容类型生成新的文件键
+func (r *mediaRepository) NewFileKey(fileName, contentType string) string {
+	return generateInternalFileKey(mediaFileType(contentType), fileName)
+}
+
+// FileURL 构建文件的公共访问URL
+func (r *mediaRepository) FileURL(fileKey string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(r.publicURL, "/"), fileKey)
+}
+
+// PresignPutObject 生成直接上传到R2的预签名地址，内容类型和大小包含在签名中
+func (r *mediaRepository) PresignPutObject(fileKey, contentType string, size int64, expires time.Duration) (string, map[string]string, error) {
+	if r.client == nil {
+		return "", nil, ErrStorageUnavailable
+	}
+
+	request, err := s3.NewPresignClient(r.client).PresignPutObject(context.Background(), &s3.PutObjectInput{
+		Bucket:        aws.String(r.bucket),
+		Key:           aws.String(fileKey),
+		ContentLength: size,
+		ContentType:   aws.String(contentType),
+		CacheControl:  aws.String("public, max-age=31536000"), // 缓存1年
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		logger.Error("Failed to presign put object", zap.Error(err), zap.String("fileKey", fileKey))
+		return "", nil, err
+	}
+	return request.URL, presignedHeaders(request.SignedHeader), nil
+}
+
+// PresignUploadPart 生成直接上传分片到R2的预签名地址，分片大小包含在签名中
+func (r *mediaRepository) PresignUploadPart(fileKey, uploadID string, partNumber int, size int64, expires time.Duration) (string, map[string]string, error) {
+	if r.client == nil {
+		return "", nil, ErrStorageUnavailable
+	}
+
+	request, err := s3.NewPresignClient(r.client).PresignUploadPart(context.Background(), &s3.UploadPartInput{
+		Bucket:        aws.String(r.bucket),
+		Key:           aws.String(fileKey),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    int32(partNumber),
+		ContentLength: size,
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		logger.Error("Failed to presign upload part",
+			zap.Error(err),
+			zap.String("fileKey", fileKey),
+			zap.Int("partNumber", partNumber))
+		return "", nil, err
+	}
+	return request.URL, presignedHeaders(request.SignedHeader), nil
+}
+
+// presignedHeaders 返回客户端上传时需要设置的签名请求头。
+// Host和Content-Length由HTTP客户端根据地址和请求体自动设置，浏览器也不允许手动设置
+func presignedHeaders(signed http.Header) map[string]string {
+	headers := make(map[string]string, len(signed))
+	for name, values := range signed {
+		if len(values) == 0 || strings.EqualFold(name, "Host") || strings.EqualFold(name, "Content-Length") {
+			continue
+		}
+		headers[http.CanonicalHeaderKey(name)] = values[0]
+	}
+	return headers
+}
+
+// DownloadMedia 从R2下载媒体文件
+func (r *mediaRepository) DownloadMedia(fileKey string, w io.Writer) error {
+	if r.client == nil {
+		return ErrStorageUnavailable
+	}
+
+	output, err := r.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(fileKey),
+	})
+	if err != nil {
+		logger.Error("Failed to download media from R2", zap.Error(err), zap.String("fileKey", fileKey))
+		return err
+	}
+	defer output.Body.Close()
+
+	_, err = io.Copy(w, output.Body)
+	return err
+}
+
 // 数据库相关操作方法
 
 // CreateMedia 创建媒体记录
@@ -139,6 +492,15 @@ func (r *mediaRepository) CreateMedia(media *models.Media) error {
 	return r.db.Create(media).Error
 }
 
+// UpdateMedia 更新媒体记录，记录已被删除时不会重新创建，返回是否更新了记录
+func (r *mediaRepository) UpdateMedia(media *models.Media) (bool, error) {
+	result := r.db.Model(media).Select("*").Omit("created_at", "deleted_at").Updates(media)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // GetMediaByID 根据ID获取媒体信息
 func (r *mediaRepository) GetMediaByID(id string) (*models.Media, error) {
 	var media models.Media
@@ -152,14 +514,27 @@ func (r *mediaRepository) GetMediaByID(id string) (*models.Media, error) {
 	return &media, nil
 }
 
+// GetMediaByIDs 根据ID列表批量获取媒体信息，不存在的ID会被忽略
+func (r *mediaRepository) GetMediaByIDs(ids []string) ([]models.Media, error) {
+	var media []models.Media
+	if len(ids) == 0 {
+		return media, nil
+	}
+	result := r.db.Where("id IN ?", ids).Find(&media)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return media, nil
+}
+
 // GetVideos 获取视频列表
-func (r *mediaRepository) GetVideos(page, limit int) ([]models.Media, error) {
+func (r *mediaRepository) GetVideos(page, limit int, sort models.PublicListSort) ([]models.Media, error) {
 	var videos []models.Media
 	offset := (page - 1) * limit
 
-	// 查询视频，按创建时间降序排序
-	result := r.db.Where("media_type = ? AND status = ?", models.MediaTypeVideo, models.MediaStatusReady).
-		Order("created_at DESC").
+	// 查询视频，默认按创建时间降序排序
+	query := r.db.Model(&models.Media{}).Where("media_type = ? AND status = ?", models.MediaTypeVideo, models.MediaStatusReady)
+	result := orderByPublicSort(query, "media", models.EngagementItemMedia, sort, "created_at").
 		Offset(offset).
 		Limit(limit).
 		Find(&videos)
@@ -172,13 +547,13 @@ func (r *mediaRepository) GetVideos(page, limit int) ([]models.Media, error) {
 }
 
 // GetAudios 获取音频列表
-func (r *mediaRepository) GetAudios(page, limit int) ([]models.Media, error) {
+func (r *mediaRepository) GetAudios(page, limit int, sort models.PublicListSort) ([]models.Media, error) {
 	var audios []models.Media
 	offset := (page - 1) * limit
 
-	// 查询音频，按创建时间降序排序
-	result := r.db.Where("media_type = ? AND status = ?", models.MediaTypeAudio, models.MediaStatusReady).
-		Order("created_at DESC").
+	// 查询音频，默认按创建时间降序排序
+	query := r.db.Model(&models.Media{}).Where("media_type = ? AND status = ?", models.MediaTypeAudio, models.MediaStatusReady)
+	result := orderByPublicSort(query, "media", models.EngagementItemMedia, sort, "created_at").
 		Offset(offset).
 		Limit(limit).
 		Find(&audios)
@@ -231,8 +606,66 @@ func (r *mediaRepository) DeleteMediaCompletely(id string) error {
 				zap.String("fileKey", media.FileKey))
 			// 不返回错误，因为数据库记录已经删除成功
 		}
+
+		// 删除视频处理生成的HLS文件、预览图和缩略图
+		if media.PlaylistURL != nil || media.Preview != nil {
+			if err := r.DeleteMediaPrefix(media.ProcessedKeyPrefix()); err != nil {
+				logger.Error("Failed to delete processed media files, but database record was deleted",
+					zap.Error(err),
+					zap.String("id", id),
+					zap.String("prefix", media.ProcessedKeyPrefix()))
+			}
+		}
 	}
 
 	logger.Info("Media deleted completely", zap.String("id", id), zap.String("fileKey", media.FileKey))
 	return nil
 }
+
+// GetFeedVideos 获取订阅源中的已就绪视频，按创建时间降序排序
+func (r *mediaRepository) GetFeedVideos(uploaderID string, limit int) ([]models.Media, error) {
+	var videos []models.Media
+	query := r.db.Where("media_type = ? AND status = ?", models.MediaTypeVideo, models.MediaStatusReady)
+	if uploaderID != "" {
+		query = query.Where("uploader_id = ?", uploaderID)
+	}
+	result := query.Order("created_at DESC").Limit(limit).Find(&videos)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return videos, nil
+}
+
+// GetSitemapMedia 获取站点地图中的已就绪视频和音频，按更新时间降序排序
+func (r *mediaRepository) GetSitemapMedia(limit int) ([]models.Media, error) {
+	var media []models.Media
+	result := r.db.Select("id", "media_type", "updated_at").
+		Where("media_type IN ? AND status = ?", []models.MediaType{models.MediaTypeVideo, models.MediaTypeAudio}, models.MediaStatusReady).
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&media)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return media, nil
+}
+
+// GetMediaWithoutMeta 获取还没有元数据的已就绪视频和音频，用于按ID分批补充元数据
+func (r *mediaRepository) GetMediaWithoutMeta(afterID string, limit int) ([]models.Media, error) {
+	var media []models.Media
+	result := r.db.
+		Where("media_type IN ? AND status = ? AND meta IS NULL AND id > ?",
+			[]models.MediaType{models.MediaTypeVideo, models.MediaTypeAudio}, models.MediaStatusReady, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&media)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return media, nil
+}
+
+// UpdateMediaMeta 只更新媒体的元数据
+func (r *mediaRepository) UpdateMediaMeta(id string, meta *models.MediaMeta) error {
+	return r.db.Model(&models.Media{}).Where("id = ?", id).UpdateColumn("meta", meta).Error
+}
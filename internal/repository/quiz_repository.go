@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"betalyr-learning-server/internal/database"
+	"betalyr-learning-server/internal/models"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrAttemptLimitReached 用户的作答次数已达到测验的上限
+var ErrAttemptLimitReached = errors.New("quiz attempt limit reached")
+
+// QuizRepository 定义测验仓库接口
+type QuizRepository interface {
+	FindByID(id string) (*models.Quiz, error)
+	FindByTarget(targetType models.QuizTargetType, targetID string) (*models.Quiz, error)
+	Create(quiz *models.Quiz) error
+	Update(quiz *models.Quiz) error
+	Delete(id string) error
+	DeleteByTarget(targetType models.QuizTargetType, targetID string) error
+	CreateAttempt(attempt *models.QuizAttempt, maxAttempts int) (int64, error)
+	GetAttempts(quizID string) ([]models.QuizAttempt, error)
+	GetUserAttempts(quizID string, userID string) ([]models.QuizAttempt, error)
+	UpdateUserID(oldUserID string, newUserID string) (int64, error)
+}
+
+// quizRepository 实现测验仓库接口
+type quizRepository struct {
+	db *gorm.DB
+}
+
+// NewQuizRepository 创建新的测验仓库实例
+func NewQuizRepository() QuizRepository {
+	return &quizRepository{
+		db: database.DB,
+	}
+}
+
+// FindByID 根据ID查找测验
+func (r *quizRepository) FindByID(id string) (*models.Quiz, error) {
+	var quiz models.Quiz
+	result := r.db.Where("id = ?", id).First(&quiz)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil // 未找到记录返回nil而不是错误
+		}
+		return nil, result.Error
+	}
+	return &quiz, nil
+}
+
+// FindByTarget 查找附加在文档或视频上的测验
+func (r *quizRepository) FindByTarget(targetType models.QuizTargetType, targetID string) (*models.Quiz, error) {
+	var quiz models.Quiz
+	result := r.db.Where("target_type = ? AND target_id = ?", targetType, targetID).First(&quiz)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &quiz, nil
+}
+
+// Create 创建测验
+func (r *quizRepository) Create(quiz *models.Quiz) error {
+	return r.db.Create(quiz).Error
+}
+
+// Update 更新测验的标题、及格线和题目
+func (r *quizRepository) Update(quiz *models.Quiz) error {
+	return r.db.Save(quiz).Error
+}
+
+// Delete 删除测验及其所有作答记录
+func (r *quizRepository) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("quiz_id = ?", id).Delete(&models.QuizAttempt{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Quiz{}).Error
+	})
+}
+
+// DeleteByTarget 删除附加在文档或视频上的测验及其所有作答记录
+func (r *quizRepository) DeleteByTarget(targetType models.QuizTargetType, targetID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		quizIDs := tx.Model(&models.Quiz{}).Select("id").Where("target_type = ? AND target_id = ?", targetType, targetID)
+		if err := tx.Where("quiz_id IN (?)", quizIDs).Delete(&models.QuizAttempt{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("target_type = ? AND target_id = ?", targetType, targetID).Delete(&models.Quiz{}).Error
+	})
+}
+
+// CreateAttempt 保存一次作答记录，返回包含本次在内的作答次数。
+// maxAttempts大于0且用户的作答次数已达到上限时不保存，返回ErrAttemptLimitReached
+func (r *quizRepository) CreateAttempt(attempt *models.QuizAttempt, maxAttempts int) (int64, error) {
+	var count int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 同一用户对同一测验的提交串行执行，避免并发提交超过次数上限
+		lockKey := fmt.Sprintf("quiz_attempt:%s:%s", attempt.QuizID, attempt.UserID)
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", lockKey).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.QuizAttempt{}).
+			Where("quiz_id = ? AND user_id = ?", attempt.QuizID, attempt.UserID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if maxAttempts > 0 && count >= int64(maxAttempts) {
+			return ErrAttemptLimitReached
+		}
+
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetAttempts 获取测验的所有作答记录，按提交时间升序排序
+func (r *quizRepository) GetAttempts(quizID string) ([]models.QuizAttempt, error) {
+	var attempts []models.QuizAttempt
+	result := r.db.Where("quiz_id = ?", quizID).Order("created_at ASC").Find(&attempts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return attempts, nil
+}
+
+// GetUserAttempts 获取用户对测验的作答记录，按提交时间降序排序
+func (r *quizRepository) GetUserAttempts(quizID string, userID string) ([]models.QuizAttempt, error) {
+	var attempts []models.QuizAttempt
+	result := r.db.Where("quiz_id = ? AND user_id = ?", quizID, userID).
+		Order("created_at DESC").
+		Find(&attempts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return attempts, nil
+}
+
+// UpdateUserID 将旧用户创建的测验和作答记录迁移给新用户，返回迁移的作答记录数量
+func (r *quizRepository) UpdateUserID(oldUserID string, newUserID string) (int64, error) {
+	var migrated int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Quiz{}).
+			Where("owner_id = ?", oldUserID).
+			Update("owner_id", newUserID).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.QuizAttempt{}).
+			Where("user_id = ?", oldUserID).
+			Update("user_id", newUserID)
+		if result.Error != nil {
+			return result.Error
+		}
+		migrated = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return migrated, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"betalyr-learning-server/internal/database"
+	"betalyr-learning-server/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UploadSessionRepository 定义分片上传会话仓库接口
+type UploadSessionRepository interface {
+	// 创建上传会话
+	Create(session *models.UploadSession) error
+	// 根据ID获取上传会话
+	FindByID(id string) (*models.UploadSession, error)
+	// 获取会话已上传的分片，按分片编号升序排序
+	GetParts(sessionID string) ([]models.UploadPart, error)
+	// 保存分片，同一编号的分片已存在时覆盖，同时顺延会话的过期时间
+	SavePart(part *models.UploadPart, expiresAt time.Time) error
+	// 顺延上传中会话的过期时间
+	Extend(id string, expiresAt time.Time) error
+	// 删除会话的所有分片记录
+	DeleteParts(sessionID string) error
+	// 将上传中的会话标记为完成或取消，会话已不是上传中时返回false
+	Finish(id string, status models.UploadSessionStatus, mediaID *string) (bool, error)
+	// 将已标记为完成的会话恢复为上传中，用于合并分片失败后允许重试
+	Reopen(id string) error
+	// 获取在before之前过期的上传中会话
+	GetExpired(before time.Time, limit int) ([]models.UploadSession, error)
+}
+
+// uploadSessionRepository 实现分片上传会话仓库接口
+type uploadSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewUploadSessionRepository 创建新的分片上传会话仓库实例
+func NewUploadSessionRepository() UploadSessionRepository {
+	return &uploadSessionRepository{
+		db: database.DB,
+	}
+}
+
+// Create 创建上传会话
+func (r *uploadSessionRepository) Create(session *models.UploadSession) error {
+	return r.db.Create(session).Error
+}
+
+// FindByID 根据ID获取上传会话
+func (r *uploadSessionRepository) FindByID(id string) (*models.UploadSession, error) {
+	var session models.UploadSession
+	result := r.db.Where("id = ?", id).First(&session)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil // 未找到记录返回nil而不是错误
+		}
+		return nil, result.Error
+	}
+	return &session, nil
+}
+
+// GetParts 获取会话已上传的分片
+func (r *uploadSessionRepository) GetParts(sessionID string) ([]models.UploadPart, error) {
+	var parts []models.UploadPart
+	result := r.db.Where("session_id = ?", sessionID).Order("part_number").Find(&parts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return parts, nil
+}
+
+// SavePart 保存分片并顺延会话的过期时间
+func (r *uploadSessionRepository) SavePart(part *models.UploadPart, expiresAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "session_id"}, {Name: "part_number"}},
+			DoUpdates: clause.AssignmentColumns([]string{"size", "checksum", "e_tag", "created_at"}),
+		}).Create(part).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.UploadSession{}).
+			Where("id = ? AND status = ?", part.SessionID, models.UploadSessionActive).
+			Update("expires_at", expiresAt).Error
+	})
+}
+
+// Extend 顺延上传中会话的过期时间
+func (r *uploadSessionRepository) Extend(id string, expiresAt time.Time) error {
+	return r.db.Model(&models.UploadSession{}).
+		Where("id = ? AND status = ?", id, models.UploadSessionActive).
+		Update("expires_at", expiresAt).Error
+}
+
+// DeleteParts 删除会话的所有分片记录
+func (r *uploadSessionRepository) DeleteParts(sessionID string) error {
+	return r.db.Where("session_id = ?", sessionID).Delete(&models.UploadPart{}).Error
+}
+
+// Finish 将上传中的会话标记为完成或取消，通过状态条件保证并发请求中只有一个成功
+func (r *uploadSessionRepository) Finish(id string, status models.UploadSessionStatus, mediaID *string) (bool, error) {
+	result := r.db.Model(&models.UploadSession{}).
+		Where("id = ? AND status = ?", id, models.UploadSessionActive).
+		Updates(map[string]interface{}{
+			"status":   status,
+			"media_id": mediaID,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Reopen 将已完成的会话恢复为上传中
+func (r *uploadSessionRepository) Reopen(id string) error {
+	return r.db.Model(&models.UploadSession{}).
+		Where("id = ? AND status = ?", id, models.UploadSessionCompleted).
+		Updates(map[string]interface{}{
+			"status":   models.UploadSessionActive,
+			"media_id": nil,
+		}).Error
+}
+
+// GetExpired 获取过期的上传中会话
+func (r *uploadSessionRepository) GetExpired(before time.Time, limit int) ([]models.UploadSession, error) {
+	var sessions []models.UploadSession
+	result := r.db.
+		Where("status = ? AND expires_at < ?", models.UploadSessionActive, before).
+		Order("expires_at").
+		Limit(limit).
+		Find(&sessions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return sessions, nil
+}
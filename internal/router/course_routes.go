@@ -0,0 +1,50 @@
+package router
+
+import (
+	"betalyr-learning-server/internal/config"
+	"betalyr-learning-server/internal/handler"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/repository"
+	"betalyr-learning-server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerCourseRoutes 注册课程相关路由
+func registerCourseRoutes(r *gin.Engine, cfg *config.Config) {
+	// 初始化课程相关依赖
+	documentRepo := repository.NewDocumentRepository()
+	sharingService := service.NewSharingService(documentRepo, repository.NewDocumentCollaboratorRepository(), repository.NewDocumentShareLinkRepository())
+	courseService := service.NewCourseService(repository.NewCourseRepository(), documentRepo, repository.NewMediaRepository(), sharingService)
+	courseHandler := handler.NewCourseHandler(courseService)
+
+	api := r.Group("")
+	api.Use(middleware.AuthChecker())
+
+	courses := api.Group("/courses")
+	{
+		// 创建课程
+		courses.POST("", courseHandler.CreateCourse)
+
+		// 获取当前用户的课程列表
+		courses.GET("/user", courseHandler.GetUserCourses)
+
+		// 获取课程详情
+		courses.GET("/:id", courseHandler.GetCourse)
+
+		// 更新课程的标题、描述和封面
+		courses.PUT("/:id", courseHandler.UpdateCourse)
+
+		// 按顺序替换课程章节
+		courses.PUT("/:id/lessons", courseHandler.SetLessons)
+
+		// 公开课程
+		courses.PATCH("/:id/publish", courseHandler.PublishCourse)
+
+		// 取消公开课程
+		courses.PATCH("/:id/unpublish", courseHandler.UnpublishCourse)
+
+		// 删除课程
+		courses.DELETE("/:id", courseHandler.DeleteCourse)
+	}
+}
@@ -14,14 +14,33 @@ import (
 func registerDocumentRoutes(r *gin.Engine, cfg *config.Config) {
 	// 初始化文档相关依赖
 	documentRepo := repository.NewDocumentRepository()
-	documentService := service.NewDocumentService(documentRepo)
+	revisionRepo := repository.NewDocumentRevisionRepository()
+	collaboratorRepo := repository.NewDocumentCollaboratorRepository()
+	commentRepo := repository.NewDocumentCommentRepository()
+	engagementRepo := repository.NewEngagementRepository()
+	quizRepo := repository.NewQuizRepository()
+	sharingService := service.NewSharingService(documentRepo, collaboratorRepo, repository.NewDocumentShareLinkRepository())
+	revisionService := service.NewRevisionService(documentRepo, revisionRepo, sharingService)
+	documentService := service.NewDocumentService(documentRepo, revisionService, sharingService, engagementRepo)
 	cloudinaryService := service.NewCloudinaryService(cfg)
+	collaborationService := service.NewCollaborationService(documentRepo, revisionService, sharingService)
 
 	// 初始化处理器
-	documentHandler := handler.NewDocumentHandler(documentService, cloudinaryService)
+	documentHandler := handler.NewDocumentHandler(documentService, cloudinaryService, collaborationService)
+	revisionHandler := handler.NewRevisionHandler(revisionService)
+	exportHandler := handler.NewExportHandler(service.NewExportService(cfg, documentRepo, documentService))
+	importHandler := handler.NewImportHandler(service.NewImportService(documentRepo, revisionService, repository.NewMediaRepository()))
+	commentHandler := handler.NewCommentHandler(service.NewCommentService(commentRepo, documentRepo, sharingService))
+
+	// 回收站，启动定期永久删除过期文档的后台任务
+	trashService := service.NewTrashService(cfg, documentRepo, revisionService, sharingService, commentRepo, engagementRepo, quizRepo)
+	trashService.StartPurgeJob()
+	trashHandler := handler.NewTrashHandler(trashService)
+	collaborationHandler := handler.NewCollaborationHandler(collaborationService, allowedOrigins)
+	sharingHandler := handler.NewSharingHandler(sharingService, collaborationService)
 
 	// 初始化用户处理器
-	userHandler := handler.NewUserHandler(documentRepo)
+	userHandler := handler.NewUserHandler(documentRepo, collaboratorRepo, repository.NewCourseRepository(), repository.NewLearningProgressRepository(), repository.NewQuizRepository(), commentRepo, engagementRepo)
 
 	// 需要验证的API路由
 	api := r.Group("")
@@ -40,23 +59,125 @@ func registerDocumentRoutes(r *gin.Engine, cfg *config.Config) {
 		// 查找文档是否存在
 		documents.GET("/findDoc/:id", documentHandler.FindDoc)
 
-		// 删除文档
+		// 删除文档（移入回收站）
 		documents.DELETE("/deleteDoc/:id", documentHandler.DeleteDoc)
 
+		// 获取回收站中的文档
+		documents.GET("/trash", trashHandler.GetTrash)
+
+		// 恢复回收站中的文档
+		documents.POST("/trash/:id/restore", trashHandler.RestoreDoc)
+
+		// 永久删除回收站中的文档
+		documents.DELETE("/trash/:id", trashHandler.PurgeDoc)
+
 		// 获取用户文档列表
 		documents.GET("/user", documentHandler.GetUserDocs)
 
+		// 获取用户的文档树（嵌套页面）
+		documents.GET("/tree", documentHandler.GetDocTree)
+
+		// 调整同级文档的顺序
+		documents.PUT("/reorder", documentHandler.ReorderDocs)
+
+		// 获取共享给当前用户的文档列表
+		documents.GET("/shared", sharingHandler.GetSharedDocs)
+
+		// 标签自动补全
+		documents.GET("/tags", documentHandler.SuggestTags)
+
+		// 搜索自己的文档
+		documents.GET("/search", documentHandler.SearchUserDocs)
+
+		// 将自己的所有文档导出为zip
+		documents.GET("/export", exportHandler.ExportUserDocs)
+
+		// 从Markdown、HTML、纯文本文件或zip压缩包导入文档
+		documents.POST("/import", importHandler.ImportDocs)
+
 		// 发布文档
 		documents.PATCH("/:id/publish", documentHandler.PublishDoc)
 
-		// 取消发布文档
+		// 取消发布文档及其子页面
 		documents.PATCH("/:id/unpublish", documentHandler.UnpublishDoc)
 
+		// 设置公开访问使用的短名称，为空时恢复为根据标题自动生成
+		documents.PUT("/:id/slug", documentHandler.SetSlug)
+
+		// 创建子页面
+		documents.POST("/:id/children", documentHandler.CreateChildDoc)
+
+		// 移动文档及其子页面到新的父文档下
+		documents.PATCH("/:id/move", documentHandler.MoveDoc)
+
 		// 更新文档
 		documents.PUT("/:id", documentHandler.UpdateDoc)
 
 		// 获取文档详情
 		documents.GET("/:id", documentHandler.GetDoc)
+
+		// 导出文档为Markdown、HTML或PDF
+		documents.GET("/:id/export", exportHandler.ExportDoc)
+
+		// 获取文档修订版本列表
+		documents.GET("/:id/revisions", revisionHandler.ListRevisions)
+
+		// 比较两个修订版本
+		documents.GET("/:id/revisions/diff", revisionHandler.DiffRevisions)
+
+		// 获取修订版本详情
+		documents.GET("/:id/revisions/:revisionId", revisionHandler.GetRevision)
+
+		// 恢复修订版本
+		documents.POST("/:id/revisions/:revisionId/restore", revisionHandler.RestoreRevision)
+
+		// 获取文档协作者列表
+		documents.GET("/:id/collaborators", sharingHandler.ListCollaborators)
+
+		// 邀请协作者或修改协作者角色
+		documents.POST("/:id/collaborators", sharingHandler.InviteCollaborator)
+
+		// 移除协作者
+		documents.DELETE("/:id/collaborators/:userId", sharingHandler.RevokeCollaborator)
+
+		// 获取文档的分享链接列表
+		documents.GET("/:id/share-links", sharingHandler.ListShareLinks)
+
+		// 创建分享链接
+		documents.POST("/:id/share-links", sharingHandler.CreateShareLink)
+
+		// 撤销分享链接
+		documents.DELETE("/:id/share-links/:linkId", sharingHandler.RevokeShareLink)
+
+		// 获取文档的讨论串
+		documents.GET("/:id/comments", commentHandler.ListComments)
+
+		// 发表评论或回复，通过commenter分享链接评论时需提供X-Share-Token
+		documents.POST("/:id/comments", commentHandler.CreateComment)
+
+		// 修改评论，通过分享链接评论的作者需提供X-Share-Token
+		documents.PUT("/:id/comments/:commentId", commentHandler.UpdateComment)
+
+		// 删除评论，通过分享链接评论的作者需提供X-Share-Token
+		documents.DELETE("/:id/comments/:commentId", commentHandler.DeleteComment)
+
+		// 将讨论串标记为已解决
+		documents.PATCH("/:id/comments/:commentId/resolve", commentHandler.ResolveComment)
+
+		// 重新打开讨论串
+		documents.PATCH("/:id/comments/:commentId/unresolve", commentHandler.UnresolveComment)
+
+		// 隐藏评论
+		documents.PATCH("/:id/comments/:commentId/hide", commentHandler.HideComment)
+
+		// 取消隐藏评论
+		documents.PATCH("/:id/comments/:commentId/unhide", commentHandler.UnhideComment)
+
+		// 举报评论
+		documents.POST("/:id/comments/:commentId/report", commentHandler.ReportComment)
+
+		// 实时协作编辑 (WebSocket)
+		documents.GET("/:id/collaborate", collaborationHandler.Connect)
 	}
 
 }
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"betalyr-learning-server/internal/config"
+	"betalyr-learning-server/internal/handler"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/repository"
+	"betalyr-learning-server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerEngagementRoutes 注册点赞和收藏相关路由
+func registerEngagementRoutes(r *gin.Engine, cfg *config.Config) {
+	// 初始化点赞和收藏相关依赖
+	documentRepo := repository.NewDocumentRepository()
+	sharingService := service.NewSharingService(documentRepo, repository.NewDocumentCollaboratorRepository(), repository.NewDocumentShareLinkRepository())
+	engagementService := service.NewEngagementService(cfg, repository.NewEngagementRepository(), documentRepo, repository.NewMediaRepository(), sharingService)
+	engagementHandler := handler.NewEngagementHandler(engagementService)
+
+	api := r.Group("")
+	api.Use(middleware.AuthChecker())
+
+	engagement := api.Group("/engagement")
+	{
+		// 获取当前用户的收藏列表
+		engagement.GET("/bookmarks", engagementHandler.GetBookmarks)
+
+		// 获取文档或媒体的互动数量及当前用户的点赞、收藏状态，type为document或media
+		engagement.GET("/:type/:id", engagementHandler.GetState)
+
+		// 点赞，只有登录用户可以点赞
+		engagement.PUT("/:type/:id/like", engagementHandler.Like)
+
+		// 取消点赞
+		engagement.DELETE("/:type/:id/like", engagementHandler.Unlike)
+
+		// 收藏，只有登录用户可以收藏
+		engagement.PUT("/:type/:id/bookmark", engagementHandler.Bookmark)
+
+		// 取消收藏
+		engagement.DELETE("/:type/:id/bookmark", engagementHandler.Unbookmark)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"betalyr-learning-server/internal/handler"
 	"betalyr-learning-server/internal/pkg/middleware"
 	"betalyr-learning-server/internal/repository"
+	"betalyr-learning-server/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,7 +13,16 @@ import (
 // registerMediaRoutes 注册媒体相关路由
 func registerMediaRoutes(r *gin.Engine, cfg *config.Config) {
 	mediaRepo := repository.NewMediaRepository()
-	mediaHandler := handler.NewMediaHandler(mediaRepo)
+	mediaProcessingService := service.NewMediaProcessingService(cfg, mediaRepo, repository.NewMediaJobRepository())
+	mediaHandler := handler.NewMediaHandler(mediaRepo, repository.NewEngagementRepository(), repository.NewQuizRepository(), mediaProcessingService)
+
+	// 启动媒体后台处理的工作协程
+	mediaProcessingService.Start()
+
+	// 可断点续传的分片上传，定期取消过期的上传会话
+	uploadService := service.NewUploadService(repository.NewUploadSessionRepository(), mediaRepo, mediaProcessingService)
+	uploadService.StartCleanupJob()
+	uploadHandler := handler.NewUploadHandler(uploadService)
 
 	api := r.Group("")
 	api.Use(middleware.AuthChecker())
@@ -24,10 +34,21 @@ func registerMediaRoutes(r *gin.Engine, cfg *config.Config) {
 		// 上传音频文件
 		media.POST("/upload/audio", mediaHandler.UploadAudio)
 
+		// 分片上传：创建会话、查询进度、上传分片、合并完成和取消
+		media.POST("/uploads", uploadHandler.CreateUpload)
+		media.POST("/uploads/direct", uploadHandler.CreateDirectUpload)
+		media.GET("/uploads/:id", uploadHandler.GetUpload)
+		media.PUT("/uploads/:id/parts/:number", uploadHandler.UploadPart)
+		media.POST("/uploads/:id/part-urls", uploadHandler.PresignParts)
+		media.POST("/uploads/:id/complete", uploadHandler.CompleteUpload)
+		media.DELETE("/uploads/:id", uploadHandler.AbortUpload)
+
 		// 获取视频详情
 		media.GET("/video/:id", mediaHandler.GetVideoDetail)
 		// 获取音频详情
 		media.GET("/audio/:id", mediaHandler.GetAudioDetail)
+		// 获取媒体的处理状态
+		media.GET("/:id/status", mediaHandler.GetMediaStatus)
 
 		// 删除媒体文件
 		media.DELETE("/:id", mediaHandler.DeleteMedia)
@@ -0,0 +1,41 @@
+package router
+
+import (
+	"betalyr-learning-server/internal/config"
+	"betalyr-learning-server/internal/handler"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/repository"
+	"betalyr-learning-server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerProgressRoutes 注册学习进度相关路由
+func registerProgressRoutes(r *gin.Engine, cfg *config.Config) {
+	// 初始化学习进度相关依赖
+	documentRepo := repository.NewDocumentRepository()
+	sharingService := service.NewSharingService(documentRepo, repository.NewDocumentCollaboratorRepository(), repository.NewDocumentShareLinkRepository())
+	progressService := service.NewProgressService(repository.NewLearningProgressRepository(), documentRepo, repository.NewMediaRepository(), sharingService)
+	progressHandler := handler.NewProgressHandler(progressService)
+
+	api := r.Group("")
+	api.Use(middleware.AuthChecker())
+
+	progress := api.Group("/progress")
+	{
+		// 获取当前用户的所有学习进度
+		progress.GET("", progressHandler.GetUserProgress)
+
+		// 继续学习：最近学习但未完成的内容
+		progress.GET("/continue", progressHandler.GetContinueLearning)
+
+		// 获取文档或媒体的学习进度，type为document或media
+		progress.GET("/:type/:id", progressHandler.GetProgress)
+
+		// 上报文档或媒体的学习进度
+		progress.PUT("/:type/:id", progressHandler.ReportProgress)
+
+		// 清除文档或媒体的学习进度
+		progress.DELETE("/:type/:id", progressHandler.ResetProgress)
+	}
+}
@@ -3,6 +3,7 @@ package router
 import (
 	"betalyr-learning-server/internal/config"
 	"betalyr-learning-server/internal/handler"
+	"betalyr-learning-server/internal/pkg/middleware"
 	"betalyr-learning-server/internal/repository"
 	"betalyr-learning-server/internal/service"
 
@@ -13,25 +14,71 @@ import (
 func registerPublicRoutes(r *gin.Engine, cfg *config.Config) {
 	// 初始化文档相关依赖
 	documentRepo := repository.NewDocumentRepository()
-	documentService := service.NewDocumentService(documentRepo)
+	sharingService := service.NewSharingService(documentRepo, repository.NewDocumentCollaboratorRepository(), repository.NewDocumentShareLinkRepository())
+	revisionService := service.NewRevisionService(documentRepo, repository.NewDocumentRevisionRepository(), sharingService)
+	engagementRepo := repository.NewEngagementRepository()
+	documentService := service.NewDocumentService(documentRepo, revisionService, sharingService, engagementRepo)
 	cloudinaryService := service.NewCloudinaryService(cfg)
 
 	// 初始化媒体相关依赖
 	mediaRepo := repository.NewMediaRepository()
-	mediaHandler := handler.NewMediaHandler(mediaRepo)
+	mediaProcessingService := service.NewMediaProcessingService(cfg, mediaRepo, repository.NewMediaJobRepository())
+	mediaHandler := handler.NewMediaHandler(mediaRepo, engagementRepo, repository.NewQuizRepository(), mediaProcessingService)
+
+	// 初始化浏览统计相关依赖
+	engagementHandler := handler.NewEngagementHandler(service.NewEngagementService(cfg, engagementRepo, documentRepo, mediaRepo, sharingService))
+
+	// 初始化课程相关依赖
+	courseService := service.NewCourseService(repository.NewCourseRepository(), documentRepo, mediaRepo, sharingService)
+	courseHandler := handler.NewCourseHandler(courseService)
+
+	// 初始化评论相关依赖
+	commentHandler := handler.NewCommentHandler(service.NewCommentService(repository.NewDocumentCommentRepository(), documentRepo, sharingService))
+
+	// 初始化订阅源和站点地图相关依赖
+	feedHandler := handler.NewFeedHandler(service.NewFeedService(cfg, documentRepo, mediaRepo))
 
 	// 初始化处理器
-	documentHandler := handler.NewDocumentHandler(documentService, cloudinaryService)
+	documentHandler := handler.NewDocumentHandler(documentService, cloudinaryService, nil)
+	// 公开路由只通过分享链接访问文档，不涉及协作者管理，因此不需要协作服务
+	sharingHandler := handler.NewSharingHandler(sharingService, nil)
 
 	// 公开文章列表不需要身份验证
 	public := r.Group("/public")
 	{
 		public.GET("/documents", documentHandler.GetPublishedDocs)
+		// 全文搜索公开文档
+		public.GET("/documents/search", documentHandler.SearchPublishedDocs)
+		// 根据短名称获取公开文档及其元数据，旧短名称会重定向到当前短名称
+		public.GET("/documents/by-slug/:slug", documentHandler.GetPublicDocBySlug)
+		public.GET("/documents/by-slug/:slug/meta", documentHandler.GetPublicDocMetaBySlug)
+		// 公开文档详情
+		public.GET("/documents/:id", documentHandler.GetPublicDoc)
+		// 公开文档的SEO和OpenGraph元数据
+		public.GET("/documents/:id/meta", documentHandler.GetPublicDocMeta)
+		// 公开文档或分享链接可访问文档的评论
+		public.GET("/documents/:id/comments", commentHandler.GetPublicComments)
+		// 公开文档和视频的RSS/Atom订阅源，可按标签或作者过滤
+		public.GET("/feed.xml", feedHandler.GetFeed)
+		// 公开文档、视频和音频的站点地图
+		public.GET("/sitemap.xml", feedHandler.GetSitemap)
+		// 公开文档的标签及数量
+		public.GET("/tags", documentHandler.GetPublicTags)
+		// 通过私密分享链接访问文档
+		public.GET("/shared/:token", sharingHandler.GetSharedDocument)
 		// Cloudinary签名接口
 		public.POST("/sign-cloudinary", documentHandler.CloudinarySignRequest)
 
 		// 公开媒体相关接口
 		public.GET("/media/video", mediaHandler.GetVideos)
 		public.GET("/media/audio", mediaHandler.GetAudios)
+
+		// 公开课程列表及详情
+		public.GET("/courses", courseHandler.GetPublishedCourses)
+		public.GET("/courses/:id", courseHandler.GetPublicCourse)
+
+		// 文档或媒体的点赞、收藏和浏览数量，以及记录浏览；携带认证信息时按用户去重，否则按IP去重
+		public.GET("/engagement/:type/:id", middleware.OptionalAuth(), engagementHandler.GetState)
+		public.POST("/engagement/:type/:id/view", middleware.OptionalAuth(), engagementHandler.RecordView)
 	}
 }
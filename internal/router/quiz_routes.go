@@ -0,0 +1,53 @@
+package router
+
+import (
+	"betalyr-learning-server/internal/config"
+	"betalyr-learning-server/internal/handler"
+	"betalyr-learning-server/internal/pkg/middleware"
+	"betalyr-learning-server/internal/repository"
+	"betalyr-learning-server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerQuizRoutes 注册测验相关路由
+func registerQuizRoutes(r *gin.Engine, cfg *config.Config) {
+	// 初始化测验相关依赖
+	documentRepo := repository.NewDocumentRepository()
+	sharingService := service.NewSharingService(documentRepo, repository.NewDocumentCollaboratorRepository(), repository.NewDocumentShareLinkRepository())
+	quizService := service.NewQuizService(repository.NewQuizRepository(), documentRepo, repository.NewMediaRepository(), sharingService)
+	quizHandler := handler.NewQuizHandler(quizService)
+
+	api := r.Group("")
+	api.Use(middleware.AuthChecker())
+
+	quizzes := api.Group("/quizzes")
+	{
+		// 在文档或视频上创建测验
+		quizzes.POST("", quizHandler.CreateQuiz)
+
+		// 获取附加在文档或视频上的测验（不包含答案）
+		quizzes.GET("", quizHandler.GetLearnerQuizByTarget)
+
+		// 获取测验题目（不包含答案）
+		quizzes.GET("/:id", quizHandler.GetLearnerQuiz)
+
+		// 获取包含答案的测验，供作者编辑
+		quizzes.GET("/:id/edit", quizHandler.GetQuiz)
+
+		// 更新测验
+		quizzes.PUT("/:id", quizHandler.UpdateQuiz)
+
+		// 删除测验
+		quizzes.DELETE("/:id", quizHandler.DeleteQuiz)
+
+		// 提交作答并评分
+		quizzes.POST("/:id/attempts", quizHandler.SubmitAttempt)
+
+		// 获取当前用户的作答记录
+		quizzes.GET("/:id/attempts", quizHandler.GetUserAttempts)
+
+		// 获取测验的作答统计
+		quizzes.GET("/:id/stats", quizHandler.GetQuizStats)
+	}
+}
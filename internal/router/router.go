@@ -8,6 +8,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// 允许跨域访问的前端地址
+var allowedOrigins = []string{"http://localhost:3030", "https://375566.xyz"}
+
 // SetupRouter 初始化并配置Gin路由器
 func SetupRouter(cfg *config.Config) *gin.Engine {
 	// 初始化gin
@@ -16,10 +19,10 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 
 	// 配置CORS
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3030", "https://375566.xyz"},
+		AllowOrigins:     allowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-Virtual-User-ID"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-Virtual-User-ID", "If-Match", "X-Share-Token", "X-Share-Password"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", "ETag"},
 		AllowCredentials: true,
 		AllowWildcard:    true,
 		MaxAge:           12 * time.Hour,
@@ -30,7 +33,7 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 		origin := c.Request.Header.Get("Origin")
 		c.Header("Access-Control-Allow-Origin", origin)
 		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin,Content-Type,Accept,Authorization,X-Requested-With,X-Virtual-User-ID")
+		c.Header("Access-Control-Allow-Headers", "Origin,Content-Type,Accept,Authorization,X-Requested-With,X-Virtual-User-ID,If-Match,X-Share-Token,X-Share-Password")
 		c.Header("Access-Control-Allow-Credentials", "true")
 		c.Status(204)
 	})
@@ -40,5 +43,9 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 	registerPublicRoutes(r, cfg)
 	registerDocumentRoutes(r, cfg)
 	registerMediaRoutes(r, cfg)
+	registerCourseRoutes(r, cfg)
+	registerProgressRoutes(r, cfg)
+	registerQuizRoutes(r, cfg)
+	registerEngagementRoutes(r, cfg)
 	return r
 }
@@ -0,0 +1,720 @@
+package service
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/jsonpatch"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/repository"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// 协作状态写入数据库的间隔
+	collabFlushInterval = 2 * time.Second
+	// 每个客户端待发送消息的缓冲数量，写满时断开该客户端
+	collabSendBuffer = 256
+	// 保留最近应用的修改数量，基于更早状态的修改无法调整，会被拒绝
+	collabHistoryLimit = 200
+)
+
+// CollabClient 协作房间中的一个连接
+type CollabClient struct {
+	ID     string
+	UserID string
+	// Send 待发送给客户端的消息，由连接的写协程消费，客户端被移出房间时关闭
+	Send chan []byte
+
+	room   *collabRoom
+	closed bool
+}
+
+// collabPresence 协作者在线状态
+type collabPresence struct {
+	ClientID  string          `json:"clientId"`
+	UserID    string          `json:"userId"`
+	Cursor    json.RawMessage `json:"cursor,omitempty"`
+	Selection json.RawMessage `json:"selection,omitempty"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+// collabIncoming 客户端发送的消息
+type collabIncoming struct {
+	Type      string                `json:"type"` // patch, presence, sync
+	BaseSeq   int64                 `json:"baseSeq"`
+	Patch     []jsonpatch.Operation `json:"patch,omitempty"`
+	Cursor    json.RawMessage       `json:"cursor,omitempty"`
+	Selection json.RawMessage       `json:"selection,omitempty"`
+}
+
+// collabAppliedPatch 房间已应用的修改，用于调整基于旧状态的修改
+type collabAppliedPatch struct {
+	seq   int64 // 应用后的seq
+	patch []jsonpatch.Operation
+}
+
+// collabOutgoing 服务器发送的消息
+type collabOutgoing struct {
+	Type     string                `json:"type"` // init, sync, patch, ack, reject, presence, join, leave, error
+	Seq      int64                 `json:"seq"`
+	ClientID string                `json:"clientId,omitempty"`
+	State    interface{}           `json:"state,omitempty"`
+	Patch    []jsonpatch.Operation `json:"patch,omitempty"`
+	Peers    []*collabPresence     `json:"peers,omitempty"`
+	Peer     *collabPresence       `json:"peer,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+// CollaborationService 定义文档实时协作服务接口
+type CollaborationService interface {
+	// Join 加入文档的协作房间，无权编辑时返回ErrDocumentNotFound
+	Join(documentID, userID string) (*CollabClient, error)
+	// Receive 处理客户端发送的消息
+	Receive(client *CollabClient, data []byte)
+	// Leave 离开协作房间
+	Leave(client *CollabClient)
+	// RefreshAccess 重新检查用户的编辑权限，权限被移除或降级时断开该用户的协作连接
+	RefreshAccess(documentID, userID string)
+	// CloseDocument 文档被移到回收站后关闭协作房间，未保存的协作内容保存为修订版本
+	CloseDocument(documentID string)
+}
+
+// collaborationService 文档实时协作服务实现
+type collaborationService struct {
+	repo      repository.DocumentRepository
+	revisions RevisionService
+	sharing   SharingService
+
+	// mu只保护rooms，不在持有时读写数据库
+	mu    sync.Mutex
+	rooms map[string]*collabRoom
+}
+
+// NewCollaborationService 创建新的文档实时协作服务实例
+func NewCollaborationService(repo repository.DocumentRepository, revisions RevisionService, sharing SharingService) CollaborationService {
+	return &collaborationService{
+		repo:      repo,
+		revisions: revisions,
+		sharing:   sharing,
+		rooms:     make(map[string]*collabRoom),
+	}
+}
+
+// collabRoom 单个文档的协作房间，state为 {"title": ..., "editorJson": ...}。
+// 房间在rooms中的生命周期：创建后由第一个加入者加载文档（ready关闭前其他加入者等待），
+// 最后一个客户端离开后标记为closing并完成最后一次保存，之后从rooms中移除并关闭closed。
+// 同一文档同时只有一个房间，新的加入者会等待正在关闭的房间保存完成，避免读取到旧内容
+type collabRoom struct {
+	service    *collaborationService
+	documentID string
+	ready      chan struct{} // 文档加载完成或失败后关闭
+	loadErr    error         // 加载失败的原因，ready关闭后只读
+	closed     chan struct{} // 最后一次保存完成并从rooms中移除后关闭
+
+	mu         sync.Mutex
+	doc        *models.Document
+	state      map[string]interface{}
+	seq        int64
+	history    []collabAppliedPatch // 最近应用的修改，按seq升序
+	dirty      bool
+	lastEditor string
+	closing    bool
+	deleted    bool // 文档已被移到回收站，不再接受新的加入
+	clients    map[string]*CollabClient
+	presence   map[string]*collabPresence
+	done       chan struct{}
+}
+
+// Join 加入文档的协作房间
+func (s *collaborationService) Join(documentID, userID string) (*CollabClient, error) {
+	for {
+		room, created := s.getOrCreateRoom(documentID)
+		if created {
+			if err := s.openRoom(room, userID); err != nil {
+				return nil, err
+			}
+		} else {
+			<-room.ready
+			if room.loadErr != nil {
+				// 创建房间的用户无权编辑或加载失败，房间已被移除，重新创建
+				continue
+			}
+			room.mu.Lock()
+			doc := room.doc
+			room.mu.Unlock()
+			if err := s.canEditDoc(doc, userID); err != nil {
+				return nil, err
+			}
+		}
+
+		client := &CollabClient{
+			ID:     uuid.New().String(),
+			UserID: userID,
+			Send:   make(chan []byte, collabSendBuffer),
+			room:   room,
+		}
+		if !room.join(client) {
+			// 房间正在关闭，等待最后一次保存完成后创建新房间
+			<-room.closed
+			continue
+		}
+
+		logger.Info("Collaborator joined document",
+			zap.String("documentID", documentID),
+			zap.String("userID", userID),
+			zap.String("clientID", client.ID))
+
+		return client, nil
+	}
+}
+
+// getOrCreateRoom 获取文档的协作房间，不存在时创建一个待加载的房间并返回true
+func (s *collaborationService) getOrCreateRoom(documentID string) (*collabRoom, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if room, ok := s.rooms[documentID]; ok {
+		return room, false
+	}
+	room := s.newRoom(documentID)
+	s.rooms[documentID] = room
+	return room, true
+}
+
+// openRoom 检查创建者的编辑权限并加载文档，失败时移除房间
+func (s *collaborationService) openRoom(room *collabRoom, userID string) error {
+	defer close(room.ready)
+
+	doc, err := s.sharing.Authorize(room.documentID, userID, models.DocumentRoleEditor)
+	if err != nil {
+		room.loadErr = err
+		s.removeRoom(room)
+		return err
+	}
+	// 协作修改前先保存原始内容，失败不影响协作
+	if err := s.revisions.SnapshotBaseline(doc); err != nil {
+		logger.Error("Failed to save original document revision", zap.String("documentID", room.documentID), zap.Error(err))
+	}
+
+	room.doc = doc
+	room.state = collabState(doc)
+	go room.flushLoop()
+	return nil
+}
+
+// removeRoom 从rooms中移除房间，文档已有新房间时不移除
+func (s *collaborationService) removeRoom(room *collabRoom) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rooms[room.documentID] == room {
+		delete(s.rooms, room.documentID)
+	}
+}
+
+// Receive 处理客户端发送的消息
+func (s *collaborationService) Receive(client *CollabClient, data []byte) {
+	var msg collabIncoming
+	if err := json.Unmarshal(data, &msg); err != nil {
+		client.room.sendTo(client, collabOutgoing{Type: "error", Error: "Invalid message format"})
+		return
+	}
+
+	switch msg.Type {
+	case "patch":
+		client.room.applyPatch(client, msg)
+	case "presence":
+		client.room.updatePresence(client, msg)
+	case "sync":
+		client.room.sync(client, "init")
+	default:
+		client.room.sendTo(client, collabOutgoing{Type: "error", Error: fmt.Sprintf("Unknown message type %q", msg.Type)})
+	}
+}
+
+// Leave 离开协作房间，最后一个客户端离开时保存并关闭房间
+func (s *collaborationService) Leave(client *CollabClient) {
+	room := client.room
+
+	logger.Info("Collaborator left document",
+		zap.String("documentID", room.documentID),
+		zap.String("userID", client.UserID),
+		zap.String("clientID", client.ID))
+
+	if !room.leave(client) {
+		return
+	}
+
+	// 房间已标记为关闭，新的加入者会等待closed，因此不会在保存前读取到旧内容
+	close(room.done)
+	room.flush(true)
+	s.removeRoom(room)
+	close(room.closed)
+}
+
+// RefreshAccess 在协作者被移除或降级后调用，断开已无编辑权限的用户在协作房间中的所有连接
+func (s *collaborationService) RefreshAccess(documentID, userID string) {
+	s.mu.Lock()
+	room, ok := s.rooms[documentID]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	<-room.ready
+	if room.loadErr != nil {
+		return
+	}
+
+	room.mu.Lock()
+	doc := room.doc
+	room.mu.Unlock()
+
+	err := s.canEditDoc(doc, userID)
+	if err == nil {
+		return
+	}
+	// 无法确认权限时同样断开，客户端可以重新连接
+	if !errors.Is(err, ErrDocumentNotFound) && !errors.Is(err, ErrPermissionDenied) {
+		logger.Error("Failed to check collaborator access", zap.String("documentID", documentID), zap.Error(err))
+	}
+	room.disconnectUser(userID, "Access to this document was revoked")
+}
+
+// CloseDocument 在文档被移到回收站后调用，断开房间中的所有连接。
+// 子文档的房间在下一次保存发现文档已不存在时关闭
+func (s *collaborationService) CloseDocument(documentID string) {
+	s.mu.Lock()
+	room, ok := s.rooms[documentID]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	<-room.ready
+	if room.loadErr != nil {
+		return
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.closeDeletedLocked()
+}
+
+// newRoom 创建待加载文档的协作房间
+func (s *collaborationService) newRoom(documentID string) *collabRoom {
+	return &collabRoom{
+		service:    s,
+		documentID: documentID,
+		ready:      make(chan struct{}),
+		closed:     make(chan struct{}),
+		clients:    make(map[string]*CollabClient),
+		presence:   make(map[string]*collabPresence),
+		done:       make(chan struct{}),
+	}
+}
+
+// collabState 根据文档生成协作状态
+func collabState(doc *models.Document) map[string]interface{} {
+	var editorJSON interface{}
+	if doc.EditorJSON != nil {
+		editorJSON = map[string]interface{}(*doc.EditorJSON)
+	}
+	return map[string]interface{}{
+		"title":      doc.Title,
+		"editorJson": jsonpatch.DeepCopy(editorJSON),
+	}
+}
+
+// canEditDoc 检查用户是否可以编辑文档，与UpdateDoc的权限规则一致
+func (s *collaborationService) canEditDoc(doc *models.Document, userID string) error {
+	role, err := s.sharing.GetRole(doc, userID)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return ErrDocumentNotFound
+	}
+	if !role.AtLeast(models.DocumentRoleEditor) {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// join 将客户端加入房间并发送初始状态，房间正在关闭时返回false
+func (r *collabRoom) join(client *CollabClient) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closing || r.deleted {
+		return false
+	}
+
+	presence := &collabPresence{
+		ClientID:  client.ID,
+		UserID:    client.UserID,
+		UpdatedAt: time.Now(),
+	}
+	r.clients[client.ID] = client
+	r.presence[client.ID] = presence
+
+	r.sendLocked(client, r.initMessage(client, "init"))
+	r.broadcastLocked(client.ID, collabOutgoing{Type: "join", Seq: r.seq, Peer: presence})
+	return true
+}
+
+// leave 将客户端移出房间。房间因此变空时标记为正在关闭并返回true，由调用方完成关闭
+func (r *collabRoom) leave(client *CollabClient) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clients[client.ID]; ok {
+		delete(r.clients, client.ID)
+		delete(r.presence, client.ID)
+		r.closeClientLocked(client)
+		r.broadcastLocked(client.ID, collabOutgoing{Type: "leave", Seq: r.seq, ClientID: client.ID})
+	}
+
+	if len(r.clients) > 0 || r.closing {
+		return false
+	}
+	r.closing = true
+	return true
+}
+
+// disconnectUser 通知用户的所有连接后关闭，连接的读协程随后会调用Leave
+func (r *collabRoom) disconnectUser(userID, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, client := range r.clients {
+		if client.UserID != userID || client.closed {
+			continue
+		}
+		r.sendLocked(client, collabOutgoing{Type: "error", Seq: r.seq, Error: reason})
+		r.closeClientLocked(client)
+
+		logger.Info("Collaborator disconnected",
+			zap.String("documentID", r.documentID),
+			zap.String("userID", userID),
+			zap.String("clientID", client.ID))
+	}
+}
+
+// applyPatch 应用客户端的修改并广播给其他协作者。
+// 基于旧状态（baseSeq小于当前seq）的修改会先根据之后已应用的修改调整数组下标等位置再应用，
+// 其他协作者收到调整后的修改，提交者在ack之后收到最新状态。与并发修改冲突、基于过早的状态
+// 或无法应用的修改会被拒绝，并向客户端发送最新状态，由客户端重新基于最新状态提交
+func (r *collabRoom) applyPatch(client *CollabClient, msg collabIncoming) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 已被断开的客户端在连接关闭前发送的修改不再接受
+	if client.closed {
+		return
+	}
+
+	if len(msg.Patch) == 0 {
+		r.sendLocked(client, collabOutgoing{Type: "ack", Seq: r.seq})
+		return
+	}
+
+	patch, err := r.rebasePatchLocked(msg)
+	var newState interface{}
+	if err == nil {
+		newState, err = jsonpatch.Apply(r.state, patch)
+	}
+	if err == nil {
+		err = validateCollabState(newState)
+	}
+	if err != nil {
+		logger.Info("Rejected collaborative patch",
+			zap.String("documentID", r.documentID),
+			zap.String("clientID", client.ID),
+			zap.Int64("baseSeq", msg.BaseSeq),
+			zap.Int64("seq", r.seq),
+			zap.Error(err))
+		r.sendLocked(client, collabOutgoing{Type: "reject", Seq: r.seq, Error: err.Error()})
+		r.sendLocked(client, r.initMessage(client, "sync"))
+		return
+	}
+
+	r.state = newState.(map[string]interface{})
+	r.seq++
+	r.dirty = true
+	r.lastEditor = client.UserID
+	r.history = append(r.history, collabAppliedPatch{seq: r.seq, patch: patch})
+	if len(r.history) > collabHistoryLimit {
+		r.history = r.history[len(r.history)-collabHistoryLimit:]
+	}
+
+	r.sendLocked(client, collabOutgoing{Type: "ack", Seq: r.seq})
+	if msg.BaseSeq != r.seq-1 {
+		// 提交者的本地状态不包含调整后的结果，发送最新状态
+		r.sendLocked(client, r.initMessage(client, "sync"))
+	}
+	r.broadcastLocked(client.ID, collabOutgoing{Type: "patch", Seq: r.seq, ClientID: client.ID, Patch: patch})
+}
+
+// rebasePatchLocked 将基于baseSeq的修改调整为可以在当前状态上应用的修改，调用方需持有r.mu
+func (r *collabRoom) rebasePatchLocked(msg collabIncoming) ([]jsonpatch.Operation, error) {
+	if msg.BaseSeq == r.seq {
+		return msg.Patch, nil
+	}
+	if msg.BaseSeq > r.seq || len(r.history) == 0 || r.history[0].seq > msg.BaseSeq+1 {
+		return nil, fmt.Errorf("patch is based on seq %d which cannot be rebased onto seq %d", msg.BaseSeq, r.seq)
+	}
+
+	patch := msg.Patch
+	for _, applied := range r.history {
+		if applied.seq <= msg.BaseSeq {
+			continue
+		}
+		var err error
+		if patch, err = jsonpatch.Transform(patch, applied.patch); err != nil {
+			return nil, err
+		}
+	}
+	return patch, nil
+}
+
+// updatePresence 更新光标位置和选区并广播
+func (r *collabRoom) updatePresence(client *CollabClient, msg collabIncoming) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	presence, ok := r.presence[client.ID]
+	if !ok || client.closed {
+		return
+	}
+	presence.Cursor = msg.Cursor
+	presence.Selection = msg.Selection
+	presence.UpdatedAt = time.Now()
+
+	r.broadcastLocked(client.ID, collabOutgoing{Type: "presence", Seq: r.seq, Peer: presence})
+}
+
+// sync 向客户端发送完整状态
+func (r *collabRoom) sync(client *CollabClient, msgType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sendLocked(client, r.initMessage(client, msgType))
+}
+
+// sendTo 向单个客户端发送消息
+func (r *collabRoom) sendTo(client *CollabClient, msg collabOutgoing) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sendLocked(client, msg)
+}
+
+// initMessage 构建包含完整状态和在线协作者的消息
+func (r *collabRoom) initMessage(client *CollabClient, msgType string) collabOutgoing {
+	peers := make([]*collabPresence, 0, len(r.presence))
+	for _, p := range r.presence {
+		peers = append(peers, p)
+	}
+	return collabOutgoing{
+		Type:     msgType,
+		Seq:      r.seq,
+		ClientID: client.ID,
+		State:    r.state,
+		Peers:    peers,
+	}
+}
+
+// sendLocked 向客户端发送消息，缓冲区已满时断开该客户端，调用方需持有r.mu
+func (r *collabRoom) sendLocked(client *CollabClient, msg collabOutgoing) {
+	if client.closed {
+		return
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logger.Error("Failed to encode collaboration message", zap.Error(err))
+		return
+	}
+	select {
+	case client.Send <- data:
+	default:
+		logger.Warn("Collaboration client is too slow, disconnecting",
+			zap.String("documentID", r.documentID),
+			zap.String("clientID", client.ID))
+		r.closeClientLocked(client)
+	}
+}
+
+// broadcastLocked 向除exceptID外的所有客户端发送消息，调用方需持有r.mu
+func (r *collabRoom) broadcastLocked(exceptID string, msg collabOutgoing) {
+	for id, client := range r.clients {
+		if id != exceptID {
+			r.sendLocked(client, msg)
+		}
+	}
+}
+
+// closeClientLocked 关闭客户端的发送通道，写协程随后会关闭连接
+func (r *collabRoom) closeClientLocked(client *CollabClient) {
+	if !client.closed {
+		client.closed = true
+		close(client.Send)
+	}
+}
+
+// flushLoop 定期将协作状态写入数据库，直到房间关闭
+func (r *collabRoom) flushLoop() {
+	ticker := time.NewTicker(collabFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.flush(false)
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// flush 将协作状态写入数据库，final为true时同时保存修订版本。
+// 文档在协作期间通过其他接口被修改时不覆盖该修改：未保存的协作内容另存为修订版本，
+// 房间切换到最新的文档内容并向所有协作者发送sync
+func (r *collabRoom) flush(final bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.dirty {
+		return
+	}
+	if r.deleted {
+		r.closeDeletedLocked()
+		return
+	}
+
+	r.applyStateLocked(r.doc)
+	err := r.service.repo.Update(r.doc)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		// 最新内容已由其他接口保存，切换成功后无需再写入
+		err = r.rebaseLocked()
+		if errors.Is(err, ErrDocumentNotFound) {
+			// 文档已被移到回收站，重试保存不会成功
+			r.closeDeletedLocked()
+			return
+		}
+		if err == nil {
+			return
+		}
+	}
+	if err != nil {
+		// 保留dirty，下次保存时重试
+		logger.Error("Failed to persist collaborative document", zap.String("documentID", r.documentID), zap.Error(err))
+		return
+	}
+
+	r.dirty = false
+	syncDocSlug(r.service.repo, r.doc)
+
+	if final {
+		if err := r.service.revisions.Snapshot(r.doc, r.lastEditor, ""); err != nil {
+			logger.Error("Failed to save document revision", zap.String("documentID", r.documentID), zap.Error(err))
+		}
+	}
+}
+
+// rebaseLocked 处理保存时的版本冲突：将未保存的协作内容保存为修订版本，
+// 再以最新的文档内容替换协作状态并通知所有协作者，调用方需持有r.mu
+func (r *collabRoom) rebaseLocked() error {
+	latest, err := r.service.repo.FindByID(r.documentID)
+	if err != nil {
+		return err
+	}
+	if latest == nil {
+		return ErrDocumentNotFound
+	}
+
+	// r.doc中是未保存的协作内容，保存失败时不切换，避免丢失
+	summary := "Unsaved collaborative changes, replaced by a concurrent edit"
+	if err := r.service.revisions.Snapshot(r.doc, r.lastEditor, summary); err != nil {
+		return err
+	}
+
+	logger.Warn("Document changed outside of collaboration session, collaborative changes saved as a revision",
+		zap.String("documentID", r.documentID),
+		zap.Int64("version", latest.Version))
+
+	r.doc = latest
+	r.state = collabState(latest)
+	r.seq++
+	r.history = nil
+	r.dirty = false
+
+	for _, client := range r.clients {
+		msg := r.initMessage(client, "sync")
+		msg.Error = "Document was modified outside of the collaboration session; unsaved changes were saved as a revision"
+		r.sendLocked(client, msg)
+	}
+	return nil
+}
+
+// closeDeletedLocked 文档已被移到回收站时关闭房间：未保存的协作内容保存为修订版本，
+// 恢复文档后可以找回，保存失败时保留dirty，由下次保存重试；然后断开所有连接，
+// 最后一个连接离开后房间关闭。调用方需持有r.mu
+func (r *collabRoom) closeDeletedLocked() {
+	if !r.deleted {
+		r.deleted = true
+		logger.Info("Closing collaboration room of deleted document", zap.String("documentID", r.documentID))
+	}
+
+	if r.dirty {
+		r.applyStateLocked(r.doc)
+		summary := "Unsaved collaborative changes, document was moved to trash"
+		if err := r.service.revisions.Snapshot(r.doc, r.lastEditor, summary); err != nil {
+			logger.Error("Failed to save collaborative changes of deleted document", zap.String("documentID", r.documentID), zap.Error(err))
+		} else {
+			r.dirty = false
+		}
+	}
+
+	for _, client := range r.clients {
+		r.sendLocked(client, collabOutgoing{Type: "error", Seq: r.seq, Error: "Document was moved to trash"})
+		r.closeClientLocked(client)
+	}
+}
+
+// applyStateLocked 将协作状态写入文档模型，调用方需持有r.mu
+func (r *collabRoom) applyStateLocked(doc *models.Document) {
+	doc.Title, _ = r.state["title"].(string)
+	if editorJSON, ok := r.state["editorJson"].(map[string]interface{}); ok {
+		content := models.JSONContent(jsonpatch.DeepCopy(editorJSON).(map[string]interface{}))
+		doc.EditorJSON = &content
+	} else {
+		doc.EditorJSON = nil
+	}
+	doc.RefreshIndex()
+	doc.UpdatedAt = time.Now()
+}
+
+// validateCollabState 校验修改后的状态结构
+func validateCollabState(state interface{}) error {
+	m, ok := state.(map[string]interface{})
+	if !ok {
+		return errors.New("state must be an object")
+	}
+	if _, ok := m["title"].(string); !ok {
+		return errors.New("title must be a string")
+	}
+	switch m["editorJson"].(type) {
+	case nil, map[string]interface{}:
+	default:
+		return errors.New("editorJson must be an object or null")
+	}
+	for key := range m {
+		if key != "title" && key != "editorJson" {
+			return fmt.Errorf("unknown field %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/editorjson"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/repository"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// 评论内容的最大长度（字符数）
+	maxCommentLength = 5000
+	// 举报原因的最大长度（字符数）
+	maxReportReasonLength = 500
+	// 评论被不同的登录用户举报达到该次数时自动隐藏，虚拟用户的举报不计入
+	commentHideReports = 5
+)
+
+// CommentService 定义文档评论服务接口
+type CommentService interface {
+	// 分页获取文档的讨论串，includeHidden只对文档所有者生效；私有文档可以通过分享链接查看
+	ListComments(documentID, userID string, link models.ShareLinkCredentials, includeHidden bool, page, limit int) ([]models.CommentView, int64, error)
+	// 发表评论或回复，公开文档所有登录用户都可以评论，私有文档需要协作者或分享链接授予commenter及以上角色
+	CreateComment(documentID, userID string, link models.ShareLinkCredentials, input models.CommentInput) (*models.CommentView, error)
+	// 修改评论内容，只有作者可以修改；通过分享链接评论的作者需要提供同一链接
+	UpdateComment(documentID, commentID, userID string, link models.ShareLinkCredentials, content string) (*models.CommentView, error)
+	// 删除评论，只有作者可以删除；通过分享链接评论的作者需要提供同一链接
+	DeleteComment(documentID, commentID, userID string, link models.ShareLinkCredentials) error
+	// 解决或重新打开讨论串，只有文档所有者可以操作
+	ResolveComment(documentID, commentID, userID string, resolved bool) (*models.CommentView, error)
+	// 隐藏或取消隐藏评论，只有文档所有者可以操作
+	HideComment(documentID, commentID, userID string, hidden bool) (*models.CommentView, error)
+	// 举报评论，同一用户重复举报不会重复计数，虚拟用户的举报不计入自动隐藏；私有文档可以通过分享链接举报
+	ReportComment(documentID, commentID, userID string, virtual bool, link models.ShareLinkCredentials, reason string) error
+}
+
+// commentService 文档评论服务实现
+type commentService struct {
+	repo    repository.DocumentCommentRepository
+	docs    repository.DocumentRepository
+	sharing SharingService
+}
+
+// NewCommentService 创建新的文档评论服务实例
+func NewCommentService(repo repository.DocumentCommentRepository, docs repository.DocumentRepository, sharing SharingService) CommentService {
+	return &commentService{
+		repo:    repo,
+		docs:    docs,
+		sharing: sharing,
+	}
+}
+
+// ListComments 分页获取文档的讨论串，按创建时间升序排序，每个讨论串包含其所有回复。
+// 已删除但仍有回复的评论以占位形式返回；文档所有者可以查看隐藏的评论和举报次数
+func (s *commentService) ListComments(documentID, userID string, link models.ShareLinkCredentials, includeHidden bool, page, limit int) ([]models.CommentView, int64, error) {
+	doc, _, err := s.findAccessibleDoc(documentID, userID, link)
+	if err != nil {
+		return nil, 0, err
+	}
+	moderator := isDocOwner(doc, userID)
+	includeHidden = includeHidden && moderator
+
+	threads, total, err := s.repo.GetThreads(documentID, includeHidden, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	threadIDs := make([]string, len(threads))
+	for i := range threads {
+		threadIDs[i] = threads[i].ID
+	}
+	replies, err := s.repo.GetReplies(threadIDs, includeHidden)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 将回复归入所属的讨论串
+	repliesByThread := make(map[string][]models.CommentView, len(threads))
+	for i := range replies {
+		parentID := *replies[i].ParentID
+		repliesByThread[parentID] = append(repliesByThread[parentID], replies[i].ToCommentView(moderator))
+	}
+
+	views := make([]models.CommentView, len(threads))
+	for i := range threads {
+		views[i] = threads[i].ToCommentView(moderator)
+		views[i].Replies = repliesByThread[threads[i].ID]
+	}
+	return views, total, nil
+}
+
+// CreateComment 发表评论或回复。回复一条回复时归入同一讨论串，回复不使用锚点；
+// 锚点的文本范围会根据文档当前内容校验，并记录锚定的原文
+func (s *commentService) CreateComment(documentID, userID string, link models.ShareLinkCredentials, input models.CommentInput) (*models.CommentView, error) {
+	doc, role, err := s.findAccessibleDoc(documentID, userID, link)
+	if err != nil {
+		return nil, err
+	}
+	if !isDocPublic(doc) && !role.AtLeast(models.DocumentRoleCommenter) {
+		return nil, ErrPermissionDenied
+	}
+
+	content, err := normalizeCommentContent(input.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &models.DocumentComment{
+		ID:         uuid.New().String(),
+		DocumentID: documentID,
+		AuthorID:   userID,
+		Content:    content,
+	}
+
+	if input.ParentID != nil && *input.ParentID != "" {
+		parent, err := s.findComment(documentID, *input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		threadID := parent.ID
+		if parent.ParentID != nil {
+			threadID = *parent.ParentID
+		}
+		comment.ParentID = &threadID
+	} else {
+		anchor, err := resolveAnchor(doc, input.Anchor)
+		if err != nil {
+			return nil, err
+		}
+		comment.Anchor = anchor
+	}
+
+	if err := s.repo.Create(comment); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Comment created",
+		zap.String("commentID", comment.ID),
+		zap.String("documentID", documentID),
+		zap.String("userID", userID))
+
+	view := comment.ToCommentView(isDocOwner(doc, userID))
+	return &view, nil
+}
+
+// UpdateComment 修改评论内容并记录修改时间
+func (s *commentService) UpdateComment(documentID, commentID, userID string, link models.ShareLinkCredentials, content string) (*models.CommentView, error) {
+	doc, _, err := s.findAccessibleDoc(documentID, userID, link)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := s.findComment(documentID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.AuthorID != userID {
+		return nil, ErrPermissionDenied
+	}
+
+	content, err = normalizeCommentContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	comment.Content = content
+	comment.EditedAt = &now
+	if err := s.repo.Update(comment); err != nil {
+		return nil, err
+	}
+
+	view := comment.ToCommentView(isDocOwner(doc, userID))
+	return &view, nil
+}
+
+// DeleteComment 删除评论，讨论串的第一条评论被删除后，如果仍有回复则以占位形式保留
+func (s *commentService) DeleteComment(documentID, commentID, userID string, link models.ShareLinkCredentials) error {
+	if _, _, err := s.findAccessibleDoc(documentID, userID, link); err != nil {
+		return err
+	}
+	comment, err := s.findComment(documentID, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.AuthorID != userID {
+		return ErrPermissionDenied
+	}
+
+	if err := s.repo.Delete(comment.ID); err != nil {
+		return err
+	}
+
+	logger.Info("Comment deleted",
+		zap.String("commentID", comment.ID),
+		zap.String("documentID", documentID),
+		zap.String("userID", userID))
+	return nil
+}
+
+// ResolveComment 将讨论串标记为已解决或重新打开，只能对讨论串的第一条评论操作
+func (s *commentService) ResolveComment(documentID, commentID, userID string, resolved bool) (*models.CommentView, error) {
+	comment, err := s.findModeratedComment(documentID, commentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.ParentID != nil {
+		return nil, ErrInvalidComment
+	}
+
+	comment.Resolved = resolved
+	if resolved {
+		now := time.Now()
+		comment.ResolvedBy = &userID
+		comment.ResolvedAt = &now
+	} else {
+		comment.ResolvedBy = nil
+		comment.ResolvedAt = nil
+	}
+	if err := s.repo.Update(comment); err != nil {
+		return nil, err
+	}
+
+	view := comment.ToCommentView(true)
+	return &view, nil
+}
+
+// HideComment 隐藏或取消隐藏评论。取消隐藏时清零举报次数，避免之后的一次举报再次触发自动隐藏
+func (s *commentService) HideComment(documentID, commentID, userID string, hidden bool) (*models.CommentView, error) {
+	comment, err := s.findModeratedComment(documentID, commentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.Hidden = hidden
+	if hidden {
+		now := time.Now()
+		comment.HiddenBy = &userID
+		comment.HiddenAt = &now
+	} else {
+		comment.HiddenBy = nil
+		comment.HiddenAt = nil
+		comment.ReportCount = 0
+	}
+	if err := s.repo.Update(comment); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Comment visibility changed",
+		zap.String("commentID", comment.ID),
+		zap.String("documentID", documentID),
+		zap.Bool("hidden", hidden))
+
+	view := comment.ToCommentView(true)
+	return &view, nil
+}
+
+// ReportComment 举报评论，可以查看文档的用户或分享链接持有者都可以举报。
+// 虚拟用户可以随意创建，其举报只保存供文档所有者参考，不计入自动隐藏的举报次数
+func (s *commentService) ReportComment(documentID, commentID, userID string, virtual bool, link models.ShareLinkCredentials, reason string) error {
+	if _, _, err := s.findAccessibleDoc(documentID, userID, link); err != nil {
+		return err
+	}
+	comment, err := s.findComment(documentID, commentID)
+	if err != nil {
+		return err
+	}
+
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > maxReportReasonLength {
+		return ErrInvalidComment
+	}
+
+	added, err := s.repo.AddReport(&models.CommentReport{
+		ID:         uuid.New().String(),
+		CommentID:  comment.ID,
+		ReporterID: userID,
+		Reason:     reason,
+		Virtual:    virtual,
+	}, commentHideReports)
+	if err != nil {
+		return err
+	}
+
+	if added {
+		logger.Info("Comment reported",
+			zap.String("commentID", comment.ID),
+			zap.String("documentID", documentID),
+			zap.String("userID", userID),
+			zap.Bool("virtual", virtual))
+	}
+	return nil
+}
+
+// findVisibleDoc 获取用户可以查看的文档，不可见时返回ErrDocumentNotFound
+func (s *commentService) findVisibleDoc(documentID, userID string) (*models.Document, error) {
+	doc, _, err := s.findAccessibleDoc(documentID, userID, models.ShareLinkCredentials{})
+	return doc, err
+}
+
+// findAccessibleDoc 获取用户或分享链接持有者可以查看的文档，并返回协作者角色和链接角色中较高的一个。
+// 不可见时返回ErrDocumentNotFound，分享链接无效时返回对应的分享链接错误
+func (s *commentService) findAccessibleDoc(documentID, userID string, link models.ShareLinkCredentials) (*models.Document, models.DocumentRole, error) {
+	doc, err := s.docs.FindByID(documentID)
+	if err != nil {
+		return nil, "", err
+	}
+	if doc == nil {
+		return nil, "", ErrDocumentNotFound
+	}
+
+	role, err := s.sharing.GetRole(doc, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	linkRole, err := s.sharing.GetLinkRole(documentID, link)
+	if err != nil {
+		return nil, "", err
+	}
+	if linkRole != "" && !role.AtLeast(linkRole) {
+		role = linkRole
+	}
+
+	if !isDocPublic(doc) && !role.AtLeast(models.DocumentRoleViewer) {
+		return nil, "", ErrDocumentNotFound
+	}
+	return doc, role, nil
+}
+
+// findComment 获取属于该文档的评论
+func (s *commentService) findComment(documentID, commentID string) (*models.DocumentComment, error) {
+	comment, err := s.repo.FindByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil || comment.DocumentID != documentID {
+		return nil, ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+// findModeratedComment 获取由文档所有者管理的评论，非所有者返回ErrPermissionDenied
+func (s *commentService) findModeratedComment(documentID, commentID, userID string) (*models.DocumentComment, error) {
+	doc, err := s.findVisibleDoc(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isDocOwner(doc, userID) {
+		logger.Warn("User attempted to moderate comments on a document they do not own",
+			zap.String("documentID", documentID),
+			zap.String("userID", userID))
+		return nil, ErrPermissionDenied
+	}
+	return s.findComment(documentID, commentID)
+}
+
+// isDocOwner 判断用户是否是文档所有者
+func isDocOwner(doc *models.Document, userID string) bool {
+	return userID != "" && doc.OwnerID == userID
+}
+
+// normalizeCommentContent 去除评论内容首尾空白并检查长度
+func normalizeCommentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" || utf8.RuneCountInString(content) > maxCommentLength {
+		return "", ErrInvalidComment
+	}
+	return content, nil
+}
+
+// resolveAnchor 根据文档当前内容校验锚点。指定BlockID时范围是该块文本中的字符偏移，
+// 否则是文档纯文本中的字符偏移；范围有效时记录锚定的原文
+func resolveAnchor(doc *models.Document, anchor *models.CommentAnchor) (*models.CommentAnchor, error) {
+	if anchor == nil || (anchor.BlockID == "" && anchor.From == nil && anchor.To == nil) {
+		return nil, nil
+	}
+	if doc.EditorJSON == nil {
+		return nil, ErrInvalidAnchor
+	}
+	content := map[string]interface{}(*doc.EditorJSON)
+
+	var text string
+	if anchor.BlockID != "" {
+		block := editorjson.FindByID(content, anchor.BlockID)
+		if block == nil {
+			return nil, ErrInvalidAnchor
+		}
+		text = editorjson.InlineText(block)
+	} else {
+		text = editorjson.PlainText(content)
+	}
+
+	resolved := &models.CommentAnchor{BlockID: anchor.BlockID}
+	if anchor.From == nil && anchor.To == nil {
+		return resolved, nil
+	}
+	if anchor.From == nil || anchor.To == nil {
+		return nil, ErrInvalidAnchor
+	}
+
+	runes := []rune(text)
+	from, to := *anchor.From, *anchor.To
+	if from < 0 || to <= from || to > len(runes) {
+		return nil, ErrInvalidAnchor
+	}
+	resolved.From = &from
+	resolved.To = &to
+	resolved.Quote = string(runes[from:to])
+	return resolved, nil
+}
@@ -0,0 +1,471 @@
+package service
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/repository"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// 每个课程最多包含的章节数量
+	maxCourseLessons = 200
+	// 课程标题的最大长度（字符数）
+	maxCourseTitleLength = 200
+)
+
+// CourseService 定义课程服务接口
+type CourseService interface {
+	// 创建课程，新课程默认不公开
+	CreateCourse(ownerID string, update models.CourseUpdate, lessons []models.CourseLesson) (*models.CourseDetail, error)
+	// 获取课程详情，所有者可以查看未公开的课程
+	GetCourse(id string, userID string) (*models.CourseDetail, error)
+	// 获取用户自己的课程列表
+	GetUserCourses(ownerID string) ([]models.CourseList, error)
+	// 更新课程的标题、描述和封面
+	UpdateCourse(id string, ownerID string, update models.CourseUpdate) (*models.CourseDetail, error)
+	// 按顺序替换课程的所有章节
+	SetLessons(id string, ownerID string, lessons []models.CourseLesson) (*models.CourseDetail, error)
+	// 公开课程，所有章节引用的内容都必须已公开
+	PublishCourse(id string, ownerID string) (*models.CourseDetail, error)
+	// 取消公开课程
+	UnpublishCourse(id string, ownerID string) (*models.CourseDetail, error)
+	// 删除课程，不影响章节引用的文档和媒体
+	DeleteCourse(id string, ownerID string) error
+	// 分页获取公开的课程
+	GetPublishedCourses(page, limit int) ([]models.CourseList, int64, error)
+	// 获取公开课程详情，只包含仍然可见的章节
+	GetPublicCourse(id string) (*models.CourseDetail, error)
+}
+
+// courseService 课程服务实现
+type courseService struct {
+	repo    repository.CourseRepository
+	docs    repository.DocumentRepository
+	media   repository.MediaRepository
+	sharing SharingService
+}
+
+// NewCourseService 创建新的课程服务实例
+func NewCourseService(repo repository.CourseRepository, docs repository.DocumentRepository, media repository.MediaRepository, sharing SharingService) CourseService {
+	return &courseService{
+		repo:    repo,
+		docs:    docs,
+		media:   media,
+		sharing: sharing,
+	}
+}
+
+// CreateCourse 创建课程，章节引用的文档必须对所有者可见
+func (s *courseService) CreateCourse(ownerID string, update models.CourseUpdate, lessons []models.CourseLesson) (*models.CourseDetail, error) {
+	if update.Title == nil {
+		return nil, ErrInvalidCourse
+	}
+
+	isPublic := false
+	course := &models.Course{
+		ID:       uuid.New().String(),
+		OwnerID:  ownerID,
+		IsPublic: &isPublic,
+	}
+	if err := applyCourseUpdate(course, update); err != nil {
+		return nil, err
+	}
+
+	lessons, err := s.validateLessons(ownerID, lessons, false)
+	if err != nil {
+		return nil, err
+	}
+	course.Lessons = lessons
+
+	if err := s.repo.Create(course); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Course created",
+		zap.String("courseID", course.ID),
+		zap.String("ownerID", ownerID),
+		zap.Int("lessons", len(lessons)))
+
+	return s.toDetail(course, ownerID, false)
+}
+
+// GetCourse 获取课程详情。所有者可以查看未公开的课程及所有章节，
+// 其他用户只能查看公开课程中仍然可见的章节
+func (s *courseService) GetCourse(id string, userID string) (*models.CourseDetail, error) {
+	course, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if course == nil {
+		return nil, ErrCourseNotFound
+	}
+
+	if course.OwnerID == userID {
+		return s.toDetail(course, userID, false)
+	}
+	if !isCoursePublic(course) {
+		return nil, ErrCourseNotFound
+	}
+	return s.toDetail(course, "", true)
+}
+
+// GetUserCourses 获取用户自己的课程列表，按更新时间降序排序
+func (s *courseService) GetUserCourses(ownerID string) ([]models.CourseList, error) {
+	courses, err := s.repo.GetCoursesByOwner(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.CourseList, len(courses))
+	for i := range courses {
+		result[i] = courses[i].ToCourseList()
+	}
+	return result, nil
+}
+
+// UpdateCourse 更新课程的标题、描述和封面
+func (s *courseService) UpdateCourse(id string, ownerID string, update models.CourseUpdate) (*models.CourseDetail, error) {
+	course, err := s.findOwnedCourse(id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyCourseUpdate(course, update); err != nil {
+		return nil, err
+	}
+	course.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(course); err != nil {
+		return nil, err
+	}
+
+	return s.toDetail(course, ownerID, false)
+}
+
+// SetLessons 按顺序替换课程的所有章节。已公开的课程只能引用已公开的内容
+func (s *courseService) SetLessons(id string, ownerID string, lessons []models.CourseLesson) (*models.CourseDetail, error) {
+	course, err := s.findOwnedCourse(id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	lessons, err = s.validateLessons(ownerID, lessons, isCoursePublic(course))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceLessons(course.ID, lessons); err != nil {
+		return nil, err
+	}
+	course.Lessons = lessons
+
+	course.UpdatedAt = time.Now()
+	if err := s.repo.Update(course); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Course lessons updated",
+		zap.String("courseID", course.ID),
+		zap.String("ownerID", ownerID),
+		zap.Int("lessons", len(lessons)))
+
+	return s.toDetail(course, ownerID, false)
+}
+
+// PublishCourse 公开课程，所有章节引用的文档必须已公开，媒体必须已处理完成
+func (s *courseService) PublishCourse(id string, ownerID string) (*models.CourseDetail, error) {
+	course, err := s.findOwnedCourse(id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.validateLessons(ownerID, course.Lessons, true); err != nil {
+		return nil, err
+	}
+
+	isPublic := true
+	course.IsPublic = &isPublic
+	course.UpdatedAt = time.Now()
+	if err := s.repo.Update(course); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Course published", zap.String("courseID", course.ID), zap.String("ownerID", ownerID))
+
+	return s.toDetail(course, ownerID, false)
+}
+
+// UnpublishCourse 取消公开课程
+func (s *courseService) UnpublishCourse(id string, ownerID string) (*models.CourseDetail, error) {
+	course, err := s.findOwnedCourse(id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	isPublic := false
+	course.IsPublic = &isPublic
+	course.UpdatedAt = time.Now()
+	if err := s.repo.Update(course); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Course unpublished", zap.String("courseID", course.ID), zap.String("ownerID", ownerID))
+
+	return s.toDetail(course, ownerID, false)
+}
+
+// DeleteCourse 删除课程及其章节
+func (s *courseService) DeleteCourse(id string, ownerID string) error {
+	course, err := s.findOwnedCourse(id, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(course.ID); err != nil {
+		return err
+	}
+
+	logger.Info("Course deleted", zap.String("courseID", course.ID), zap.String("ownerID", ownerID))
+	return nil
+}
+
+// GetPublishedCourses 分页获取公开的课程
+func (s *courseService) GetPublishedCourses(page, limit int) ([]models.CourseList, int64, error) {
+	// 设置默认值
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20 // 限制最大为100，防止请求过大
+	}
+
+	courses, err := s.repo.GetPublishedCourses(page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.CountPublishedCourses()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]models.CourseList, len(courses))
+	for i := range courses {
+		result[i] = courses[i].ToCourseList()
+	}
+	return result, total, nil
+}
+
+// GetPublicCourse 获取公开课程详情，未公开的课程视为不存在
+func (s *courseService) GetPublicCourse(id string) (*models.CourseDetail, error) {
+	return s.GetCourse(id, "")
+}
+
+// findOwnedCourse 查找课程并检查所有权。未公开的课程对其他用户不可见，返回ErrCourseNotFound，
+// 公开课程返回ErrPermissionDenied
+func (s *courseService) findOwnedCourse(id string, ownerID string) (*models.Course, error) {
+	course, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if course == nil {
+		return nil, ErrCourseNotFound
+	}
+	if course.OwnerID != ownerID {
+		logger.Warn("User attempted to modify a course they do not own",
+			zap.String("courseID", id),
+			zap.String("courseOwnerID", course.OwnerID),
+			zap.String("requestUserID", ownerID))
+		if !isCoursePublic(course) {
+			return nil, ErrCourseNotFound
+		}
+		return nil, ErrPermissionDenied
+	}
+	return course, nil
+}
+
+// validateLessons 检查章节引用的内容是否存在并且对所有者可见，返回按顺序重新编号的章节。
+// public为true时，文档必须已公开，媒体必须已处理完成
+func (s *courseService) validateLessons(ownerID string, lessons []models.CourseLesson, public bool) ([]models.CourseLesson, error) {
+	if len(lessons) > maxCourseLessons {
+		return nil, ErrInvalidCourse
+	}
+
+	docs, media, err := s.loadLessonItems(lessons)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.CourseLesson, len(lessons))
+	seen := make(map[string]bool, len(lessons))
+	for i, lesson := range lessons {
+		lesson.ItemID = strings.TrimSpace(lesson.ItemID)
+		itemErr := &CourseItemError{Index: i, Type: string(lesson.ItemType), ItemID: lesson.ItemID}
+
+		if !lesson.ItemType.IsValid() {
+			itemErr.Reason = "type must be one of document, video or audio"
+			return nil, itemErr
+		}
+		if lesson.ItemID == "" {
+			itemErr.Reason = "itemId is required"
+			return nil, itemErr
+		}
+		key := string(lesson.ItemType) + ":" + lesson.ItemID
+		if seen[key] {
+			itemErr.Reason = "item is already in the course"
+			return nil, itemErr
+		}
+		seen[key] = true
+
+		if lesson.ItemType == models.LessonTypeDocument {
+			doc := docs[lesson.ItemID]
+			visible, err := canViewDoc(s.sharing, doc, ownerID)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case !visible:
+				itemErr.Reason = "document not found"
+				return nil, itemErr
+			case public && !isDocPublic(doc):
+				itemErr.Reason = "document is not published"
+				return nil, itemErr
+			}
+		} else {
+			item := media[lesson.ItemID]
+			switch {
+			case item == nil:
+				itemErr.Reason = "media not found"
+				return nil, itemErr
+			case string(item.MediaType) != string(lesson.ItemType):
+				itemErr.Reason = "media is not a " + string(lesson.ItemType)
+				return nil, itemErr
+			case item.Status == models.MediaStatusError:
+				itemErr.Reason = "media failed to process"
+				return nil, itemErr
+			case public && item.Status != models.MediaStatusReady:
+				itemErr.Reason = "media is not ready"
+				return nil, itemErr
+			}
+		}
+
+		result[i] = models.CourseLesson{
+			Position: i,
+			ItemType: lesson.ItemType,
+			ItemID:   lesson.ItemID,
+			Title:    strings.TrimSpace(lesson.Title),
+		}
+	}
+
+	return result, nil
+}
+
+// toDetail 解析章节引用的内容并生成课程详情。publicOnly为true时只保留已公开的文档和已就绪的媒体，
+// 否则保留所有章节，并通过Available标记userID无法查看的内容
+func (s *courseService) toDetail(course *models.Course, userID string, publicOnly bool) (*models.CourseDetail, error) {
+	docs, media, err := s.loadLessonItems(course.Lessons)
+	if err != nil {
+		return nil, err
+	}
+
+	lessons := make([]models.LessonSummary, 0, len(course.Lessons))
+	for _, lesson := range course.Lessons {
+		var summary models.LessonSummary
+		if lesson.ItemType == models.LessonTypeDocument {
+			doc := docs[lesson.ItemID]
+			visible := isDocPublic(doc)
+			if !visible && !publicOnly {
+				if visible, err = canViewDoc(s.sharing, doc, userID); err != nil {
+					return nil, err
+				}
+			}
+			if !visible {
+				doc = nil
+			}
+			summary = models.LessonSummaryFromDocument(lesson, doc)
+		} else {
+			item := media[lesson.ItemID]
+			if item != nil && (item.Status != models.MediaStatusReady || string(item.MediaType) != string(lesson.ItemType)) {
+				item = nil
+			}
+			summary = models.LessonSummaryFromMedia(lesson, item)
+		}
+
+		if publicOnly && !summary.Available {
+			continue
+		}
+		lessons = append(lessons, summary)
+	}
+
+	detail := course.ToCourseDetail(lessons)
+	return &detail, nil
+}
+
+// loadLessonItems 批量加载章节引用的文档和媒体，按ID索引
+func (s *courseService) loadLessonItems(lessons []models.CourseLesson) (map[string]*models.Document, map[string]*models.Media, error) {
+	var docIDs, mediaIDs []string
+	for _, lesson := range lessons {
+		id := strings.TrimSpace(lesson.ItemID)
+		if id == "" {
+			continue
+		}
+		if lesson.ItemType == models.LessonTypeDocument {
+			docIDs = append(docIDs, id)
+		} else {
+			mediaIDs = append(mediaIDs, id)
+		}
+	}
+
+	docList, err := s.docs.FindByIDs(docIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	docs := make(map[string]*models.Document, len(docList))
+	for i := range docList {
+		docs[docList[i].ID] = &docList[i]
+	}
+
+	mediaList, err := s.media.GetMediaByIDs(mediaIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	media := make(map[string]*models.Media, len(mediaList))
+	for i := range mediaList {
+		media[mediaList[i].ID] = &mediaList[i]
+	}
+
+	return docs, media, nil
+}
+
+// applyCourseUpdate 将更新内容应用到课程，标题不能为空
+func applyCourseUpdate(course *models.Course, update models.CourseUpdate) error {
+	if update.Title != nil {
+		title := strings.TrimSpace(*update.Title)
+		if title == "" || utf8.RuneCountInString(title) > maxCourseTitleLength {
+			return ErrInvalidCourse
+		}
+		course.Title = title
+	}
+	if update.Description != nil {
+		course.Description = strings.TrimSpace(*update.Description)
+	}
+	if update.CoverImage != nil {
+		if update.CoverImage.URL == "" {
+			course.CoverImage = nil
+		} else {
+			course.CoverImage = update.CoverImage
+		}
+	}
+	return nil
+}
+
+// isCoursePublic 判断课程是否已公开
+func isCoursePublic(course *models.Course) bool {
+	return course.IsPublic != nil && *course.IsPublic
+}
@@ -3,9 +3,13 @@ package service
 import (
 	"betalyr-learning-server/internal/models"
 	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/search"
+	"betalyr-learning-server/internal/pkg/slug"
 	"betalyr-learning-server/internal/repository"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,44 +18,187 @@ import (
 
 // DocumentService 定义文档服务接口
 type DocumentService interface {
-	FindDoc(id string) (bool, error)
-	GetDoc(id string) (*models.Document, error)
+	FindDoc(id string, userID string) (bool, error)
+	GetDoc(id string, userID string) (*models.Document, error)
+	GetPublicDoc(id string) (*models.Document, error)
+	GetPublicDocBySlug(slug string) (*models.Document, string, error)
+	SetSlug(id string, ownerID string, slug string) (*models.Document, error)
 	CreateEmptyDoc(ownerID string) (*models.Document, error)
+	CreateChildDoc(parentID string, ownerID string) (*models.Document, error)
+	GetDocTree(ownerID string) ([]*models.DocumentTreeNode, error)
+	MoveDoc(id string, ownerID string, parentID *string, position int) (*models.Document, error)
+	ReorderDocs(ownerID string, parentID *string, ids []string) error
 	GetUserDocs(userID string) ([]models.DocumentList, error)
-	UpdateDoc(id string, ownerID string, updates map[string]interface{}) (*models.Document, error)
-	PublishDoc(id string, ownerID string) (*models.Document, error)
-	UnpublishDoc(id string, ownerID string) (*models.Document, error)
-	DeleteDoc(id string, ownerID string) (bool, error)
-	GetPublishedDocs(page, limit int) ([]models.PublicDocumentList, int64, error)
+	UpdateDoc(id string, ownerID string, updates map[string]interface{}, expectedVersion int64) (*models.Document, error)
+	PublishDoc(id string, ownerID string, expectedVersion int64, cascade bool) (*models.Document, error)
+	UnpublishDoc(id string, ownerID string, expectedVersion int64) (*models.Document, error)
+	DeleteDoc(id string, ownerID string, expectedVersion int64) (bool, error)
+	GetPublishedDocs(page, limit int, tag string, sort models.PublicListSort) ([]models.PublicDocumentList, int64, error)
+	GetPublicTags(limit int) ([]models.TagCount, error)
+	SuggestTags(ownerID string, prefix string, limit int) ([]models.TagCount, error)
+	SearchPublishedDocs(q, tag, ownerID string, page, limit int) ([]models.DocumentSearchResult, int64, error)
+	SearchUserDocs(userID, q, tag string, page, limit int) ([]models.DocumentSearchResult, int64, error)
 }
 
 // documentService 文档服务实现
 type documentService struct {
-	repo repository.DocumentRepository
+	repo       repository.DocumentRepository
+	revisions  RevisionService
+	sharing    SharingService
+	engagement repository.EngagementRepository
 }
 
 // NewDocumentService 创建新的文档服务实例
-func NewDocumentService(repo repository.DocumentRepository) DocumentService {
+func NewDocumentService(repo repository.DocumentRepository, revisions RevisionService, sharing SharingService, engagement repository.EngagementRepository) DocumentService {
 	return &documentService{
-		repo: repo,
+		repo:       repo,
+		revisions:  revisions,
+		sharing:    sharing,
+		engagement: engagement,
 	}
 }
 
-// FindDoc 检查文档是否存在
-func (s *documentService) FindDoc(id string) (bool, error) {
-	return s.repo.CheckDocExists(id)
+// FindDoc 检查文档是否存在，用户无权查看的文档视为不存在
+func (s *documentService) FindDoc(id string, userID string) (bool, error) {
+	doc, err := s.GetDoc(id, userID)
+	if err != nil {
+		return false, err
+	}
+	return doc != nil, nil
+}
+
+// GetDoc 获取文档详情。公开文档所有人可见，私有文档只有所有者和协作者可见，
+// 无权查看时返回nil，与文档不存在的情况一致
+func (s *documentService) GetDoc(id string, userID string) (*models.Document, error) {
+	doc, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, nil
+	}
+
+	if doc.IsPublic != nil && *doc.IsPublic {
+		return doc, nil
+	}
+
+	role, err := s.sharing.GetRole(doc, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !role.AtLeast(models.DocumentRoleViewer) {
+		logger.Warn("User attempted to read a private document",
+			zap.String("documentID", id),
+			zap.String("requestUserID", userID))
+		return nil, nil
+	}
+
+	return doc, nil
+}
+
+// GetPublicDoc 获取公开文档详情，非公开文档返回nil
+func (s *documentService) GetPublicDoc(id string) (*models.Document, error) {
+	return s.GetDoc(id, "")
 }
 
-// GetDoc 获取文档详情
-func (s *documentService) GetDoc(id string) (*models.Document, error) {
-	return s.repo.FindByID(id)
+// GetPublicDocBySlug 根据短名称获取公开文档。短名称是文档以前使用过的短名称时，
+// 返回nil和文档当前的短名称，由调用方重定向；文档不存在或非公开时都返回空
+func (s *documentService) GetPublicDocBySlug(value string) (*models.Document, string, error) {
+	doc, err := s.repo.FindBySlug(value)
+	if err != nil {
+		return nil, "", err
+	}
+	if doc != nil {
+		if !isDocPublic(doc) {
+			return nil, "", nil
+		}
+		return doc, "", nil
+	}
+
+	redirect, err := s.repo.FindSlugRedirect(value)
+	if err != nil || redirect == nil {
+		return nil, "", err
+	}
+	doc, err = s.repo.FindByID(redirect.DocumentID)
+	if err != nil {
+		return nil, "", err
+	}
+	if doc == nil || !isDocPublic(doc) || doc.Slug == nil {
+		return nil, "", nil
+	}
+	return nil, *doc.Slug, nil
 }
 
-// CreateEmptyDoc 创建空文档
+// SetSlug 设置文档的短名称，只有所有者可以设置。slug为空时恢复为根据标题自动生成，
+// 否则先规范化为小写字母、数字和连字符，已被其他文档使用时返回ErrSlugTaken
+func (s *documentService) SetSlug(id string, ownerID string, value string) (*models.Document, error) {
+	doc, err := s.sharing.Authorize(id, ownerID, models.DocumentRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(value) == "" {
+		doc.SlugCustom = false
+		base := docSlugBase(doc)
+		if doc.Slug != nil && slug.HasBase(*doc.Slug, base) {
+			// 当前短名称与标题一致时保留
+			value = *doc.Slug
+		} else if value, err = uniqueDocSlug(s.repo, doc.ID, base); err != nil {
+			return nil, err
+		}
+	} else {
+		doc.SlugCustom = true
+		value = slug.Make(value)
+		if !slug.Valid(value) {
+			return nil, ErrInvalidSlug
+		}
+		taken, err := s.repo.IsSlugTaken(value, doc.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrSlugTaken
+		}
+	}
+
+	if err := s.repo.SetSlug(doc.ID, value, doc.SlugCustom); err != nil {
+		return nil, err
+	}
+	doc.Slug = &value
+
+	logger.Info("Document slug updated",
+		zap.String("documentID", id),
+		zap.String("slug", value),
+		zap.Bool("custom", doc.SlugCustom))
+
+	return doc, nil
+}
+
+// CreateEmptyDoc 创建空文档，追加到顶层文档末尾
 func (s *documentService) CreateEmptyDoc(ownerID string) (*models.Document, error) {
+	return s.createEmptyDoc(ownerID, nil)
+}
+
+// CreateChildDoc 在父文档下创建空的子文档，追加到子文档末尾，只有父文档的所有者可以创建
+func (s *documentService) CreateChildDoc(parentID string, ownerID string) (*models.Document, error) {
+	parent, err := s.sharing.Authorize(parentID, ownerID, models.DocumentRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	return s.createEmptyDoc(parent.OwnerID, &parent.ID)
+}
+
+// createEmptyDoc 创建空文档，parentID为空时创建顶层文档
+func (s *documentService) createEmptyDoc(ownerID string, parentID *string) (*models.Document, error) {
 	// 生成唯一ID
 	id := uuid.New().String()
 
+	// 排在同级文档的最后
+	position, err := s.repo.NextPosition(ownerID, parentID)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建默认文档
 	now := time.Now()
 	isPublic := false
@@ -66,10 +213,14 @@ func (s *documentService) CreateEmptyDoc(ownerID string) (*models.Document, erro
 		CoverImage: nil, // 确保明确设置为nil
 		EditorJSON: nil, // 确保明确设置为nil
 		IsPublic:   &isPublic,
+		Tags:       models.StringList{},
+		ManualTags: models.StringList{},
+		ParentID:   parentID,
+		Position:   position,
 	}
 
 	// 保存文档
-	err := s.repo.Create(doc)
+	err = s.repo.Create(doc)
 	if err != nil {
 		return nil, err
 	}
@@ -94,8 +245,8 @@ func (s *documentService) GetUserDocs(userID string) ([]models.DocumentList, err
 	return result, nil
 }
 
-// UpdateDoc 更新文档
-func (s *documentService) UpdateDoc(id string, ownerID string, updates map[string]interface{}) (*models.Document, error) {
+// UpdateDoc 更新文档，expectedVersion大于0时要求与文档当前版本一致
+func (s *documentService) UpdateDoc(id string, ownerID string, updates map[string]interface{}, expectedVersion int64) (*models.Document, error) {
 	// 获取现有文档
 	doc, err := s.repo.FindByID(id)
 	if err != nil {
@@ -105,13 +256,23 @@ func (s *documentService) UpdateDoc(id string, ownerID string, updates map[strin
 		return nil, nil // 文档不存在
 	}
 
-	// 验证文档所有者
-	if doc.OwnerID != ownerID {
-		logger.Warn("User attempted to update a document that is not their own",
-			zap.String("documentID", id),
-			zap.String("docOwnerID", doc.OwnerID),
-			zap.String("requestUserID", ownerID))
-		return nil, nil // 不是文档所有者，返回nil表示无权更新
+	// 验证用户角色
+	allowed, err := s.hasRole(doc, ownerID, models.DocumentRoleEditor, "update")
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, nil // 角色不足，返回nil表示无权更新
+	}
+
+	// 检查客户端版本
+	if err := checkVersion(doc, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	// 修订历史上线前创建的文档先保存原始内容，失败不影响本次更新
+	if err := s.revisions.SnapshotBaseline(doc); err != nil {
+		logger.Error("Failed to save original document revision", zap.String("documentID", id), zap.Error(err))
 	}
 
 	// 记录更新前的文档状态
@@ -183,6 +344,26 @@ func (s *documentService) UpdateDoc(id string, ownerID string, updates map[strin
 		}
 	}
 
+	// 处理tags字段，手动设置的标签会与内容中提取的标签合并
+	if tags, exists := updates["tags"]; exists {
+		if tags == nil {
+			doc.ManualTags = models.StringList{}
+		} else if tagList, ok := tags.([]interface{}); ok {
+			manualTags := make([]string, 0, len(tagList))
+			for _, tag := range tagList {
+				if tagStr, ok := tag.(string); ok {
+					manualTags = append(manualTags, tagStr)
+				}
+			}
+			doc.ManualTags = models.NormalizeTags(manualTags)
+		} else {
+			logger.Warn("Unexpected type for tags",
+				zap.String("documentID", id),
+				zap.String("type", fmt.Sprintf("%T", tags)))
+		}
+	}
+	doc.RefreshIndex()
+
 	// 更新时间
 	doc.UpdatedAt = time.Now()
 
@@ -194,14 +375,22 @@ func (s *documentService) UpdateDoc(id string, ownerID string, updates map[strin
 	err = s.repo.Update(doc)
 	if err != nil {
 		logger.Error("Failed to save update", zap.String("documentID", id), zap.Error(err))
-		return nil, err
+		return nil, wrapVersionConflict(s.repo, id, err)
 	}
 
+	// 保存修订版本快照，失败不影响本次更新
+	if err := s.revisions.Snapshot(doc, ownerID, ""); err != nil {
+		logger.Error("Failed to save document revision", zap.String("documentID", id), zap.Error(err))
+	}
+
+	// 公开文档的标题变化后更新短名称
+	syncDocSlug(s.repo, doc)
+
 	return doc, nil
 }
 
-// PublishDoc 将文档设为公开
-func (s *documentService) PublishDoc(id string, ownerID string) (*models.Document, error) {
+// PublishDoc 将文档设为公开，cascade为true时同时公开所有子孙文档
+func (s *documentService) PublishDoc(id string, ownerID string, expectedVersion int64, cascade bool) (*models.Document, error) {
 	// 获取现有文档
 	doc, err := s.repo.FindByID(id)
 	if err != nil {
@@ -211,13 +400,18 @@ func (s *documentService) PublishDoc(id string, ownerID string) (*models.Documen
 		return nil, nil // 文档不存在
 	}
 
-	// 验证文档所有者
-	if doc.OwnerID != ownerID {
-		logger.Warn("User attempted to publish a document that is not their own",
-			zap.String("documentID", id),
-			zap.String("docOwnerID", doc.OwnerID),
-			zap.String("requestUserID", ownerID))
-		return nil, nil // 不是文档所有者，返回nil表示无权更新
+	// 验证用户角色
+	allowed, err := s.hasRole(doc, ownerID, models.DocumentRoleOwner, "publish")
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, nil // 角色不足，返回nil表示无权更新
+	}
+
+	// 检查客户端版本
+	if err := checkVersion(doc, expectedVersion); err != nil {
+		return nil, err
 	}
 
 	// 设置为公开
@@ -228,14 +422,20 @@ func (s *documentService) PublishDoc(id string, ownerID string) (*models.Documen
 	// 保存更新
 	err = s.repo.Update(doc)
 	if err != nil {
-		return nil, err
+		return nil, wrapVersionConflict(s.repo, id, err)
+	}
+
+	syncDocSlug(s.repo, doc)
+	if cascade {
+		s.setDescendantsPublic(id, true)
+		s.syncDescendantSlugs(id)
 	}
 
 	return doc, nil
 }
 
-// UnpublishDoc 将文档设为非公开
-func (s *documentService) UnpublishDoc(id string, ownerID string) (*models.Document, error) {
+// UnpublishDoc 将文档及其所有子孙文档设为非公开
+func (s *documentService) UnpublishDoc(id string, ownerID string, expectedVersion int64) (*models.Document, error) {
 	doc, err := s.repo.FindByID(id)
 	if err != nil {
 		return nil, err
@@ -244,13 +444,18 @@ func (s *documentService) UnpublishDoc(id string, ownerID string) (*models.Docum
 		return nil, nil // 文档不存在
 	}
 
-	// 验证文档所有者
-	if doc.OwnerID != ownerID {
-		logger.Warn("User attempted to unpublish a document that is not their own",
-			zap.String("documentID", id),
-			zap.String("docOwnerID", doc.OwnerID),
-			zap.String("requestUserID", ownerID))
-		return nil, nil // 不是文档所有者，返回nil表示无权更新
+	// 验证用户角色
+	allowed, err := s.hasRole(doc, ownerID, models.DocumentRoleOwner, "unpublish")
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, nil // 角色不足，返回nil表示无权更新
+	}
+
+	// 检查客户端版本
+	if err := checkVersion(doc, expectedVersion); err != nil {
+		return nil, err
 	}
 
 	// 设置为非公开
@@ -261,36 +466,186 @@ func (s *documentService) UnpublishDoc(id string, ownerID string) (*models.Docum
 	// 保存更新
 	err = s.repo.Update(doc)
 	if err != nil {
-		return nil, err
+		return nil, wrapVersionConflict(s.repo, id, err)
 	}
 
+	// 子孙文档随父文档一起取消公开
+	s.setDescendantsPublic(id, false)
+
 	return doc, nil
 }
 
-// DeleteDoc 删除文档
-func (s *documentService) DeleteDoc(id string, ownerID string) (bool, error) {
+// setDescendantsPublic 设置子孙文档的公开状态，失败只记录日志，不影响父文档的操作结果
+func (s *documentService) setDescendantsPublic(id string, isPublic bool) {
+	count, err := s.repo.SetDescendantsPublic(id, isPublic)
+	if err != nil {
+		logger.Error("Failed to update descendant documents",
+			zap.String("documentID", id),
+			zap.Bool("isPublic", isPublic),
+			zap.Error(err))
+		return
+	}
+	if count > 0 {
+		logger.Info("Updated descendant documents",
+			zap.String("documentID", id),
+			zap.Bool("isPublic", isPublic),
+			zap.Int64("count", count))
+	}
+}
+
+// syncDescendantSlugs 为随父文档一起公开的子孙文档生成短名称
+func (s *documentService) syncDescendantSlugs(id string) {
+	ids, err := s.repo.GetSubtreeIDs(id)
+	if err != nil {
+		logger.Error("Failed to get descendant documents", zap.String("documentID", id), zap.Error(err))
+		return
+	}
+	docs, err := s.repo.FindByIDs(ids)
+	if err != nil {
+		logger.Error("Failed to get descendant documents", zap.String("documentID", id), zap.Error(err))
+		return
+	}
+	for i := range docs {
+		if docs[i].ID != id {
+			syncDocSlug(s.repo, &docs[i])
+		}
+	}
+}
+
+// DeleteDoc 将文档及其所有子孙文档移入回收站
+func (s *documentService) DeleteDoc(id string, ownerID string, expectedVersion int64) (bool, error) {
 	// 获取现有文档
 	doc, err := s.repo.FindByID(id)
 	if err != nil {
 		return false, err
 	}
 
-	// 如果文档不存在或用户ID不匹配，拒绝删除
-	if doc == nil || doc.OwnerID != ownerID {
+	if doc == nil {
 		return false, nil
 	}
 
-	// 删除文档
-	err = s.repo.Delete(id)
+	// 只有所有者可以删除文档
+	allowed, err := s.hasRole(doc, ownerID, models.DocumentRoleOwner, "delete")
 	if err != nil {
 		return false, err
 	}
+	if !allowed {
+		return false, nil
+	}
+
+	// 检查客户端版本
+	if err := checkVersion(doc, expectedVersion); err != nil {
+		return false, err
+	}
+
+	// 移入回收站，修订版本和分享设置保留到永久删除时再清理，以便恢复
+	err = s.repo.Delete(id, expectedVersion)
+	if err != nil {
+		return false, wrapVersionConflict(s.repo, id, err)
+	}
+
+	logger.Info("Document moved to trash", zap.String("documentID", id), zap.String("userID", ownerID))
 
 	return true, nil
 }
 
-// GetPublishedDocs 获取所有公开的文档
-func (s *documentService) GetPublishedDocs(page, limit int) ([]models.PublicDocumentList, int64, error) {
+// GetDocTree 获取用户的文档树，同级文档按排序位置排序
+func (s *documentService) GetDocTree(ownerID string) ([]*models.DocumentTreeNode, error) {
+	docs, err := s.repo.GetTree(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return models.BuildDocumentTree(docs), nil
+}
+
+// MoveDoc 将文档（连同子孙文档）移动到新的父文档下的position位置，parentID为空时移动到顶层，
+// position小于0或超出范围时追加到末尾。文档和新的父文档都必须属于当前用户
+func (s *documentService) MoveDoc(id string, ownerID string, parentID *string, position int) (*models.Document, error) {
+	doc, err := s.sharing.Authorize(id, ownerID, models.DocumentRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID != nil {
+		if *parentID == id {
+			return nil, ErrInvalidMove
+		}
+		if _, err := s.sharing.Authorize(*parentID, ownerID, models.DocumentRoleOwner); err != nil {
+			return nil, err
+		}
+
+		// 不能移动到自己的子孙文档下
+		subtree, err := s.repo.GetSubtreeIDs(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, childID := range subtree {
+			if childID == *parentID {
+				return nil, ErrInvalidMove
+			}
+		}
+	}
+
+	siblings, err := s.repo.GetChildren(doc.OwnerID, parentID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(siblings)+1)
+	for _, sibling := range siblings {
+		if sibling.ID != id {
+			ids = append(ids, sibling.ID)
+		}
+	}
+	if position < 0 || position > len(ids) {
+		position = len(ids)
+	}
+	ids = append(ids[:position], append([]string{id}, ids[position:]...)...)
+
+	if err := s.repo.Move(id, parentID, ids); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Document moved",
+		zap.String("documentID", id),
+		zap.Stringp("parentID", parentID),
+		zap.Int("position", position))
+
+	doc.ParentID = parentID
+	doc.Position = position
+	return doc, nil
+}
+
+// ReorderDocs 调整同一父文档下子文档的顺序，ids必须恰好包含该父文档下的所有子文档
+func (s *documentService) ReorderDocs(ownerID string, parentID *string, ids []string) error {
+	if parentID != nil {
+		if _, err := s.sharing.Authorize(*parentID, ownerID, models.DocumentRoleOwner); err != nil {
+			return err
+		}
+	}
+
+	children, err := s.repo.GetChildren(ownerID, parentID)
+	if err != nil {
+		return err
+	}
+	if len(children) != len(ids) {
+		return ErrInvalidOrder
+	}
+	pending := make(map[string]bool, len(children))
+	for _, child := range children {
+		pending[child.ID] = true
+	}
+	for _, id := range ids {
+		if !pending[id] {
+			return ErrInvalidOrder
+		}
+		delete(pending, id)
+	}
+
+	return s.repo.UpdatePositions(ids)
+}
+
+// GetPublishedDocs 获取所有公开的文档及其点赞、收藏和浏览数量，tag不为空时只返回带有该标签的文档
+func (s *documentService) GetPublishedDocs(page, limit int, tag string, sort models.PublicListSort) ([]models.PublicDocumentList, int64, error) {
 	// 设置默认值
 	if page < 1 {
 		page = 1
@@ -299,14 +654,33 @@ func (s *documentService) GetPublishedDocs(page, limit int) ([]models.PublicDocu
 		limit = 20 // 限制最大为100，防止请求过大
 	}
 
+	// 规范化标签，与存储格式保持一致
+	if tag != "" {
+		normalized := models.NormalizeTags([]string{tag})
+		if len(normalized) == 0 {
+			return []models.PublicDocumentList{}, 0, nil
+		}
+		tag = normalized[0]
+	}
+
 	// 获取公开文档
-	docs, err := s.repo.GetPublishedDocs(page, limit)
+	docs, err := s.repo.GetPublishedDocs(page, limit, tag, sort)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// 获取总数
-	totalCount, err := s.repo.CountPublishedDocs()
+	totalCount, err := s.repo.CountPublishedDocs(tag)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 获取互动统计
+	ids := make([]string, len(docs))
+	for i := range docs {
+		ids[i] = docs[i].ID
+	}
+	stats, err := s.engagement.GetStats(models.EngagementItemDocument, ids)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -315,7 +689,201 @@ func (s *documentService) GetPublishedDocs(page, limit int) ([]models.PublicDocu
 	result := make([]models.PublicDocumentList, len(docs))
 	for i, doc := range docs {
 		result[i] = doc.ToPublicDocumentList()
+		result[i].EngagementCounts = stats[doc.ID].Counts()
 	}
 
 	return result, totalCount, nil
 }
+
+// GetPublicTags 获取公开文档的标签及文档数量
+func (s *documentService) GetPublicTags(limit int) ([]models.TagCount, error) {
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	return s.repo.GetPublicTagCounts(limit)
+}
+
+// SuggestTags 根据前缀补全用户自己使用过的标签
+func (s *documentService) SuggestTags(ownerID string, prefix string, limit int) ([]models.TagCount, error) {
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+	prefix = strings.ToLower(strings.TrimLeft(strings.TrimSpace(prefix), "#"))
+	return s.repo.GetOwnerTags(ownerID, prefix, limit)
+}
+
+// SearchPublishedDocs 全文搜索公开文档，可按标签和作者过滤
+func (s *documentService) SearchPublishedDocs(q, tag, ownerID string, page, limit int) ([]models.DocumentSearchResult, int64, error) {
+	return s.searchDocs(models.DocumentSearchQuery{
+		Query:      q,
+		Tag:        tag,
+		OwnerID:    ownerID,
+		PublicOnly: true,
+		Page:       page,
+		Limit:      limit,
+	})
+}
+
+// SearchUserDocs 全文搜索当前用户自己的文档（包括未公开的）
+func (s *documentService) SearchUserDocs(userID, q, tag string, page, limit int) ([]models.DocumentSearchResult, int64, error) {
+	return s.searchDocs(models.DocumentSearchQuery{
+		Query:   q,
+		Tag:     tag,
+		OwnerID: userID,
+		Page:    page,
+		Limit:   limit,
+	})
+}
+
+// searchDocs 规范化搜索条件并执行搜索
+func (s *documentService) searchDocs(query models.DocumentSearchQuery) ([]models.DocumentSearchResult, int64, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 || query.Limit > 100 {
+		query.Limit = 20
+	}
+
+	query.Query = strings.TrimSpace(search.Segment(query.Query))
+	if query.Query == "" {
+		return []models.DocumentSearchResult{}, 0, nil
+	}
+
+	if query.Tag != "" {
+		normalized := models.NormalizeTags([]string{query.Tag})
+		if len(normalized) == 0 {
+			return []models.DocumentSearchResult{}, 0, nil
+		}
+		query.Tag = normalized[0]
+	}
+
+	results, total, err := s.repo.SearchDocuments(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range results {
+		results[i].Snippet = search.FormatSnippet(results[i].Snippet)
+		if results[i].Tags == nil {
+			results[i].Tags = models.StringList{}
+		}
+	}
+	if results == nil {
+		results = []models.DocumentSearchResult{}
+	}
+
+	return results, total, nil
+}
+
+// hasRole 检查用户对文档的角色是否满足要求，不满足时记录日志
+func (s *documentService) hasRole(doc *models.Document, userID string, required models.DocumentRole, action string) (bool, error) {
+	role, err := s.sharing.GetRole(doc, userID)
+	if err != nil {
+		return false, err
+	}
+	if !role.AtLeast(required) {
+		logger.Warn("User attempted to "+action+" a document without the required role",
+			zap.String("documentID", doc.ID),
+			zap.String("docOwnerID", doc.OwnerID),
+			zap.String("requestUserID", userID),
+			zap.String("role", string(role)))
+		return false, nil
+	}
+	return true, nil
+}
+
+// checkVersion 检查客户端提供的版本号是否与文档当前版本一致，expectedVersion为0时不检查
+func checkVersion(doc *models.Document, expectedVersion int64) error {
+	if expectedVersion > 0 && doc.Version != expectedVersion {
+		return &VersionConflictError{CurrentVersion: doc.Version, PreconditionFailed: true}
+	}
+	return nil
+}
+
+// wrapVersionConflict 将仓库层的版本冲突转换为包含当前版本号的错误
+func wrapVersionConflict(repo repository.DocumentRepository, id string, err error) error {
+	if !errors.Is(err, repository.ErrVersionConflict) {
+		return err
+	}
+
+	current, findErr := repo.FindByID(id)
+	if findErr != nil || current == nil {
+		return &VersionConflictError{}
+	}
+	return &VersionConflictError{CurrentVersion: current.Version}
+}
+
+// 生成唯一短名称时尝试的最大数字后缀
+const maxSlugSuffix = 100
+
+// syncDocSlug 为公开文档维护自动生成的短名称：没有短名称时根据标题生成，
+// 标题变化后重新生成，原短名称保留为重定向。手动设置的短名称不会改变，失败只记录日志
+func syncDocSlug(repo repository.DocumentRepository, doc *models.Document) {
+	if !isDocPublic(doc) || doc.SlugCustom {
+		return
+	}
+	base := docSlugBase(doc)
+	if doc.Slug != nil && slug.HasBase(*doc.Slug, base) {
+		return
+	}
+
+	value, err := uniqueDocSlug(repo, doc.ID, base)
+	if err == nil {
+		err = repo.SetSlug(doc.ID, value, false)
+	}
+	if err != nil {
+		logger.Error("Failed to update document slug", zap.String("documentID", doc.ID), zap.Error(err))
+		return
+	}
+	doc.Slug = &value
+}
+
+// BackfillDocSlugs 为短名称功能上线前已公开的文档生成短名称，返回生成了短名称的文档数量。
+// 生成失败的文档只记录日志并跳过，下次启动时重试
+func BackfillDocSlugs(repo repository.DocumentRepository) (int64, error) {
+	var updated int64
+	afterID := ""
+	for {
+		docs, err := repo.FindPublicWithoutSlug(afterID, 100)
+		if err != nil {
+			return updated, err
+		}
+		if len(docs) == 0 {
+			return updated, nil
+		}
+
+		for i := range docs {
+			syncDocSlug(repo, &docs[i])
+			if docs[i].Slug != nil {
+				updated++
+			}
+		}
+		afterID = docs[len(docs)-1].ID
+	}
+}
+
+// docSlugBase 根据标题生成短名称，标题中没有可用字符时使用文档ID的第一段
+func docSlugBase(doc *models.Document) string {
+	if base := slug.Make(doc.Title); base != "" {
+		return base
+	}
+	return "doc-" + strings.SplitN(doc.ID, "-", 2)[0]
+}
+
+// uniqueDocSlug 在base已被其他文档使用时追加数字后缀，返回第一个可用的短名称
+func uniqueDocSlug(repo repository.DocumentRepository, id string, base string) (string, error) {
+	value := base
+	for n := 2; ; n++ {
+		taken, err := repo.IsSlugTaken(value, id)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return value, nil
+		}
+		if n > maxSlugSuffix {
+			return "", ErrSlugTaken
+		}
+		value = slug.WithSuffix(base, n)
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"betalyr-learning-server/internal/config"
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/repository"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 同一观看者在该时间内的重复浏览只计数一次
+const viewDedupWindow = 30 * time.Minute
+
+// EngagementService 定义点赞、收藏和浏览服务接口
+type EngagementService interface {
+	// 获取内容的互动数量以及当前用户是否已点赞、收藏
+	GetState(userID string, itemType models.EngagementItemType, itemID string) (*models.EngagementState, error)
+	// 点赞或取消点赞，重复操作不会报错；虚拟用户只能取消点赞
+	SetLiked(userID string, virtual bool, itemType models.EngagementItemType, itemID string, liked bool) (*models.EngagementState, error)
+	// 收藏或取消收藏，重复操作不会报错；虚拟用户只能取消收藏
+	SetBookmarked(userID string, virtual bool, itemType models.EngagementItemType, itemID string, bookmarked bool) (*models.EngagementState, error)
+	// 分页获取用户的收藏，已删除或不再可见的内容会被跳过
+	GetBookmarks(userID string, itemType models.EngagementItemType, page, limit int) ([]models.BookmarkItem, int64, error)
+	// 记录一次浏览，登录用户按用户ID去重，匿名用户和虚拟用户按IP的HMAC去重，计数时返回true
+	RecordView(itemType models.EngagementItemType, itemID, userID string, virtual bool, clientIP string) (bool, error)
+}
+
+// engagementService 点赞、收藏和浏览服务实现
+type engagementService struct {
+	repo    repository.EngagementRepository
+	docs    repository.DocumentRepository
+	media   repository.MediaRepository
+	sharing SharingService
+	viewKey []byte // 对访客IP做HMAC的密钥
+}
+
+// NewEngagementService 创建新的点赞、收藏和浏览服务实例
+func NewEngagementService(cfg *config.Config, repo repository.EngagementRepository, docs repository.DocumentRepository, media repository.MediaRepository, sharing SharingService) EngagementService {
+	return &engagementService{
+		repo:    repo,
+		docs:    docs,
+		media:   media,
+		sharing: sharing,
+		viewKey: viewHashKey(cfg.Engagement.ViewHashSecret),
+	}
+}
+
+// 未配置密钥时随机生成的HMAC密钥，进程内的所有服务实例共用
+var (
+	randomViewKey     []byte
+	randomViewKeyOnce sync.Once
+)
+
+// viewHashKey 返回浏览去重使用的HMAC密钥，未配置时随机生成，重启后同一访客会重新计数
+func viewHashKey(secret string) []byte {
+	if secret != "" {
+		return []byte(secret)
+	}
+
+	randomViewKeyOnce.Do(func() {
+		logger.Warn("View hash secret is not configured, using a random key")
+		randomViewKey = make([]byte, 32)
+		if _, err := rand.Read(randomViewKey); err != nil {
+			panic("failed to generate view hash key: " + err.Error())
+		}
+	})
+	return randomViewKey
+}
+
+// GetState 获取内容的互动数量以及当前用户是否已点赞、收藏
+func (s *engagementService) GetState(userID string, itemType models.EngagementItemType, itemID string) (*models.EngagementState, error) {
+	if err := s.checkItem(userID, itemType, itemID); err != nil {
+		return nil, err
+	}
+	return s.getState(userID, itemType, itemID)
+}
+
+// SetLiked 点赞或取消点赞。取消点赞不检查内容是否可见，以便撤销对已不可见内容的点赞。
+// 点赞数计入热门排序，虚拟用户点赞时返回ErrLoginRequired
+func (s *engagementService) SetLiked(userID string, virtual bool, itemType models.EngagementItemType, itemID string, liked bool) (*models.EngagementState, error) {
+	if !itemType.IsValid() {
+		return nil, ErrInvalidItemType
+	}
+	if liked && virtual {
+		return nil, ErrLoginRequired
+	}
+
+	var err error
+	if liked {
+		if err := s.checkItem(userID, itemType, itemID); err != nil {
+			return nil, err
+		}
+		_, err = s.repo.AddLike(&models.ItemLike{
+			ID:       uuid.New().String(),
+			UserID:   userID,
+			ItemType: itemType,
+			ItemID:   itemID,
+		})
+	} else {
+		_, err = s.repo.RemoveLike(userID, itemType, itemID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.getState(userID, itemType, itemID)
+}
+
+// SetBookmarked 收藏或取消收藏。取消收藏不检查内容是否可见，以便清理已不可见内容的收藏。
+// 收藏数计入热门排序，虚拟用户收藏时返回ErrLoginRequired
+func (s *engagementService) SetBookmarked(userID string, virtual bool, itemType models.EngagementItemType, itemID string, bookmarked bool) (*models.EngagementState, error) {
+	if !itemType.IsValid() {
+		return nil, ErrInvalidItemType
+	}
+	if bookmarked && virtual {
+		return nil, ErrLoginRequired
+	}
+
+	var err error
+	if bookmarked {
+		if err := s.checkItem(userID, itemType, itemID); err != nil {
+			return nil, err
+		}
+		_, err = s.repo.AddBookmark(&models.ItemBookmark{
+			ID:       uuid.New().String(),
+			UserID:   userID,
+			ItemType: itemType,
+			ItemID:   itemID,
+		})
+	} else {
+		_, err = s.repo.RemoveBookmark(userID, itemType, itemID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.getState(userID, itemType, itemID)
+}
+
+// GetBookmarks 分页获取用户的收藏，按收藏时间降序排序
+func (s *engagementService) GetBookmarks(userID string, itemType models.EngagementItemType, page, limit int) ([]models.BookmarkItem, int64, error) {
+	if itemType != "" && !itemType.IsValid() {
+		return nil, 0, ErrInvalidItemType
+	}
+
+	bookmarks, total, err := s.repo.GetBookmarks(userID, itemType, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var docIDs, mediaIDs []string
+	for _, b := range bookmarks {
+		if b.ItemType == models.EngagementItemDocument {
+			docIDs = append(docIDs, b.ItemID)
+		} else {
+			mediaIDs = append(mediaIDs, b.ItemID)
+		}
+	}
+
+	docList, err := s.docs.FindByIDs(docIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	docs := make(map[string]*models.Document, len(docList))
+	for i := range docList {
+		docs[docList[i].ID] = &docList[i]
+	}
+
+	mediaList, err := s.media.GetMediaByIDs(mediaIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	media := make(map[string]*models.Media, len(mediaList))
+	for i := range mediaList {
+		media[mediaList[i].ID] = &mediaList[i]
+	}
+
+	items := make([]models.BookmarkItem, 0, len(bookmarks))
+	for i := range bookmarks {
+		b := &bookmarks[i]
+		if b.ItemType == models.EngagementItemDocument {
+			doc := docs[b.ItemID]
+			visible, err := canViewDoc(s.sharing, doc, userID)
+			if err != nil {
+				return nil, 0, err
+			}
+			if visible {
+				items = append(items, b.BookmarkItemFromDocument(doc))
+			}
+		} else if item := media[b.ItemID]; item != nil && item.Status == models.MediaStatusReady {
+			items = append(items, b.BookmarkItemFromMedia(item))
+		}
+	}
+
+	return items, total, nil
+}
+
+// RecordView 记录一次浏览。虚拟用户ID由客户端提供，更换ID即可重复计数，因此和匿名用户一样按IP去重
+func (s *engagementService) RecordView(itemType models.EngagementItemType, itemID, userID string, virtual bool, clientIP string) (bool, error) {
+	if err := s.checkItem(userID, itemType, itemID); err != nil {
+		return false, err
+	}
+
+	viewerKey := "user:" + userID
+	if userID == "" || virtual {
+		// 不保存访客的原始IP，使用带密钥的HMAC避免通过穷举IPv4地址还原
+		mac := hmac.New(sha256.New, s.viewKey)
+		mac.Write([]byte(clientIP))
+		viewerKey = "ip:" + hex.EncodeToString(mac.Sum(nil))
+	}
+	return s.repo.RecordView(itemType, itemID, viewerKey, viewDedupWindow)
+}
+
+// checkItem 检查内容是否存在且对用户可见：文档需要可以查看，媒体需要是已就绪的视频或音频
+func (s *engagementService) checkItem(userID string, itemType models.EngagementItemType, itemID string) error {
+	switch itemType {
+	case models.EngagementItemDocument:
+		doc, err := s.docs.FindByID(itemID)
+		if err != nil {
+			return err
+		}
+		visible, err := canViewDoc(s.sharing, doc, userID)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			return ErrDocumentNotFound
+		}
+	case models.EngagementItemMedia:
+		media, err := s.media.GetMediaByID(itemID)
+		if err != nil {
+			return err
+		}
+		if media == nil || media.Status != models.MediaStatusReady ||
+			(media.MediaType != models.MediaTypeVideo && media.MediaType != models.MediaTypeAudio) {
+			return ErrMediaNotFound
+		}
+	default:
+		return ErrInvalidItemType
+	}
+	return nil
+}
+
+// getState 获取内容的互动数量以及用户是否已点赞、收藏
+func (s *engagementService) getState(userID string, itemType models.EngagementItemType, itemID string) (*models.EngagementState, error) {
+	stats, err := s.repo.GetStats(itemType, []string{itemID})
+	if err != nil {
+		return nil, err
+	}
+	state := &models.EngagementState{EngagementCounts: stats[itemID].Counts()}
+
+	if userID != "" {
+		state.Liked, state.Bookmarked, err = s.repo.GetUserState(userID, itemType, itemID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
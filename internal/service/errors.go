@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 服务层通用错误
+var (
+	// ErrDocumentNotFound 文档不存在或当前用户无权访问
+	ErrDocumentNotFound = errors.New("document not found")
+	// ErrRevisionNotFound 修订版本不存在
+	ErrRevisionNotFound = errors.New("revision not found")
+	// ErrPermissionDenied 用户可以访问文档，但角色不足以执行该操作
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrInvalidRole 无效的协作者角色
+	ErrInvalidRole = errors.New("invalid collaborator role")
+	// ErrInvalidCollaborator 不能邀请文档所有者或空用户作为协作者
+	ErrInvalidCollaborator = errors.New("invalid collaborator")
+	// ErrCollaboratorNotFound 协作者不存在
+	ErrCollaboratorNotFound = errors.New("collaborator not found")
+	// ErrShareLinkNotFound 分享链接不存在或已被撤销
+	ErrShareLinkNotFound = errors.New("share link not found")
+	// ErrShareLinkExpired 分享链接已过期
+	ErrShareLinkExpired = errors.New("share link expired")
+	// ErrPasswordRequired 访问分享链接需要密码
+	ErrPasswordRequired = errors.New("share link password required")
+	// ErrInvalidPassword 分享链接密码错误
+	ErrInvalidPassword = errors.New("invalid share link password")
+	// ErrInvalidExpiry 分享链接的过期时间必须晚于当前时间
+	ErrInvalidExpiry = errors.New("invalid share link expiry")
+	// ErrInvalidMove 不能将文档移动到自身或其子孙文档下
+	ErrInvalidMove = errors.New("cannot move a document under itself or its descendants")
+	// ErrInvalidOrder 排序的文档列表必须恰好包含同一父文档下的所有子文档
+	ErrInvalidOrder = errors.New("order must list every sibling document exactly once")
+	// ErrUnsupportedImport 不支持导入的文件格式
+	ErrUnsupportedImport = errors.New("unsupported import file format")
+	// ErrInvalidImport 导入的文件无法解析
+	ErrInvalidImport = errors.New("invalid import file")
+	// ErrImportTooLarge 导入的文件超过大小限制
+	ErrImportTooLarge = errors.New("import file too large")
+	// ErrCourseNotFound 课程不存在或当前用户无权访问
+	ErrCourseNotFound = errors.New("course not found")
+	// ErrInvalidCourse 课程标题为空或章节数量超过限制
+	ErrInvalidCourse = errors.New("invalid course")
+	// ErrInvalidCourseItem 课程章节引用的内容不存在或不可见
+	ErrInvalidCourseItem = errors.New("invalid course lesson")
+	// ErrMediaNotFound 媒体不存在或不是视频、音频
+	ErrMediaNotFound = errors.New("media not found")
+	// ErrInvalidMediaFile 上传的文件无法识别，或其中的流与声明的内容类型不一致
+	ErrInvalidMediaFile = errors.New("invalid media file")
+	// ErrUploadNotFound 上传会话不存在或不属于当前用户
+	ErrUploadNotFound = errors.New("upload session not found")
+	// ErrInvalidUpload 上传的文件名、大小或类型无效
+	ErrInvalidUpload = errors.New("invalid upload")
+	// ErrInvalidUploadPart 分片编号超出范围或分片大小与会话不一致
+	ErrInvalidUploadPart = errors.New("invalid upload part")
+	// ErrChecksumMismatch 分片内容与客户端提供的校验和不一致
+	ErrChecksumMismatch = errors.New("upload part checksum mismatch")
+	// ErrUploadIncomplete 还有分片没有上传
+	ErrUploadIncomplete = errors.New("upload is incomplete")
+	// ErrUploadClosed 上传会话已完成、已取消或已过期
+	ErrUploadClosed = errors.New("upload session is closed")
+	// ErrUploadModeMismatch 经由服务器上传的会话不能获取预签名地址，直传的会话不能经由服务器上传分片
+	ErrUploadModeMismatch = errors.New("operation not supported by this upload session")
+	// ErrInvalidProgress 学习进度的内容类型或位置无效
+	ErrInvalidProgress = errors.New("invalid learning progress")
+	// ErrQuizNotFound 测验不存在或当前用户无权访问
+	ErrQuizNotFound = errors.New("quiz not found")
+	// ErrQuizExists 内容上已经附加了测验
+	ErrQuizExists = errors.New("quiz already exists for this item")
+	// ErrQuizAttemptsExhausted 已用完测验的作答次数
+	ErrQuizAttemptsExhausted = errors.New("no quiz attempts left")
+	// ErrInvalidQuiz 测验的内容无效
+	ErrInvalidQuiz = errors.New("invalid quiz")
+	// ErrCommentNotFound 评论不存在或不属于该文档
+	ErrCommentNotFound = errors.New("comment not found")
+	// ErrInvalidComment 评论内容为空或过长，或对回复执行了只适用于讨论串的操作
+	ErrInvalidComment = errors.New("invalid comment")
+	// ErrInvalidAnchor 评论锚定的块不存在或文本范围超出
+	ErrInvalidAnchor = errors.New("invalid comment anchor")
+	// ErrInvalidItemType 点赞、收藏或浏览的内容类型无效
+	ErrInvalidItemType = errors.New("invalid item type")
+	// ErrLoginRequired 虚拟用户可以随意创建，不能点赞或收藏，以免刷高排行
+	ErrLoginRequired = errors.New("login required")
+	// ErrInvalidSlug 短名称为空或包含无法使用的字符
+	ErrInvalidSlug = errors.New("invalid slug")
+	// ErrSlugTaken 短名称已被其他文档使用
+	ErrSlugTaken = errors.New("slug already taken")
+)
+
+// VersionConflictError 文档版本冲突，包含服务器当前版本号
+type VersionConflictError struct {
+	CurrentVersion int64
+	// PreconditionFailed 为true表示客户端If-Match中的版本已过期，
+	// 为false表示保存时文档被并发修改
+	PreconditionFailed bool
+}
+
+// Error 实现error接口
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("document version conflict, current version is %d", e.CurrentVersion)
+}
+
+// CourseItemError 课程章节引用的内容无效，包含出错章节的位置和原因
+type CourseItemError struct {
+	Index  int
+	Type   string
+	ItemID string
+	Reason string
+}
+
+// Error 实现error接口
+func (e *CourseItemError) Error() string {
+	return fmt.Sprintf("invalid course lesson %d (%s %s): %s", e.Index, e.Type, e.ItemID, e.Reason)
+}
+
+// Unwrap 使errors.Is(err, ErrInvalidCourseItem)成立
+func (e *CourseItemError) Unwrap() error {
+	return ErrInvalidCourseItem
+}
+
+// QuizQuestionError 测验题目无效，包含出错题目的位置和原因，Index为-1表示测验本身无效
+type QuizQuestionError struct {
+	Index  int
+	Reason string
+}
+
+// Error 实现error接口
+func (e *QuizQuestionError) Error() string {
+	if e.Index < 0 {
+		return "invalid quiz: " + e.Reason
+	}
+	return fmt.Sprintf("invalid quiz question %d: %s", e.Index, e.Reason)
+}
+
+// Unwrap 使errors.Is(err, ErrInvalidQuiz)成立
+func (e *QuizQuestionError) Unwrap() error {
+	return ErrInvalidQuiz
+}
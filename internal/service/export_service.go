@@ -0,0 +1,145 @@
+package service
+
+import (
+	"archive/zip"
+	"betalyr-learning-server/internal/config"
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/export"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/repository"
+	"io"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// ExportFile 导出的文件
+type ExportFile struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// ExportService 定义文档导出服务接口
+type ExportService interface {
+	// 导出单个文档，用户需要有查看权限
+	ExportDoc(id string, userID string, format export.Format) (*ExportFile, error)
+	// 将用户自己的所有文档打包为zip写入w
+	ExportUserDocs(userID string, format export.Format, w io.Writer) error
+}
+
+// exportService 文档导出服务实现
+type exportService struct {
+	repo       repository.DocumentRepository
+	documents  DocumentService
+	pdfOptions export.PDFOptions
+}
+
+// NewExportService 创建新的文档导出服务实例
+func NewExportService(cfg *config.Config, repo repository.DocumentRepository, documents DocumentService) ExportService {
+	return &exportService{
+		repo:      repo,
+		documents: documents,
+		pdfOptions: export.PDFOptions{
+			FontPath: cfg.Export.PDFFont,
+		},
+	}
+}
+
+// ExportDoc 导出单个文档
+func (s *exportService) ExportDoc(id string, userID string, format export.Format) (*ExportFile, error) {
+	doc, err := s.documents.GetDoc(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, ErrDocumentNotFound
+	}
+
+	data, err := s.render(doc, format, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExportFile{
+		Name:        export.Filename(doc.Title) + format.Extension(),
+		ContentType: format.ContentType(),
+		Data:        data,
+	}, nil
+}
+
+// ExportUserDocs 将用户的所有文档打包为zip，重名的文件会加上序号
+func (s *exportService) ExportUserDocs(userID string, format export.Format, w io.Writer) error {
+	docs, err := s.repo.GetDocumentsByOwner(userID)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	used := make(map[string]int)
+	// 所有文档共用图片下载预算，避免文档数量放大下载时间
+	images := export.NewImageBudget()
+	for i := range docs {
+		doc := &docs[i]
+		data, err := s.render(doc, format, images)
+		if err != nil {
+			// 单个文档渲染失败不影响其他文档
+			logger.Error("Failed to export document", zap.String("documentID", doc.ID), zap.Error(err))
+			continue
+		}
+
+		name := export.Filename(doc.Title)
+		used[name]++
+		if n := used[name]; n > 1 {
+			name += " (" + strconv.Itoa(n) + ")"
+		}
+
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     name + format.Extension(),
+			Method:   zip.Deflate,
+			Modified: doc.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("Exported user documents",
+		zap.String("userID", userID),
+		zap.String("format", string(format)),
+		zap.Int("count", len(docs)))
+
+	return zw.Close()
+}
+
+// render 按格式渲染文档，images为空时单独限制每个PDF的图片下载
+func (s *exportService) render(doc *models.Document, format export.Format, images *export.ImageBudget) ([]byte, error) {
+	src := export.Document{
+		Title:     doc.Title,
+		Tags:      doc.Tags,
+		UpdatedAt: doc.UpdatedAt,
+	}
+	if doc.IconImage != nil {
+		src.IconURL = doc.IconImage.URL
+	}
+	if doc.CoverImage != nil {
+		src.CoverURL = doc.CoverImage.URL
+	}
+	if doc.EditorJSON != nil {
+		src.Content = *doc.EditorJSON
+	}
+
+	switch format {
+	case export.FormatMarkdown:
+		return export.Markdown(src), nil
+	case export.FormatHTML:
+		return export.HTML(src), nil
+	default:
+		opts := s.pdfOptions
+		opts.Images = images
+		return export.PDF(src, opts)
+	}
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"betalyr-learning-server/internal/config"
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/editorjson"
+	"betalyr-learning-server/internal/pkg/export"
+	"betalyr-learning-server/internal/pkg/feed"
+	"betalyr-learning-server/internal/repository"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 订阅源限制
+const (
+	feedItemLimit     = 50  // 订阅源中的最大条目数
+	feedSummaryLength = 300 // 条目摘要的最大字符数
+)
+
+// FeedService 定义订阅源和站点地图服务接口
+type FeedService interface {
+	// 获取公开文档和视频的订阅源。tag不为空时只包含带有该标签的文档，
+	// author不为空时只包含该用户的文档和视频，fullText为true时条目包含HTML全文
+	GetFeed(tag, author string, fullText bool, selfLink string) (*feed.Feed, error)
+	// 获取站点地图中的公开文档、视频和音频
+	GetSitemap() ([]feed.SitemapURL, error)
+}
+
+// feedService 订阅源和站点地图服务实现
+type feedService struct {
+	site  config.SiteConfig
+	docs  repository.DocumentRepository
+	media repository.MediaRepository
+}
+
+// NewFeedService 创建新的订阅源和站点地图服务实例
+func NewFeedService(cfg *config.Config, docs repository.DocumentRepository, media repository.MediaRepository) FeedService {
+	return &feedService{
+		site:  cfg.Site,
+		docs:  docs,
+		media: media,
+	}
+}
+
+// GetFeed 获取订阅源，文档和视频按更新时间混合排序
+func (s *feedService) GetFeed(tag, author string, fullText bool, selfLink string) (*feed.Feed, error) {
+	tag = strings.TrimSpace(tag)
+	if tag != "" {
+		// 与文档保存时的标签规范化保持一致
+		if tags := models.NormalizeTags([]string{tag}); len(tags) > 0 {
+			tag = tags[0]
+		}
+	}
+
+	docs, err := s.docs.GetFeedDocs(tag, author, feedItemLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]feed.Item, 0, len(docs))
+	for i := range docs {
+		items = append(items, s.documentItem(&docs[i], fullText))
+	}
+
+	// 视频没有标签，按标签过滤时只包含文档
+	if tag == "" {
+		videos, err := s.media.GetFeedVideos(author, feedItemLimit)
+		if err != nil {
+			return nil, err
+		}
+		for i := range videos {
+			items = append(items, s.videoItem(&videos[i]))
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Updated.After(items[j].Updated)
+	})
+	if len(items) > feedItemLimit {
+		items = items[:feedItemLimit]
+	}
+
+	title := s.site.Title
+	switch {
+	case tag != "":
+		title += " - #" + tag
+	case author != "":
+		title += " - " + author
+	}
+
+	result := &feed.Feed{
+		Title:       title,
+		Description: s.site.Description,
+		Link:        strings.TrimRight(s.site.URL, "/"),
+		SelfLink:    selfLink,
+		Author:      s.site.Title,
+		Updated:     time.Now(),
+		Items:       items,
+	}
+	if len(items) > 0 {
+		result.Updated = items[0].Updated
+	}
+	if result.Description == "" {
+		result.Description = title
+	}
+	return result, nil
+}
+
+// GetSitemap 获取站点地图，文档在前，视频和音频在后
+func (s *feedService) GetSitemap() ([]feed.SitemapURL, error) {
+	docs, err := s.docs.GetSitemapDocs(feed.MaxSitemapURLs)
+	if err != nil {
+		return nil, err
+	}
+	media, err := s.media.GetSitemapMedia(feed.MaxSitemapURLs - len(docs))
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]feed.SitemapURL, 0, len(docs)+len(media))
+	for i := range docs {
+		urls = append(urls, feed.SitemapURL{Loc: s.documentLink(&docs[i]), LastMod: docs[i].UpdatedAt})
+	}
+	for i := range media {
+		urls = append(urls, feed.SitemapURL{Loc: s.mediaLink(&media[i]), LastMod: media[i].UpdatedAt})
+	}
+	return urls, nil
+}
+
+// documentItem 将文档转换为订阅源条目
+func (s *feedService) documentItem(doc *models.Document, fullText bool) feed.Item {
+	item := feed.Item{
+		ID:         "urn:uuid:" + doc.ID,
+		Title:      doc.Title,
+		Link:       s.documentLink(doc),
+		Categories: doc.Tags,
+		Published:  doc.CreatedAt,
+		Updated:    doc.UpdatedAt,
+	}
+	if doc.EditorJSON != nil {
+		item.Summary = editorjson.Excerpt(*doc.EditorJSON, feedSummaryLength)
+		if fullText {
+			item.Content = export.HTMLFragment(*doc.EditorJSON)
+		}
+	}
+	return item
+}
+
+// videoItem 将视频转换为订阅源条目，视频文件作为附件
+func (s *feedService) videoItem(video *models.Media) feed.Item {
+	item := feed.Item{
+		ID:        "urn:uuid:" + video.ID,
+		Title:     video.Title,
+		Link:      s.mediaLink(video),
+		Published: video.CreatedAt,
+		Updated:   video.UpdatedAt,
+		Enclosure: &feed.Enclosure{
+			URL:    video.FileURL,
+			Type:   video.ContentType,
+			Length: video.FileSize,
+		},
+	}
+	if video.Description != nil {
+		item.Summary = *video.Description
+	}
+	if video.Category != "" {
+		item.Categories = []string{video.Category}
+	}
+	return item
+}
+
+// documentLink 生成文档页面的地址，有短名称时优先使用短名称
+func (s *feedService) documentLink(doc *models.Document) string {
+	slug := doc.ID
+	if doc.Slug != nil {
+		slug = *doc.Slug
+	}
+	return s.link(strings.ReplaceAll(s.site.DocumentPath, ":slug", url.PathEscape(slug)), doc.ID)
+}
+
+// mediaLink 生成视频或音频页面的地址
+func (s *feedService) mediaLink(media *models.Media) string {
+	path := s.site.VideoPath
+	if media.MediaType == models.MediaTypeAudio {
+		path = s.site.AudioPath
+	}
+	return s.link(path, media.ID)
+}
+
+// link 将路径中的:id替换为id并拼接站点地址
+func (s *feedService) link(path, id string) string {
+	path = strings.ReplaceAll(path, ":id", url.PathEscape(id))
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return strings.TrimRight(s.site.URL, "/") + path
+}
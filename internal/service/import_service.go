@@ -0,0 +1,375 @@
+package service
+
+import (
+	"archive/zip"
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/importer"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/safehttp"
+	"betalyr-learning-server/internal/repository"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// 单个导入文件的最大大小
+	maxImportFileSize = 10 << 20
+	// 单张图片的最大大小
+	maxImportImageSize = 10 << 20
+	// 压缩包中最多导入的文档数
+	maxImportZipDocs = 200
+	// 压缩包解压后的最大总大小，防止压缩炸弹
+	maxImportZipTotalSize = 200 << 20
+	// 一次导入中最多下载的外部图片数，以及下载外部图片的总时间，超出后保留原地址
+	maxImportRemoteImages = 50
+	importRemoteImageTime = time.Minute
+)
+
+// ImportFailure 批量导入中失败的文件
+type ImportFailure struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// ImportService 定义文档导入服务接口
+type ImportService interface {
+	// 导入单个Markdown、HTML或纯文本文件，创建属于用户的新文档
+	ImportFile(userID string, filename string, data []byte) (*models.Document, error)
+	// 导入zip压缩包中的所有文档，压缩包中的图片可以被文档以相对路径引用
+	ImportZip(userID string, data []byte) ([]models.DocumentList, []ImportFailure, error)
+}
+
+// importService 文档导入服务实现
+type importService struct {
+	repo      repository.DocumentRepository
+	revisions RevisionService
+	media     repository.MediaRepository
+	client    *http.Client
+}
+
+// NewImportService 创建新的文档导入服务实例
+func NewImportService(repo repository.DocumentRepository, revisions RevisionService, media repository.MediaRepository) ImportService {
+	return &importService{
+		repo:      repo,
+		revisions: revisions,
+		media:     media,
+		client:    safehttp.NewClient(15 * time.Second),
+	}
+}
+
+// ImportFile 导入单个文件
+func (s *importService) ImportFile(userID string, filename string, data []byte) (*models.Document, error) {
+	if !importer.Supported(filename) {
+		return nil, ErrUnsupportedImport
+	}
+	if len(data) > maxImportFileSize {
+		return nil, ErrImportTooLarge
+	}
+
+	images := s.newImageResolver(nil)
+	return s.importDoc(userID, path.Base(filename), data, images, "")
+}
+
+// ImportZip 导入压缩包，单个文档失败不影响其他文档
+func (s *importService) ImportZip(userID string, data []byte) ([]models.DocumentList, []ImportFailure, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	files := make(map[string]*zip.File)
+	var docs []string
+	var total uint64
+	for _, f := range zr.File {
+		name := path.Clean(strings.TrimPrefix(strings.ReplaceAll(f.Name, "\\", "/"), "/"))
+		if f.FileInfo().IsDir() || skipZipEntry(name) {
+			continue
+		}
+		total += f.UncompressedSize64
+		if total > maxImportZipTotalSize {
+			return nil, nil, ErrImportTooLarge
+		}
+		files[name] = f
+		if importer.Supported(name) {
+			docs = append(docs, name)
+		}
+	}
+	if len(docs) == 0 {
+		return nil, nil, fmt.Errorf("%w: archive contains no importable documents", ErrInvalidImport)
+	}
+	if len(docs) > maxImportZipDocs {
+		return nil, nil, fmt.Errorf("%w: archive contains more than %d documents", ErrInvalidImport, maxImportZipDocs)
+	}
+	sort.Strings(docs)
+
+	// 同一压缩包中的图片只上传一次
+	images := s.newImageResolver(files)
+	imported := make([]models.DocumentList, 0, len(docs))
+	var failed []ImportFailure
+	for _, name := range docs {
+		content, err := readZipFile(files[name], maxImportFileSize)
+		var doc *models.Document
+		if err == nil {
+			doc, err = s.importDoc(userID, name, content, images, path.Dir(name))
+		}
+		if err != nil {
+			logger.Warn("Failed to import document from archive", zap.String("file", name), zap.Error(err))
+			failed = append(failed, ImportFailure{File: name, Error: err.Error()})
+			continue
+		}
+		imported = append(imported, doc.ToDocumentList())
+	}
+
+	logger.Info("Imported documents from archive",
+		zap.String("userID", userID),
+		zap.Int("imported", len(imported)),
+		zap.Int("failed", len(failed)))
+
+	return imported, failed, nil
+}
+
+// importDoc 转换文件并创建文档，dir为文件在压缩包中的目录，用于解析相对路径的图片
+func (s *importService) importDoc(userID, filename string, data []byte, images *imageResolver, dir string) (*models.Document, error) {
+	result, err := importer.Import(filename, data, importer.Options{
+		ResolveImage: func(src string) (string, bool) {
+			return images.resolve(src, dir)
+		},
+	})
+	if err != nil {
+		if errors.Is(err, importer.ErrUnsupportedFormat) {
+			return nil, ErrUnsupportedImport
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	now := time.Now()
+	isPublic := false
+	content := models.JSONContent(result.Content)
+	tags := models.StringList{}
+	if result.Tags != nil {
+		tags = models.StringList(result.Tags)
+	}
+
+	title := result.Title
+	if title == "" {
+		title = "Untitled"
+	}
+
+	// 导入的文档排在顶层文档的最后
+	position, err := s.repo.NextPosition(userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &models.Document{
+		ID:         uuid.New().String(),
+		OwnerID:    userID,
+		Title:      title,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		EditorJSON: &content,
+		IsPublic:   &isPublic,
+		ManualTags: tags,
+		Position:   position,
+	}
+	doc.RefreshIndex()
+
+	if err := s.repo.Create(doc); err != nil {
+		return nil, err
+	}
+
+	// 保存初始修订版本，失败不影响导入
+	if err := s.revisions.Snapshot(doc, userID, "Imported from "+path.Base(filename)); err != nil {
+		logger.Error("Failed to save document revision", zap.String("documentID", doc.ID), zap.Error(err))
+	}
+
+	logger.Info("Imported document",
+		zap.String("documentID", doc.ID),
+		zap.String("userID", userID),
+		zap.String("file", filename))
+
+	return doc, nil
+}
+
+// imageResolver 将导入文档中的图片上传到媒体存储
+type imageResolver struct {
+	service *importService
+	files   map[string]*zip.File
+	// 已处理的图片地址，值为空表示删除该图片
+	cache map[string]string
+	// 外部图片的下载次数和截止时间，限制一次导入请求的总耗时
+	remoteFetches int
+	deadline      time.Time
+}
+
+// newImageResolver 创建图片处理器，files为压缩包中的文件
+func (s *importService) newImageResolver(files map[string]*zip.File) *imageResolver {
+	return &imageResolver{
+		service:  s,
+		files:    files,
+		cache:    make(map[string]string),
+		deadline: time.Now().Add(importRemoteImageTime),
+	}
+}
+
+// resolve 处理图片地址：data URI和压缩包中的图片上传后替换地址，无法上传时删除；
+// 外部图片下载后上传，失败时保留原地址
+func (r *imageResolver) resolve(src string, dir string) (string, bool) {
+	src = strings.TrimSpace(src)
+	u, err := url.Parse(src)
+	if src == "" || err != nil {
+		return "", false
+	}
+
+	// 协议相对地址按https处理
+	if u.Scheme == "" && u.Host != "" {
+		src = "https:" + src
+		u.Scheme = "https"
+	}
+
+	key := src
+	scheme := strings.ToLower(u.Scheme)
+	if scheme == "" {
+		// 相对路径以压缩包中的完整路径缓存，不同目录中的同名图片互不影响
+		p := u.Path
+		if !strings.HasPrefix(p, "/") {
+			p = path.Join(dir, p)
+		}
+		key = path.Clean(strings.TrimPrefix(p, "/"))
+	}
+	if cached, ok := r.cache[key]; ok {
+		return cached, cached != ""
+	}
+
+	var resolved string
+	switch scheme {
+	case "data":
+		resolved = r.upload(src, func() ([]byte, error) { return decodeDataURI(src) })
+	case "http", "https":
+		// 超出下载次数或时间后不再下载，保留原地址
+		if r.remoteFetches < maxImportRemoteImages && time.Now().Before(r.deadline) {
+			r.remoteFetches++
+			resolved = r.upload(src, func() ([]byte, error) {
+				ctx, cancel := context.WithDeadline(context.Background(), r.deadline)
+				defer cancel()
+				return safehttp.GetContext(ctx, r.service.client, src, maxImportImageSize)
+			})
+		}
+		if resolved == "" {
+			resolved = src
+		}
+	case "":
+		if f, ok := r.files[key]; ok {
+			resolved = r.upload(key, func() ([]byte, error) { return readZipFile(f, maxImportImageSize) })
+		}
+	}
+
+	r.cache[key] = resolved
+	return resolved, resolved != ""
+}
+
+// upload 读取并上传图片，失败时返回空字符串
+func (r *imageResolver) upload(src string, read func() ([]byte, error)) string {
+	data, err := read()
+	if err != nil {
+		logger.Warn("Failed to read imported image", zap.String("src", truncateSrc(src)), zap.Error(err))
+		return ""
+	}
+
+	// 根据内容判断类型，不信任扩展名；DetectContentType不会识别SVG，因此SVG会被拒绝
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		logger.Warn("Imported image has unsupported content type",
+			zap.String("src", truncateSrc(src)),
+			zap.String("contentType", contentType))
+		return ""
+	}
+
+	fileName := "image." + strings.TrimPrefix(contentType, "image/")
+	if u, err := url.Parse(src); err == nil && u.Scheme != "data" && path.Base(u.Path) != "." && path.Base(u.Path) != "/" {
+		fileName = path.Base(u.Path)
+	}
+
+	location, err := r.service.media.UploadMedia(bytes.NewReader(data), int64(len(data)), fileName, contentType)
+	if err != nil {
+		if !errors.Is(err, repository.ErrStorageUnavailable) {
+			logger.Error("Failed to upload imported image", zap.String("src", truncateSrc(src)), zap.Error(err))
+		}
+		return ""
+	}
+	return location
+}
+
+// decodeDataURI 解码data URI中的数据
+func decodeDataURI(src string) ([]byte, error) {
+	meta, payload, ok := strings.Cut(strings.TrimPrefix(src, "data:"), ",")
+	if !ok {
+		return nil, errors.New("malformed data URI")
+	}
+	if base64.StdEncoding.DecodedLen(len(payload)) > maxImportImageSize {
+		return nil, ErrImportTooLarge
+	}
+	if strings.HasSuffix(meta, ";base64") {
+		payload = strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+				return -1
+			}
+			return r
+		}, payload)
+		return base64.StdEncoding.DecodeString(payload)
+	}
+	data, err := url.PathUnescape(payload)
+	return []byte(data), err
+}
+
+// readZipFile 读取压缩包中的文件，按实际解压大小限制，不信任文件头中记录的大小
+func readZipFile(f *zip.File, maxBytes int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, ErrImportTooLarge
+	}
+	return data, nil
+}
+
+// skipZipEntry 跳过隐藏文件和系统生成的文件，以及越出压缩包根目录的路径
+func skipZipEntry(name string) bool {
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return true
+	}
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateSrc 截断日志中的图片地址，避免记录完整的data URI
+func truncateSrc(src string) string {
+	if len(src) > 100 {
+		return src[:100] + "..."
+	}
+	return src
+}
@@ -0,0 +1,704 @@
+package service
+
+import (
+	"betalyr-learning-server/internal/config"
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/hls"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/pkg/probe"
+	"betalyr-learning-server/internal/repository"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	ffmpeg_go "github.com/u2takey/ffmpeg-go"
+	"go.uber.org/zap"
+)
+
+const (
+	// 默认并发处理任务数和最大尝试次数
+	defaultMediaWorkers     = 2
+	defaultMediaMaxAttempts = 5
+	// 没有新任务通知时检查队列的间隔
+	mediaJobPollInterval = 5 * time.Second
+	// 执行中的任务超过该时间没有刷新锁定时间时视为执行它的进程已崩溃，重新放回队列
+	mediaJobLockTimeout = 30 * time.Minute
+	// 执行任务期间刷新锁定时间的间隔，转码较长的视频可能超过mediaJobLockTimeout
+	mediaJobHeartbeatInterval = 5 * time.Minute
+	// 检查超时任务的间隔
+	mediaJobStaleCheckInterval = time.Minute
+	// 第一次重试的等待时间，之后每次翻倍，最长不超过mediaJobMaxBackoff
+	mediaJobBaseBackoff = 30 * time.Second
+	mediaJobMaxBackoff  = 30 * time.Minute
+	// 补充元数据时每批查询的媒体数量
+	mediaBackfillBatchSize = 50
+	// 提取一帧预览图或缩略图的时限，超时后终止ffmpeg
+	mediaFrameTimeout = 2 * time.Minute
+)
+
+// MediaProcessingService 定义媒体后台处理服务接口
+type MediaProcessingService interface {
+	// 将已上传到存储、状态为处理中的媒体加入处理队列
+	Enqueue(media *models.Media) error
+	// 在同一事务中保存已上传到存储的媒体记录和处理任务，失败时两者都不会保存
+	Submit(media *models.Media) error
+	// 获取媒体的处理状态，只有上传者可以查看
+	GetStatus(mediaID string, userID string) (*models.MediaProcessingStatus, error)
+	// 删除媒体的处理任务
+	DeleteJobs(mediaID string) error
+	// 启动处理队列的工作协程
+	Start()
+	// 为还没有元数据的已有视频和音频探测并保存元数据，返回成功和失败的数量
+	BackfillMeta() (updated int, failed int, err error)
+}
+
+// mediaProcessingService 媒体后台处理服务实现
+type mediaProcessingService struct {
+	repo        repository.MediaRepository
+	jobs        repository.MediaJobRepository
+	workers     int
+	maxAttempts int
+
+	wake      chan struct{}
+	startOnce sync.Once
+}
+
+// NewMediaProcessingService 创建新的媒体后台处理服务实例
+func NewMediaProcessingService(cfg *config.Config, repo repository.MediaRepository, jobs repository.MediaJobRepository) MediaProcessingService {
+	workers := parsePositiveInt(cfg.Media.Workers, defaultMediaWorkers, "media workers")
+	return &mediaProcessingService{
+		repo:        repo,
+		jobs:        jobs,
+		workers:     workers,
+		maxAttempts: parsePositiveInt(cfg.Media.MaxAttempts, defaultMediaMaxAttempts, "media job max attempts"),
+		wake:        make(chan struct{}, workers),
+	}
+}
+
+// parsePositiveInt 解析正整数配置，为空或无效时使用默认值
+func parsePositiveInt(value string, defaultValue int, name string) int {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		logger.Warn("Invalid "+name+" setting, using default",
+			zap.String("value", value),
+			zap.Int("default", defaultValue))
+		return defaultValue
+	}
+	return n
+}
+
+// Enqueue 将媒体加入处理队列，并唤醒一个空闲的工作协程
+func (s *mediaProcessingService) Enqueue(media *models.Media) error {
+	job := s.newJob(media.ID)
+	if err := s.jobs.Enqueue(job); err != nil {
+		return err
+	}
+	s.notify(job)
+	return nil
+}
+
+// Submit 在同一事务中保存媒体记录和处理任务，保证处理中的媒体一定有对应的任务
+func (s *mediaProcessingService) Submit(media *models.Media) error {
+	media.Status = models.MediaStatusProcessing
+	job := s.newJob(media.ID)
+	if err := s.jobs.EnqueueWithMedia(media, job); err != nil {
+		return err
+	}
+	s.notify(job)
+	return nil
+}
+
+// newJob 创建媒体的处理任务
+func (s *mediaProcessingService) newJob(mediaID string) *models.MediaJob {
+	return &models.MediaJob{
+		ID:          uuid.New().String(),
+		MediaID:     mediaID,
+		Type:        models.MediaJobProcess,
+		MaxAttempts: s.maxAttempts,
+	}
+}
+
+// notify 唤醒一个空闲的工作协程执行新加入的任务
+func (s *mediaProcessingService) notify(job *models.MediaJob) {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	logger.Info("Media processing job enqueued", zap.String("mediaID", job.MediaID), zap.String("jobID", job.ID))
+}
+
+// GetStatus 获取媒体的处理状态
+func (s *mediaProcessingService) GetStatus(mediaID string, userID string) (*models.MediaProcessingStatus, error) {
+	media, err := s.repo.GetMediaByID(mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if media == nil || media.UploaderID != userID {
+		return nil, ErrMediaNotFound
+	}
+
+	job, err := s.jobs.FindLatestByMedia(mediaID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := media.ToMediaProcessingStatus(job)
+	return &status, nil
+}
+
+// DeleteJobs 删除媒体的处理任务
+func (s *mediaProcessingService) DeleteJobs(mediaID string) error {
+	return s.jobs.DeleteByMedia(mediaID)
+}
+
+// Start 启动工作协程和超时任务检查，多次调用只启动一次
+func (s *mediaProcessingService) Start() {
+	s.startOnce.Do(func() {
+		for i := 0; i < s.workers; i++ {
+			go s.work()
+		}
+		go s.recoverStaleJobs()
+
+		logger.Info("Media processing workers started",
+			zap.Int("workers", s.workers),
+			zap.Int("maxAttempts", s.maxAttempts))
+	})
+}
+
+// BackfillMeta 按ID分批下载已有媒体的原文件并探测元数据。单个文件失败只记录日志并继续，
+// 与声明类型不一致的文件仍然保存元数据，但不修改媒体状态
+func (s *mediaProcessingService) BackfillMeta() (int, int, error) {
+	updated, failed := 0, 0
+	afterID := ""
+	for {
+		batch, err := s.repo.GetMediaWithoutMeta(afterID, mediaBackfillBatchSize)
+		if err != nil {
+			return updated, failed, err
+		}
+		if len(batch) == 0 {
+			return updated, failed, nil
+		}
+
+		for i := range batch {
+			media := &batch[i]
+			if err := s.backfillMediaMeta(media); err != nil {
+				logger.Error("Failed to backfill media meta", zap.Error(err), zap.String("mediaID", media.ID))
+				failed++
+				continue
+			}
+			updated++
+		}
+		afterID = batch[len(batch)-1].ID
+
+		logger.Info("Media meta backfill progress", zap.Int("updated", updated), zap.Int("failed", failed))
+	}
+}
+
+// backfillMediaMeta 下载单个媒体的原文件，探测并保存元数据
+func (s *mediaProcessingService) backfillMediaMeta(media *models.Media) error {
+	workDir, err := os.MkdirTemp("", "media_"+media.ID+"_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	localPath, err := s.downloadOriginal(media, workDir)
+	if err != nil {
+		return fmt.Errorf("download original file: %w", err)
+	}
+
+	result, err := probeFile(localPath)
+	if err != nil {
+		return err
+	}
+	if err := checkStreams(media, result); err != nil {
+		logger.Warn("Existing media does not match its content type", zap.Error(err), zap.String("mediaID", media.ID))
+	}
+
+	return s.repo.UpdateMediaMeta(media.ID, mediaMetaFromProbe(media.MediaType, result))
+}
+
+// work 循环领取并执行任务，队列为空时等待新任务通知或轮询间隔
+func (s *mediaProcessingService) work() {
+	for {
+		job, err := s.jobs.Claim(time.Now())
+		if err != nil {
+			logger.Error("Failed to claim media job", zap.Error(err))
+		}
+		if job == nil {
+			select {
+			case <-s.wake:
+			case <-time.After(mediaJobPollInterval):
+			}
+			continue
+		}
+		s.run(job)
+	}
+}
+
+// recoverStaleJobs 定期将执行超时的任务放回队列或标记为失败
+func (s *mediaProcessingService) recoverStaleJobs() {
+	ticker := time.NewTicker(mediaJobStaleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		jobs, err := s.jobs.GetStale(time.Now().Add(-mediaJobLockTimeout))
+		if err != nil {
+			logger.Error("Failed to get stale media jobs", zap.Error(err))
+		}
+		for i := range jobs {
+			logger.Warn("Recovering stale media job",
+				zap.String("jobID", jobs[i].ID),
+				zap.String("mediaID", jobs[i].MediaID),
+				zap.Int("attempts", jobs[i].Attempts))
+			s.handleFailure(&jobs[i], errors.New("processing timed out"))
+		}
+		<-ticker.C
+	}
+}
+
+// run 执行任务并根据结果更新任务状态
+func (s *mediaProcessingService) run(job *models.MediaJob) {
+	logger.Info("Running media job",
+		zap.String("jobID", job.ID),
+		zap.String("mediaID", job.MediaID),
+		zap.String("type", string(job.Type)),
+		zap.Int("attempt", job.Attempts))
+
+	stop := s.keepAlive(job)
+	err := s.process(job)
+	stop()
+	if err != nil {
+		s.handleFailure(job, err)
+		return
+	}
+
+	if err := s.jobs.Complete(job.ID, job.Attempts); err != nil {
+		logJobFinishError(err, "Failed to complete media job", job)
+		return
+	}
+	logger.Info("Media job completed", zap.String("jobID", job.ID), zap.String("mediaID", job.MediaID))
+}
+
+// keepAlive 在任务执行期间定期刷新锁定时间，返回的函数用于停止刷新
+func (s *mediaProcessingService) keepAlive(job *models.MediaJob) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(mediaJobHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := s.jobs.Heartbeat(job.ID, job.Attempts, time.Now()); err != nil {
+					logger.Error("Failed to refresh media job lock", zap.Error(err), zap.String("jobID", job.ID))
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// process 执行任务，处理过程中的panic作为错误返回
+func (s *mediaProcessingService) process(job *models.MediaJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	switch job.Type {
+	case models.MediaJobProcess:
+		return s.processMedia(job.MediaID)
+	default:
+		return fmt.Errorf("unknown media job type %q", job.Type)
+	}
+}
+
+// handleFailure 任务失败后按退避时间重试，尝试次数用尽或文件无效时将任务和媒体标记为失败
+func (s *mediaProcessingService) handleFailure(job *models.MediaJob, cause error) {
+	// 文件本身无效时重试没有意义，直接标记为失败
+	if job.Attempts < job.MaxAttempts && !errors.Is(cause, ErrInvalidMediaFile) {
+		backoff := mediaJobBaseBackoff << (job.Attempts - 1)
+		if backoff > mediaJobMaxBackoff || backoff <= 0 {
+			backoff = mediaJobMaxBackoff
+		}
+		logger.Warn("Media job failed, will retry",
+			zap.Error(cause),
+			zap.String("jobID", job.ID),
+			zap.String("mediaID", job.MediaID),
+			zap.Int("attempt", job.Attempts),
+			zap.Duration("backoff", backoff))
+		if err := s.jobs.Retry(job.ID, job.Attempts, time.Now().Add(backoff), cause.Error()); err != nil {
+			logJobFinishError(err, "Failed to reschedule media job", job)
+		}
+		return
+	}
+
+	logger.Error("Media job failed permanently",
+		zap.Error(cause),
+		zap.String("jobID", job.ID),
+		zap.String("mediaID", job.MediaID),
+		zap.Int("attempts", job.Attempts))
+	if err := s.jobs.Fail(job.ID, job.Attempts, cause.Error()); err != nil {
+		logJobFinishError(err, "Failed to mark media job as failed", job)
+		// 任务已被重新领取时由新的执行决定媒体状态
+		if errors.Is(err, repository.ErrMediaJobNotRunning) {
+			return
+		}
+	}
+	if err := s.setMediaError(job.MediaID, cause.Error()); err != nil {
+		logger.Error("Failed to mark media as failed", zap.Error(err), zap.String("mediaID", job.MediaID))
+	}
+}
+
+// logJobFinishError 记录结束任务时的错误，任务已被重新领取时只记录警告
+func logJobFinishError(err error, msg string, job *models.MediaJob) {
+	if errors.Is(err, repository.ErrMediaJobNotRunning) {
+		logger.Warn("Media job was reclaimed, discarding result of this attempt",
+			zap.String("jobID", job.ID),
+			zap.String("mediaID", job.MediaID),
+			zap.Int("attempt", job.Attempts))
+		return
+	}
+	logger.Error(msg, zap.Error(err), zap.String("jobID", job.ID))
+}
+
+// setMediaError 将媒体状态设为错误并记录原因
+func (s *mediaProcessingService) setMediaError(mediaID string, message string) error {
+	media, err := s.repo.GetMediaByID(mediaID)
+	if err != nil || media == nil {
+		return err
+	}
+	media.Status = models.MediaStatusError
+	media.StatusError = &message
+	_, err = s.repo.UpdateMedia(media)
+	return err
+}
+
+// processMedia 处理已上传的媒体：探测并保存元数据，视频还会转码为HLS并提取预览图和缩略图，完成后将媒体设为就绪
+func (s *mediaProcessingService) processMedia(mediaID string) error {
+	media, err := s.repo.GetMediaByID(mediaID)
+	if err != nil {
+		return err
+	}
+	if media == nil {
+		// 媒体在处理前已被删除
+		logger.Info("Skipping processing of deleted media", zap.String("mediaID", mediaID))
+		return nil
+	}
+
+	if media.MediaType == models.MediaTypeVideo || media.MediaType == models.MediaTypeAudio {
+		if err := s.processFile(media); err != nil {
+			// 处理期间媒体被删除（如原文件已不存在）时清理已上传的文件，不再重试
+			if current, getErr := s.repo.GetMediaByID(mediaID); getErr == nil && current == nil {
+				s.removeDeletedMediaFiles(media)
+				return nil
+			}
+			return err
+		}
+	}
+
+	media.Status = models.MediaStatusReady
+	media.StatusError = nil
+	ok, err := s.repo.UpdateMedia(media)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// 删除媒体时还没有播放列表和图片，这些文件需要在这里清理
+		s.removeDeletedMediaFiles(media)
+	}
+	return nil
+}
+
+// removeDeletedMediaFiles 删除处理期间已被删除的媒体生成的HLS文件、预览图和缩略图，失败时只记录日志
+func (s *mediaProcessingService) removeDeletedMediaFiles(media *models.Media) {
+	logger.Info("Media was deleted during processing, removing processed files", zap.String("mediaID", media.ID))
+	if media.MediaType != models.MediaTypeVideo {
+		return
+	}
+	if err := s.repo.DeleteMediaPrefix(media.ProcessedKeyPrefix()); err != nil {
+		logger.Error("Failed to delete processed media files",
+			zap.Error(err),
+			zap.String("mediaID", media.ID),
+			zap.String("prefix", media.ProcessedKeyPrefix()))
+	}
+}
+
+// processFile 下载原文件，探测元数据并校验文件中的流与声明的类型一致，视频继续转码和提取预览图
+func (s *mediaProcessingService) processFile(media *models.Media) error {
+	workDir, err := os.MkdirTemp("", "media_"+media.ID+"_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	localPath, err := s.downloadOriginal(media, workDir)
+	if err != nil {
+		return fmt.Errorf("download original file: %w", err)
+	}
+
+	result, err := probeFile(localPath)
+	if err != nil {
+		return err
+	}
+	if err := checkStreams(media, result); err != nil {
+		return err
+	}
+	media.Meta = mediaMetaFromProbe(media.MediaType, result)
+
+	if media.MediaType == models.MediaTypeVideo {
+		return s.processVideo(media, result, localPath, workDir)
+	}
+	return nil
+}
+
+// processVideo 将视频转码为HLS，提取预览图和缩略图并上传到存储
+func (s *mediaProcessingService) processVideo(media *models.Media, result *probe.Result, videoPath, workDir string) error {
+	// 转码失败或超时时返回错误由任务重试，重试用尽后媒体标记为错误
+	playlistURL, err := s.transcodeHLS(media, result, videoPath, workDir)
+	if err != nil {
+		return fmt.Errorf("transcode hls: %w", err)
+	}
+	media.PlaylistURL = &playlistURL
+
+	// 无法提取视频帧时不影响视频本身的使用，与同步上传时的行为一致
+	previewPath, thumbnailPath, err := extractVideoFrames(videoPath, workDir)
+	if err != nil {
+		logger.Error("Failed to extract video frames", zap.Error(err), zap.String("mediaID", media.ID))
+		return nil
+	}
+
+	// 图片与HLS文件位于同一前缀下，重试时覆盖，删除媒体时一并删除
+	prefix := media.ProcessedKeyPrefix()
+	previewURL, err := s.uploadFile(previewPath, prefix+"preview.jpg", "image/jpeg")
+	if err != nil {
+		return fmt.Errorf("upload preview image: %w", err)
+	}
+	thumbnailURL, err := s.uploadFile(thumbnailPath, prefix+"thumbnail.jpg", "image/jpeg")
+	if err != nil {
+		return fmt.Errorf("upload thumbnail image: %w", err)
+	}
+
+	media.Preview = &previewURL
+	media.Thumbnail = &thumbnailURL
+	return nil
+}
+
+// transcodeHLS 按源视频分辨率转码为多种清晰度的HLS，上传到媒体文件键前缀下，返回主播放列表URL。
+// 转码时限根据探测到的时长计算
+func (s *mediaProcessingService) transcodeHLS(media *models.Media, result *probe.Result, videoPath, workDir string) (string, error) {
+	video := result.Video
+	renditions := hls.Renditions(video.Width, video.Height)
+	logger.Info("Transcoding video to HLS",
+		zap.String("mediaID", media.ID),
+		zap.Int("width", video.Width),
+		zap.Int("height", video.Height),
+		zap.Int("renditions", len(renditions)),
+		zap.Float64("duration", result.Duration))
+
+	outDir := filepath.Join(workDir, "hls")
+	if err := hls.Transcode(videoPath, outDir, renditions, result.Duration); err != nil {
+		return "", err
+	}
+
+	// 先上传各清晰度的播放列表和分片，最后上传主播放列表
+	prefix := media.HLSKeyPrefix()
+	err := filepath.WalkDir(outDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || entry.Name() == hls.MasterPlaylist {
+			return err
+		}
+		rel, err := filepath.Rel(outDir, path)
+		if err != nil {
+			return err
+		}
+		_, err = s.uploadFile(path, prefix+filepath.ToSlash(rel), hls.ContentType(path))
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("upload hls segments: %w", err)
+	}
+
+	return s.uploadFile(filepath.Join(outDir, hls.MasterPlaylist), prefix+hls.MasterPlaylist, hls.ContentType(hls.MasterPlaylist))
+}
+
+// uploadFile 上传本地文件到存储的指定文件键，返回公开访问URL
+func (s *mediaProcessingService) uploadFile(localPath, fileKey, contentType string) (string, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	return s.repo.UploadMediaObject(fileKey, file, fileInfo.Size(), contentType)
+}
+
+// downloadOriginal 将媒体原文件下载到工作目录，返回本地路径
+func (s *mediaProcessingService) downloadOriginal(media *models.Media, workDir string) (string, error) {
+	path := filepath.Join(workDir, "original"+filepath.Ext(media.FileName))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := s.repo.DownloadMedia(media.FileKey, file); err != nil {
+		return "", err
+	}
+	return path, file.Close()
+}
+
+// probeFile 使用ffprobe探测媒体文件，无法识别的文件返回包装了ErrInvalidMediaFile的错误
+func probeFile(path string) (*probe.Result, error) {
+	result, err := probe.Probe(path)
+	if err != nil {
+		if errors.Is(err, probe.ErrUnrecognized) {
+			return nil, fmt.Errorf("%w: unrecognized file format", ErrInvalidMediaFile)
+		}
+		return nil, fmt.Errorf("probe file: %w", err)
+	}
+	return result, nil
+}
+
+// checkStreams 校验文件中实际的流与声明的内容类型一致：视频必须包含视频流，音频必须包含音频流且不能包含视频流
+func checkStreams(media *models.Media, result *probe.Result) error {
+	switch media.MediaType {
+	case models.MediaTypeVideo:
+		if result.Video == nil {
+			return fmt.Errorf("%w: declared as %s but contains no video stream", ErrInvalidMediaFile, media.ContentType)
+		}
+	case models.MediaTypeAudio:
+		if result.Audio == nil {
+			return fmt.Errorf("%w: declared as %s but contains no audio stream", ErrInvalidMediaFile, media.ContentType)
+		}
+		if result.Video != nil {
+			return fmt.Errorf("%w: declared as %s but contains a video stream", ErrInvalidMediaFile, media.ContentType)
+		}
+	}
+	return nil
+}
+
+// mediaMetaFromProbe 根据探测结果生成媒体元数据，编码格式取视频的视频流或音频的音频流
+func mediaMetaFromProbe(mediaType models.MediaType, result *probe.Result) *models.MediaMeta {
+	meta := &models.MediaMeta{}
+	if result.Duration > 0 {
+		duration := int64(math.Round(result.Duration))
+		meta.Duration = &duration
+	}
+	if result.Bitrate > 0 {
+		bitrate := result.Bitrate
+		meta.Bitrate = &bitrate
+	}
+
+	switch {
+	case mediaType == models.MediaTypeVideo && result.Video != nil:
+		video := result.Video
+		width, height := video.Width, video.Height
+		resolution := fmt.Sprintf("%dx%d", width, height)
+		meta.Width = &width
+		meta.Height = &height
+		meta.Resolution = &resolution
+		meta.Codec = optionalString(video.Codec)
+		meta.FrameRate = optionalString(video.FrameRate)
+		meta.AspectRatio = optionalString(video.AspectRatio)
+	case result.Audio != nil:
+		meta.Codec = optionalString(result.Audio.Codec)
+	}
+	return meta
+}
+
+// optionalString 空字符串返回nil
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// extractVideoFrames 提取视频第1秒的画面，生成预览图和缩略图，返回两张图片的本地路径。每次提取超过mediaFrameTimeout时终止ffmpeg
+func extractVideoFrames(videoPath, workDir string) (string, string, error) {
+	previewPath := filepath.Join(workDir, "preview.jpg")
+	thumbnailPath := filepath.Join(workDir, "thumbnail.jpg")
+
+	logger.Info("Extracting video frames",
+		zap.String("videoPath", videoPath),
+		zap.String("previewPath", previewPath),
+		zap.String("thumbnailPath", thumbnailPath))
+
+	// 提取预览图（高质量）
+	err := ffmpeg_go.Input(videoPath).
+		Output(previewPath, ffmpeg_go.KwArgs{
+			"vframes": 1,
+			"ss":      "00:00:01", // 从第1秒开始提取，避免全黑帧
+			"q:v":     2,          // 高质量
+			"vf":      "scale=1280:720:force_original_aspect_ratio=decrease,pad=1280:720:(ow-iw)/2:(oh-ih)/2:black",
+		}).
+		OverWriteOutput().
+		WithTimeout(mediaFrameTimeout).
+		Run()
+	if err != nil {
+		logger.Error("Failed to extract preview frame", zap.Error(err))
+		// 尝试更简单的命令
+		err = ffmpeg_go.Input(videoPath).
+			Output(previewPath, ffmpeg_go.KwArgs{
+				"vframes": 1,
+				"ss":      "00:00:01",
+			}).
+			OverWriteOutput().
+			WithTimeout(mediaFrameTimeout).
+			Run()
+		if err != nil {
+			return "", "", fmt.Errorf("extract preview frame: %w", err)
+		}
+	}
+
+	// 提取缩略图（较低质量）
+	err = ffmpeg_go.Input(videoPath).
+		Output(thumbnailPath, ffmpeg_go.KwArgs{
+			"vframes": 1,
+			"ss":      "00:00:01",
+			"q:v":     8,
+			"vf":      "scale=320:180:force_original_aspect_ratio=decrease,pad=320:180:(ow-iw)/2:(oh-ih)/2:black",
+		}).
+		OverWriteOutput().
+		WithTimeout(mediaFrameTimeout).
+		Run()
+	if err != nil {
+		logger.Error("Failed to extract thumbnail frame", zap.Error(err))
+		// 尝试更简单的命令
+		err = ffmpeg_go.Input(videoPath).
+			Output(thumbnailPath, ffmpeg_go.KwArgs{
+				"vframes": 1,
+				"ss":      "00:00:01",
+				"vf":      "scale=320:180",
+			}).
+			OverWriteOutput().
+			WithTimeout(mediaFrameTimeout).
+			Run()
+		if err != nil {
+			return "", "", fmt.Errorf("extract thumbnail frame: %w", err)
+		}
+	}
+
+	return previewPath, thumbnailPath, nil
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/repository"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// 文档滚动位置达到该比例时自动标记为已完成
+	documentCompleteRatio = 0.95
+	// 媒体播放位置达到时长的该比例时自动标记为已完成
+	mediaCompleteRatio = 0.95
+	// “继续学习”列表的默认和最大长度
+	defaultContinueLimit = 10
+	maxContinueLimit     = 50
+)
+
+// ProgressService 定义学习进度服务接口
+type ProgressService interface {
+	// 获取用户对某个内容的学习进度，没有记录时返回初始进度
+	GetProgress(userID string, itemType models.ProgressItemType, itemID string) (*models.LearningProgress, error)
+	// 上报学习进度，内容必须存在且对用户可见
+	ReportProgress(userID string, itemType models.ProgressItemType, itemID string, update models.ProgressUpdate) (*models.LearningProgress, error)
+	// 获取用户的所有学习进度，itemType为空时返回所有类型
+	GetUserProgress(userID string, itemType models.ProgressItemType) ([]models.LearningProgress, error)
+	// 获取最近学习但未完成的内容
+	GetContinueLearning(userID string, limit int) ([]models.ContinueLearningItem, error)
+	// 清除用户对某个内容的学习进度
+	ResetProgress(userID string, itemType models.ProgressItemType, itemID string) error
+}
+
+// progressService 学习进度服务实现
+type progressService struct {
+	repo    repository.LearningProgressRepository
+	docs    repository.DocumentRepository
+	media   repository.MediaRepository
+	sharing SharingService
+}
+
+// NewProgressService 创建新的学习进度服务实例
+func NewProgressService(repo repository.LearningProgressRepository, docs repository.DocumentRepository, media repository.MediaRepository, sharing SharingService) ProgressService {
+	return &progressService{
+		repo:    repo,
+		docs:    docs,
+		media:   media,
+		sharing: sharing,
+	}
+}
+
+// GetProgress 获取用户对某个内容的学习进度
+func (s *progressService) GetProgress(userID string, itemType models.ProgressItemType, itemID string) (*models.LearningProgress, error) {
+	if !itemType.IsValid() {
+		return nil, ErrInvalidProgress
+	}
+
+	progress, err := s.repo.Find(userID, itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil {
+		return &models.LearningProgress{ItemType: itemType, ItemID: itemID}, nil
+	}
+	return progress, nil
+}
+
+// ReportProgress 上报学习进度。文档的位置为滚动比例（0~1），媒体的位置为播放秒数，
+// 位置超过完成阈值时自动标记为已完成；completed为false时可以取消完成状态
+func (s *progressService) ReportProgress(userID string, itemType models.ProgressItemType, itemID string, update models.ProgressUpdate) (*models.LearningProgress, error) {
+	if !itemType.IsValid() {
+		return nil, ErrInvalidProgress
+	}
+	if update.Position != nil && (*update.Position < 0 || math.IsNaN(*update.Position) || math.IsInf(*update.Position, 0)) {
+		return nil, ErrInvalidProgress
+	}
+
+	// 内容达到该位置时视为已完成，0表示无法判断
+	var completeAt float64
+	switch itemType {
+	case models.ProgressItemDocument:
+		doc, err := s.docs.FindByID(itemID)
+		if err != nil {
+			return nil, err
+		}
+		visible, err := canViewDoc(s.sharing, doc, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !visible {
+			return nil, ErrDocumentNotFound
+		}
+		if update.Position != nil && *update.Position > 1 {
+			return nil, ErrInvalidProgress
+		}
+		completeAt = documentCompleteRatio
+	case models.ProgressItemMedia:
+		media, err := s.media.GetMediaByID(itemID)
+		if err != nil {
+			return nil, err
+		}
+		if media == nil || (media.MediaType != models.MediaTypeVideo && media.MediaType != models.MediaTypeAudio) {
+			return nil, ErrMediaNotFound
+		}
+		if media.Meta != nil && media.Meta.Duration != nil && *media.Meta.Duration > 0 {
+			duration := float64(*media.Meta.Duration)
+			if update.Position != nil && *update.Position > duration {
+				update.Position = &duration
+			}
+			completeAt = duration * mediaCompleteRatio
+		}
+	}
+
+	progress, err := s.repo.Find(userID, itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if progress == nil {
+		progress = &models.LearningProgress{
+			ID:       uuid.New().String(),
+			UserID:   userID,
+			ItemType: itemType,
+			ItemID:   itemID,
+		}
+	}
+
+	if update.Position != nil {
+		progress.Position = *update.Position
+		if completeAt > 0 && progress.Position >= completeAt {
+			progress.Completed = true
+		}
+	}
+	if update.Completed != nil {
+		progress.Completed = *update.Completed
+	}
+	if !progress.Completed {
+		progress.CompletedAt = nil
+	} else if progress.CompletedAt == nil {
+		progress.CompletedAt = &now
+	}
+	progress.LastAccessedAt = now
+	progress.UpdatedAt = now
+
+	if err := s.repo.Upsert(progress); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// GetUserProgress 获取用户的所有学习进度，按最近访问时间降序排序
+func (s *progressService) GetUserProgress(userID string, itemType models.ProgressItemType) ([]models.LearningProgress, error) {
+	if itemType != "" && !itemType.IsValid() {
+		return nil, ErrInvalidProgress
+	}
+
+	progress, err := s.repo.GetByUser(userID, itemType)
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil {
+		progress = []models.LearningProgress{}
+	}
+	return progress, nil
+}
+
+// GetContinueLearning 获取最近学习但未完成的内容，已删除或不再可见的内容会被跳过
+func (s *progressService) GetContinueLearning(userID string, limit int) ([]models.ContinueLearningItem, error) {
+	if limit < 1 || limit > maxContinueLimit {
+		limit = defaultContinueLimit
+	}
+
+	// 多取一些记录，弥补被跳过的内容
+	progress, err := s.repo.GetInProgress(userID, limit*2)
+	if err != nil {
+		return nil, err
+	}
+
+	var docIDs, mediaIDs []string
+	for _, p := range progress {
+		if p.ItemType == models.ProgressItemDocument {
+			docIDs = append(docIDs, p.ItemID)
+		} else {
+			mediaIDs = append(mediaIDs, p.ItemID)
+		}
+	}
+
+	docList, err := s.docs.FindByIDs(docIDs)
+	if err != nil {
+		return nil, err
+	}
+	docs := make(map[string]*models.Document, len(docList))
+	for i := range docList {
+		docs[docList[i].ID] = &docList[i]
+	}
+
+	mediaList, err := s.media.GetMediaByIDs(mediaIDs)
+	if err != nil {
+		return nil, err
+	}
+	media := make(map[string]*models.Media, len(mediaList))
+	for i := range mediaList {
+		media[mediaList[i].ID] = &mediaList[i]
+	}
+
+	items := make([]models.ContinueLearningItem, 0, limit)
+	for i := range progress {
+		if len(items) >= limit {
+			break
+		}
+		p := &progress[i]
+		if p.ItemType == models.ProgressItemDocument {
+			doc := docs[p.ItemID]
+			visible, err := canViewDoc(s.sharing, doc, userID)
+			if err != nil {
+				return nil, err
+			}
+			if visible {
+				items = append(items, p.ContinueItemFromDocument(doc))
+			}
+		} else if item := media[p.ItemID]; item != nil && item.Status == models.MediaStatusReady {
+			items = append(items, p.ContinueItemFromMedia(item))
+		}
+	}
+
+	return items, nil
+}
+
+// ResetProgress 清除用户对某个内容的学习进度
+func (s *progressService) ResetProgress(userID string, itemType models.ProgressItemType, itemID string) error {
+	if !itemType.IsValid() {
+		return ErrInvalidProgress
+	}
+	return s.repo.Delete(userID, itemType, itemID)
+}
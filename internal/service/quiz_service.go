@@ -0,0 +1,634 @@
+package service
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/repository"
+	"errors"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// 每个测验最多包含的题目数量
+	maxQuizQuestions = 100
+	// 每道选择题最多包含的选项数量
+	maxQuizOptions = 20
+	// 每道题目的最高分值
+	maxQuestionPoints = 100
+	// 默认及格分数线（得分百分比）
+	defaultPassScore = 60
+	// 测验标题的最大长度（字符数）
+	maxQuizTitleLength = 200
+	// 作答次数上限的最大值
+	maxQuizAttemptLimit = 100
+)
+
+// QuizService 定义测验服务接口
+type QuizService interface {
+	// 在用户自己的文档或视频上创建测验
+	CreateQuiz(userID string, input models.QuizInput) (*models.Quiz, error)
+	// 获取包含答案的测验，只有测验作者可以查看
+	GetQuiz(id string, userID string) (*models.Quiz, error)
+	// 更新测验的标题、及格线和题目
+	UpdateQuiz(id string, userID string, input models.QuizInput) (*models.Quiz, error)
+	// 删除测验及其所有作答记录
+	DeleteQuiz(id string, userID string) error
+	// 获取不包含答案的测验，用户必须可以查看测验附加的内容
+	GetLearnerQuiz(id string, userID string) (*models.LearnerQuiz, error)
+	// 获取附加在文档或视频上的、不包含答案的测验
+	GetLearnerQuizByTarget(targetType models.QuizTargetType, targetID string, userID string) (*models.LearnerQuiz, error)
+	// 提交作答并在服务端评分，及格后才返回正确答案
+	SubmitAttempt(id string, userID string, answers []models.QuizAnswer) (*models.QuizAttemptResult, error)
+	// 获取用户自己的作答记录
+	GetUserAttempts(id string, userID string) ([]models.QuizAttempt, error)
+	// 获取测验的作答统计，只有测验作者可以查看
+	GetQuizStats(id string, userID string) (*models.QuizStats, error)
+}
+
+// quizService 测验服务实现
+type quizService struct {
+	repo    repository.QuizRepository
+	docs    repository.DocumentRepository
+	media   repository.MediaRepository
+	sharing SharingService
+}
+
+// NewQuizService 创建新的测验服务实例
+func NewQuizService(repo repository.QuizRepository, docs repository.DocumentRepository, media repository.MediaRepository, sharing SharingService) QuizService {
+	return &quizService{
+		repo:    repo,
+		docs:    docs,
+		media:   media,
+		sharing: sharing,
+	}
+}
+
+// CreateQuiz 在文档或视频上创建测验。文档只有所有者可以添加测验，视频只有上传者可以添加测验
+func (s *quizService) CreateQuiz(userID string, input models.QuizInput) (*models.Quiz, error) {
+	if !input.TargetType.IsValid() {
+		return nil, &QuizQuestionError{Index: -1, Reason: "targetType must be document or video"}
+	}
+	if err := s.authorizeTarget(input.TargetType, input.TargetID, userID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.FindByTarget(input.TargetType, input.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrQuizExists
+	}
+
+	quiz := &models.Quiz{
+		ID:         uuid.New().String(),
+		OwnerID:    userID,
+		TargetType: input.TargetType,
+		TargetID:   input.TargetID,
+	}
+	if err := applyQuizInput(quiz, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(quiz); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Quiz created",
+		zap.String("quizID", quiz.ID),
+		zap.String("targetType", string(quiz.TargetType)),
+		zap.String("targetID", quiz.TargetID),
+		zap.String("userID", userID))
+
+	return quiz, nil
+}
+
+// GetQuiz 获取包含答案的测验
+func (s *quizService) GetQuiz(id string, userID string) (*models.Quiz, error) {
+	return s.findOwnedQuiz(id, userID)
+}
+
+// UpdateQuiz 更新测验的标题、及格线和题目，保留题目ID以延续作答统计
+func (s *quizService) UpdateQuiz(id string, userID string, input models.QuizInput) (*models.Quiz, error) {
+	quiz, err := s.findOwnedQuiz(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyQuizInput(quiz, input); err != nil {
+		return nil, err
+	}
+	quiz.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(quiz); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Quiz updated", zap.String("quizID", quiz.ID), zap.String("userID", userID))
+	return quiz, nil
+}
+
+// DeleteQuiz 删除测验及其所有作答记录
+func (s *quizService) DeleteQuiz(id string, userID string) error {
+	quiz, err := s.findOwnedQuiz(id, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(quiz.ID); err != nil {
+		return err
+	}
+
+	logger.Info("Quiz deleted", zap.String("quizID", quiz.ID), zap.String("userID", userID))
+	return nil
+}
+
+// GetLearnerQuiz 获取不包含答案的测验
+func (s *quizService) GetLearnerQuiz(id string, userID string) (*models.LearnerQuiz, error) {
+	quiz, err := s.findVisibleQuiz(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	learnerQuiz := quiz.ToLearnerQuiz()
+	return &learnerQuiz, nil
+}
+
+// GetLearnerQuizByTarget 获取附加在文档或视频上的测验，没有测验时返回ErrQuizNotFound
+func (s *quizService) GetLearnerQuizByTarget(targetType models.QuizTargetType, targetID string, userID string) (*models.LearnerQuiz, error) {
+	if !targetType.IsValid() {
+		return nil, ErrQuizNotFound
+	}
+
+	quiz, err := s.repo.FindByTarget(targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if quiz == nil {
+		return nil, ErrQuizNotFound
+	}
+
+	visible, err := s.canViewTarget(quiz, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrQuizNotFound
+	}
+
+	learnerQuiz := quiz.ToLearnerQuiz()
+	return &learnerQuiz, nil
+}
+
+// SubmitAttempt 评分并保存作答记录，返回每道题是否正确。
+// 及格后才同时返回正确答案和解析。用完作答次数不会公开答案，否则可以用随意更换的虚拟用户ID提交空白作答获取全部答案。
+// 未作答的题目计为错误，不属于测验的题目会被忽略
+func (s *quizService) SubmitAttempt(id string, userID string, answers []models.QuizAnswer) (*models.QuizAttemptResult, error) {
+	quiz, err := s.findVisibleQuiz(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	submitted := make(map[string]models.QuizAnswer, len(answers))
+	for _, answer := range answers {
+		if _, ok := submitted[answer.QuestionID]; !ok {
+			submitted[answer.QuestionID] = answer
+		}
+	}
+
+	attempt := &models.QuizAttempt{
+		ID:       uuid.New().String(),
+		QuizID:   quiz.ID,
+		UserID:   userID,
+		Answers:  make(models.QuizAnswers, 0, len(quiz.Questions)),
+		MaxScore: quiz.MaxScore(),
+	}
+	for _, question := range quiz.Questions {
+		answer, answered := submitted[question.ID]
+		graded := models.QuizAnswer{QuestionID: question.ID}
+		if answered {
+			graded.OptionIDs = answer.OptionIDs
+			graded.Text = strings.TrimSpace(answer.Text)
+			graded.Correct = gradeAnswer(question, graded)
+		}
+		if graded.Correct {
+			graded.Points = question.Points
+			attempt.Score += question.Points
+		}
+		attempt.Answers = append(attempt.Answers, graded)
+	}
+
+	if attempt.MaxScore > 0 {
+		attempt.Percent = roundPercent(float64(attempt.Score) / float64(attempt.MaxScore) * 100)
+	}
+	attempt.Passed = attempt.Percent >= float64(quiz.PassScore)
+
+	used, err := s.repo.CreateAttempt(attempt, quiz.MaxAttempts)
+	if errors.Is(err, repository.ErrAttemptLimitReached) {
+		return nil, ErrQuizAttemptsExhausted
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.QuizAttemptResult{QuizAttempt: *attempt}
+	if quiz.MaxAttempts > 0 {
+		left := quiz.MaxAttempts - int(used)
+		result.AttemptsLeft = &left
+	}
+	result.AnswersRevealed = attempt.Passed
+
+	result.Feedback = make([]models.QuestionFeedback, len(quiz.Questions))
+	for i, question := range quiz.Questions {
+		result.Feedback[i] = questionFeedback(question, attempt.Answers[i], result.AnswersRevealed)
+	}
+
+	logger.Info("Quiz attempt submitted",
+		zap.String("quizID", quiz.ID),
+		zap.String("userID", userID),
+		zap.Int("score", attempt.Score),
+		zap.Int("maxScore", attempt.MaxScore),
+		zap.Int64("attempt", used))
+
+	return result, nil
+}
+
+// GetUserAttempts 获取用户自己的作答记录，按提交时间降序排序
+func (s *quizService) GetUserAttempts(id string, userID string) ([]models.QuizAttempt, error) {
+	quiz, err := s.findVisibleQuiz(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.repo.GetUserAttempts(quiz.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if attempts == nil {
+		attempts = []models.QuizAttempt{}
+	}
+	return attempts, nil
+}
+
+// GetQuizStats 统计测验的作答次数、平均得分、及格率以及每道题的正确率。
+// 只统计测验当前包含的题目
+func (s *quizService) GetQuizStats(id string, userID string) (*models.QuizStats, error) {
+	quiz, err := s.findOwnedQuiz(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.repo.GetAttempts(quiz.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.QuizStats{
+		QuizID:    quiz.ID,
+		Attempts:  len(attempts),
+		Questions: make([]models.QuestionStats, len(quiz.Questions)),
+	}
+
+	questionIndex := make(map[string]int, len(quiz.Questions))
+	for i, question := range quiz.Questions {
+		questionIndex[question.ID] = i
+		stats.Questions[i] = models.QuestionStats{
+			QuestionID: question.ID,
+			Prompt:     question.Prompt,
+		}
+		if question.Type != models.QuestionShortAnswer {
+			stats.Questions[i].OptionCounts = make(map[string]int, len(question.Options))
+			for _, option := range question.Options {
+				stats.Questions[i].OptionCounts[option.ID] = 0
+			}
+		}
+	}
+
+	learners := make(map[string]bool)
+	var totalPercent float64
+	passed := 0
+	for i := range attempts {
+		attempt := &attempts[i]
+		learners[attempt.UserID] = true
+		totalPercent += attempt.Percent
+		if attempt.Passed {
+			passed++
+		}
+		if stats.LastAttemptAt == nil || attempt.CreatedAt.After(*stats.LastAttemptAt) {
+			stats.LastAttemptAt = &attempt.CreatedAt
+		}
+
+		for _, answer := range attempt.Answers {
+			index, ok := questionIndex[answer.QuestionID]
+			if !ok {
+				continue
+			}
+			questionStats := &stats.Questions[index]
+			questionStats.Answered++
+			if answer.Correct {
+				questionStats.CorrectCount++
+			}
+			for _, optionID := range answer.OptionIDs {
+				if _, ok := questionStats.OptionCounts[optionID]; ok {
+					questionStats.OptionCounts[optionID]++
+				}
+			}
+		}
+	}
+
+	stats.Learners = len(learners)
+	if len(attempts) > 0 {
+		stats.AveragePercent = roundPercent(totalPercent / float64(len(attempts)))
+		stats.PassRate = roundPercent(float64(passed) / float64(len(attempts)) * 100)
+	}
+	for i := range stats.Questions {
+		if stats.Questions[i].Answered > 0 {
+			stats.Questions[i].CorrectRate = roundPercent(float64(stats.Questions[i].CorrectCount) / float64(stats.Questions[i].Answered) * 100)
+		}
+	}
+
+	return stats, nil
+}
+
+// authorizeTarget 检查用户是否可以在文档或视频上添加测验
+func (s *quizService) authorizeTarget(targetType models.QuizTargetType, targetID string, userID string) error {
+	if targetType == models.QuizTargetDocument {
+		_, err := s.sharing.Authorize(targetID, userID, models.DocumentRoleOwner)
+		return err
+	}
+
+	media, err := s.media.GetMediaByID(targetID)
+	if err != nil {
+		return err
+	}
+	if media == nil || media.MediaType != models.MediaTypeVideo {
+		return ErrMediaNotFound
+	}
+	if media.UploaderID != userID {
+		logger.Warn("User attempted to add a quiz to a video they did not upload",
+			zap.String("mediaID", targetID),
+			zap.String("uploaderID", media.UploaderID),
+			zap.String("requestUserID", userID))
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// canViewTarget 检查用户是否可以查看测验附加的内容，测验作者始终可以查看
+func (s *quizService) canViewTarget(quiz *models.Quiz, userID string) (bool, error) {
+	if quiz.OwnerID == userID {
+		return true, nil
+	}
+
+	if quiz.TargetType == models.QuizTargetDocument {
+		doc, err := s.docs.FindByID(quiz.TargetID)
+		if err != nil {
+			return false, err
+		}
+		return canViewDoc(s.sharing, doc, userID)
+	}
+
+	media, err := s.media.GetMediaByID(quiz.TargetID)
+	if err != nil {
+		return false, err
+	}
+	return media != nil && media.Status == models.MediaStatusReady, nil
+}
+
+// findVisibleQuiz 查找测验并检查用户是否可以查看测验附加的内容，不可见时返回ErrQuizNotFound
+func (s *quizService) findVisibleQuiz(id string, userID string) (*models.Quiz, error) {
+	quiz, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if quiz == nil {
+		return nil, ErrQuizNotFound
+	}
+
+	visible, err := s.canViewTarget(quiz, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrQuizNotFound
+	}
+	return quiz, nil
+}
+
+// findOwnedQuiz 查找测验并检查用户是否为测验作者。
+// 可以查看测验但不是作者时返回ErrPermissionDenied
+func (s *quizService) findOwnedQuiz(id string, userID string) (*models.Quiz, error) {
+	quiz, err := s.findVisibleQuiz(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if quiz.OwnerID != userID {
+		logger.Warn("User attempted to manage a quiz they do not own",
+			zap.String("quizID", id),
+			zap.String("quizOwnerID", quiz.OwnerID),
+			zap.String("requestUserID", userID))
+		return nil, ErrPermissionDenied
+	}
+	return quiz, nil
+}
+
+// applyQuizInput 校验并规范化测验内容后应用到测验
+func applyQuizInput(quiz *models.Quiz, input models.QuizInput) error {
+	title := strings.TrimSpace(input.Title)
+	if title == "" {
+		title = "Quiz"
+	}
+	if utf8.RuneCountInString(title) > maxQuizTitleLength {
+		return &QuizQuestionError{Index: -1, Reason: "title is too long"}
+	}
+
+	passScore := defaultPassScore
+	if input.PassScore != nil {
+		passScore = *input.PassScore
+	}
+	if passScore < 0 || passScore > 100 {
+		return &QuizQuestionError{Index: -1, Reason: "passScore must be between 0 and 100"}
+	}
+
+	if input.MaxAttempts < 0 || input.MaxAttempts > maxQuizAttemptLimit {
+		return &QuizQuestionError{Index: -1, Reason: "maxAttempts must be between 0 and 100"}
+	}
+
+	questions, err := normalizeQuestions(input.Questions)
+	if err != nil {
+		return err
+	}
+
+	quiz.Title = title
+	quiz.PassScore = passScore
+	quiz.MaxAttempts = input.MaxAttempts
+	quiz.Questions = questions
+	return nil
+}
+
+// normalizeQuestions 校验题目并补全缺失的题目和选项ID，分值为0时使用1分
+func normalizeQuestions(questions []models.QuizQuestion) (models.QuizQuestions, error) {
+	if len(questions) == 0 || len(questions) > maxQuizQuestions {
+		return nil, &QuizQuestionError{Index: -1, Reason: "a quiz must have between 1 and 100 questions"}
+	}
+
+	result := make(models.QuizQuestions, len(questions))
+	questionIDs := make(map[string]bool, len(questions))
+	for i, question := range questions {
+		fail := func(reason string) error {
+			return &QuizQuestionError{Index: i, Reason: reason}
+		}
+
+		question.ID = strings.TrimSpace(question.ID)
+		if question.ID == "" {
+			question.ID = uuid.New().String()
+		}
+		if questionIDs[question.ID] {
+			return nil, fail("duplicate question id")
+		}
+		questionIDs[question.ID] = true
+
+		question.Prompt = strings.TrimSpace(question.Prompt)
+		if question.Prompt == "" {
+			return nil, fail("prompt is required")
+		}
+		question.Explanation = strings.TrimSpace(question.Explanation)
+
+		if question.Points == 0 {
+			question.Points = 1
+		}
+		if question.Points < 0 || question.Points > maxQuestionPoints {
+			return nil, fail("points must be between 1 and 100")
+		}
+
+		switch question.Type {
+		case models.QuestionSingleChoice, models.QuestionMultipleChoice:
+			if len(question.Options) < 2 || len(question.Options) > maxQuizOptions {
+				return nil, fail("choice questions must have between 2 and 20 options")
+			}
+			options := make([]models.QuizOption, len(question.Options))
+			optionIDs := make(map[string]bool, len(question.Options))
+			correct := 0
+			for j, option := range question.Options {
+				option.ID = strings.TrimSpace(option.ID)
+				if option.ID == "" {
+					option.ID = uuid.New().String()
+				}
+				if optionIDs[option.ID] {
+					return nil, fail("duplicate option id")
+				}
+				optionIDs[option.ID] = true
+				option.Text = strings.TrimSpace(option.Text)
+				if option.Text == "" {
+					return nil, fail("option text is required")
+				}
+				if option.Correct {
+					correct++
+				}
+				options[j] = option
+			}
+			if question.Type == models.QuestionSingleChoice && correct != 1 {
+				return nil, fail("single choice questions must have exactly one correct option")
+			}
+			if question.Type == models.QuestionMultipleChoice && correct == 0 {
+				return nil, fail("multiple choice questions must have at least one correct option")
+			}
+			question.Options = options
+			question.AcceptedAnswers = nil
+		case models.QuestionShortAnswer:
+			var accepted []string
+			seen := make(map[string]bool)
+			for _, answer := range question.AcceptedAnswers {
+				answer = strings.TrimSpace(answer)
+				key := normalizeShortAnswer(answer)
+				if key == "" || seen[key] {
+					continue
+				}
+				seen[key] = true
+				accepted = append(accepted, answer)
+			}
+			if len(accepted) == 0 {
+				return nil, fail("short answer questions must have at least one accepted answer")
+			}
+			question.AcceptedAnswers = accepted
+			question.Options = nil
+		default:
+			return nil, fail("type must be single, multiple or short")
+		}
+
+		result[i] = question
+	}
+	return result, nil
+}
+
+// gradeAnswer 判断作答是否正确。选择题必须恰好选中所有正确选项，
+// 简答题忽略大小写和多余空白后与任一参考答案一致即为正确
+func gradeAnswer(question models.QuizQuestion, answer models.QuizAnswer) bool {
+	if question.Type == models.QuestionShortAnswer {
+		text := normalizeShortAnswer(answer.Text)
+		if text == "" {
+			return false
+		}
+		for _, accepted := range question.AcceptedAnswers {
+			if normalizeShortAnswer(accepted) == text {
+				return true
+			}
+		}
+		return false
+	}
+
+	selected := make(map[string]bool, len(answer.OptionIDs))
+	for _, id := range answer.OptionIDs {
+		selected[id] = true
+	}
+	if question.Type == models.QuestionSingleChoice && len(selected) != 1 {
+		return false
+	}
+
+	matched := 0
+	for _, option := range question.Options {
+		if option.Correct != selected[option.ID] {
+			return false
+		}
+		if option.Correct {
+			matched++
+		}
+	}
+	return matched == len(selected)
+}
+
+// questionFeedback 生成提交后返回给学习者的单题反馈，reveal为false时不包含正确答案和解析
+func questionFeedback(question models.QuizQuestion, answer models.QuizAnswer, reveal bool) models.QuestionFeedback {
+	feedback := models.QuestionFeedback{
+		QuestionID: question.ID,
+		Correct:    answer.Correct,
+		Points:     answer.Points,
+	}
+	if !reveal {
+		return feedback
+	}
+
+	feedback.AcceptedAnswers = question.AcceptedAnswers
+	feedback.Explanation = question.Explanation
+	for _, option := range question.Options {
+		if option.Correct {
+			feedback.CorrectOptionIDs = append(feedback.CorrectOptionIDs, option.ID)
+		}
+	}
+	return feedback
+}
+
+// normalizeShortAnswer 规范化简答题答案：转为小写并合并连续空白
+func normalizeShortAnswer(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// roundPercent 将百分比保留一位小数
+func roundPercent(value float64) float64 {
+	return math.Round(value*10) / 10
+}
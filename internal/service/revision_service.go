@@ -0,0 +1,375 @@
+package service
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/editorjson"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/repository"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// 修订版本保留策略：保留最近的keepLatestRevisions个版本，更早的版本每天只保留最后一个
+	keepLatestRevisions = 50
+	// 逐行比较的最大规模（去掉相同的首尾后两边行数的乘积），超过时只标记被替换的区间
+	maxDiffCells = 1 << 20
+)
+
+// RevisionService 定义文档修订版本服务接口
+type RevisionService interface {
+	Snapshot(doc *models.Document, authorID string, summary string) error
+	SnapshotBaseline(doc *models.Document) error
+	ListRevisions(documentID, userID string) ([]models.DocumentRevisionList, error)
+	GetRevision(documentID, revisionID, userID string) (*models.DocumentRevision, error)
+	DiffRevisions(documentID, fromID, toID, userID string) (*models.RevisionDiff, error)
+	RestoreRevision(documentID, revisionID, userID string) (*models.Document, error)
+	DeleteRevisions(documentID string) error
+}
+
+// revisionService 文档修订版本服务实现
+type revisionService struct {
+	docRepo repository.DocumentRepository
+	repo    repository.DocumentRevisionRepository
+	sharing SharingService
+}
+
+// NewRevisionService 创建新的文档修订版本服务实例
+func NewRevisionService(docRepo repository.DocumentRepository, repo repository.DocumentRevisionRepository, sharing SharingService) RevisionService {
+	return &revisionService{
+		docRepo: docRepo,
+		repo:    repo,
+		sharing: sharing,
+	}
+}
+
+// Snapshot 为文档当前状态创建修订版本，summary为空时自动生成变更摘要。
+// summary为空且与上一版本相比没有变化时不创建修订版本，避免无效的保存挤掉保留的历史版本
+func (s *revisionService) Snapshot(doc *models.Document, authorID string, summary string) error {
+	rev := &models.DocumentRevision{
+		ID:         uuid.New().String(),
+		DocumentID: doc.ID,
+		AuthorID:   authorID,
+		Title:      doc.Title,
+		IconImage:  doc.IconImage,
+		CoverImage: doc.CoverImage,
+		EditorJSON: doc.EditorJSON,
+		Size:       contentSize(doc.EditorJSON),
+		Summary:    summary,
+		CreatedAt:  time.Now(),
+	}
+
+	if rev.Summary == "" {
+		previous, err := s.repo.FindLatest(doc.ID)
+		if err != nil {
+			return err
+		}
+		summary, changed := summarizeChanges(previous, rev)
+		if !changed {
+			return nil
+		}
+		rev.Summary = summary
+	}
+
+	if err := s.repo.Create(rev); err != nil {
+		return err
+	}
+
+	// 应用保留策略，失败不影响本次保存
+	if err := s.pruneRevisions(doc.ID); err != nil {
+		logger.Warn("Failed to prune document revisions", zap.String("documentID", doc.ID), zap.Error(err))
+	}
+
+	return nil
+}
+
+// SnapshotBaseline 文档还没有修订版本时保存当前状态作为初始版本，需在修改文档前调用，
+// 避免修订历史功能上线前创建的文档在第一次修改后丢失原始内容
+func (s *revisionService) SnapshotBaseline(doc *models.Document) error {
+	latest, err := s.repo.FindLatest(doc.ID)
+	if err != nil {
+		return err
+	}
+	if latest != nil {
+		return nil
+	}
+	return s.Snapshot(doc, doc.OwnerID, "Original version")
+}
+
+// ListRevisions 获取文档的修订版本列表
+func (s *revisionService) ListRevisions(documentID, userID string) ([]models.DocumentRevisionList, error) {
+	if _, err := s.findEditableDoc(documentID, userID); err != nil {
+		return nil, err
+	}
+
+	revs, err := s.repo.ListByDocument(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.DocumentRevisionList, len(revs))
+	for i, rev := range revs {
+		result[i] = rev.ToRevisionList()
+	}
+
+	return result, nil
+}
+
+// GetRevision 获取某个修订版本的完整内容
+func (s *revisionService) GetRevision(documentID, revisionID, userID string) (*models.DocumentRevision, error) {
+	if _, err := s.findEditableDoc(documentID, userID); err != nil {
+		return nil, err
+	}
+
+	rev, err := s.repo.FindByID(documentID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	if rev == nil {
+		return nil, ErrRevisionNotFound
+	}
+
+	return rev, nil
+}
+
+// DiffRevisions 比较两个修订版本
+func (s *revisionService) DiffRevisions(documentID, fromID, toID, userID string) (*models.RevisionDiff, error) {
+	from, err := s.GetRevision(documentID, fromID, userID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetRevision(documentID, toID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	content, coarse := diffLines(contentBlocks(from.EditorJSON), contentBlocks(to.EditorJSON))
+	diff := &models.RevisionDiff{
+		FromID:    from.ID,
+		ToID:      to.ID,
+		SizeDelta: to.Size - from.Size,
+		Content:   content,
+		Coarse:    coarse,
+	}
+	if from.Title != to.Title {
+		diff.Title = &models.FieldChange{From: from.Title, To: to.Title}
+	}
+	if !reflect.DeepEqual(from.IconImage, to.IconImage) {
+		diff.IconImage = &models.FieldChange{From: from.IconImage, To: to.IconImage}
+	}
+	if !reflect.DeepEqual(from.CoverImage, to.CoverImage) {
+		diff.CoverImage = &models.FieldChange{From: from.CoverImage, To: to.CoverImage}
+	}
+
+	return diff, nil
+}
+
+// RestoreRevision 将修订版本恢复为文档的当前版本
+func (s *revisionService) RestoreRevision(documentID, revisionID, userID string) (*models.Document, error) {
+	doc, err := s.findEditableDoc(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rev, err := s.repo.FindByID(documentID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	if rev == nil {
+		return nil, ErrRevisionNotFound
+	}
+
+	doc.Title = rev.Title
+	doc.IconImage = rev.IconImage
+	doc.CoverImage = rev.CoverImage
+	doc.EditorJSON = rev.EditorJSON
+	doc.RefreshIndex()
+	doc.UpdatedAt = time.Now()
+
+	if err := s.docRepo.Update(doc); err != nil {
+		return nil, wrapVersionConflict(s.docRepo, documentID, err)
+	}
+	syncDocSlug(s.docRepo, doc)
+
+	summary := fmt.Sprintf("Restored revision from %s", rev.CreatedAt.Format(time.RFC3339))
+	if err := s.Snapshot(doc, userID, summary); err != nil {
+		logger.Error("Failed to snapshot restored document", zap.String("documentID", documentID), zap.Error(err))
+	}
+
+	logger.Info("Document revision restored",
+		zap.String("documentID", documentID),
+		zap.String("revisionID", revisionID),
+		zap.String("userID", userID))
+
+	return doc, nil
+}
+
+// DeleteRevisions 删除文档的所有修订版本
+func (s *revisionService) DeleteRevisions(documentID string) error {
+	return s.repo.DeleteByDocument(documentID)
+}
+
+// findEditableDoc 获取文档并验证用户拥有编辑权限
+func (s *revisionService) findEditableDoc(documentID, userID string) (*models.Document, error) {
+	return s.sharing.Authorize(documentID, userID, models.DocumentRoleEditor)
+}
+
+// pruneRevisions 删除超出保留策略的修订版本
+func (s *revisionService) pruneRevisions(documentID string) error {
+	revs, err := s.repo.ListByDocument(documentID)
+	if err != nil {
+		return err
+	}
+	if len(revs) <= keepLatestRevisions {
+		return nil
+	}
+
+	// 列表按时间降序，较早的版本每天只保留最新的一个
+	var toDelete []string
+	keptDays := make(map[string]bool)
+	for _, rev := range revs[keepLatestRevisions:] {
+		day := rev.CreatedAt.Format("2006-01-02")
+		if keptDays[day] {
+			toDelete = append(toDelete, rev.ID)
+			continue
+		}
+		keptDays[day] = true
+	}
+
+	return s.repo.DeleteByIDs(toDelete)
+}
+
+// contentSize 计算编辑器内容的大小
+func contentSize(content *models.JSONContent) int64 {
+	if content == nil {
+		return 0
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return 0
+	}
+	return int64(len(data))
+}
+
+// contentBlocks 提取编辑器内容的文本块
+func contentBlocks(content *models.JSONContent) []string {
+	if content == nil {
+		return nil
+	}
+	return editorjson.Blocks(*content)
+}
+
+// summarizeChanges 根据与上一版本的差异生成变更摘要，没有变化时第二个返回值为false
+func summarizeChanges(previous, current *models.DocumentRevision) (string, bool) {
+	if previous == nil {
+		return "Initial version", true
+	}
+
+	var changed []string
+	if previous.Title != current.Title {
+		changed = append(changed, "title")
+	}
+	if !reflect.DeepEqual(previous.IconImage, current.IconImage) {
+		changed = append(changed, "icon")
+	}
+	if !reflect.DeepEqual(previous.CoverImage, current.CoverImage) {
+		changed = append(changed, "cover")
+	}
+	if !reflect.DeepEqual(previous.EditorJSON, current.EditorJSON) {
+		changed = append(changed, "content")
+	}
+
+	if len(changed) == 0 {
+		return "", false
+	}
+
+	return fmt.Sprintf("Updated %s (%+d bytes)", strings.Join(changed, ", "), current.Size-previous.Size), true
+}
+
+// diffLines 基于最长公共子序列计算两组文本行的差异。相同的首尾行直接跳过，
+// 剩余部分超过maxDiffCells时不逐行比较，整体标记为删除和插入，并返回coarse为true
+func diffLines(from, to []string) ([]models.DiffLine, bool) {
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix &&
+		from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]models.DiffLine, 0, len(from)+len(to)-prefix-suffix)
+	for _, text := range from[:prefix] {
+		lines = append(lines, models.DiffLine{Op: "equal", Text: text})
+	}
+
+	middleFrom := from[prefix : len(from)-suffix]
+	middleTo := to[prefix : len(to)-suffix]
+	coarse := len(middleFrom)*len(middleTo) > maxDiffCells
+	if coarse {
+		for _, text := range middleFrom {
+			lines = append(lines, models.DiffLine{Op: "delete", Text: text})
+		}
+		for _, text := range middleTo {
+			lines = append(lines, models.DiffLine{Op: "insert", Text: text})
+		}
+	} else {
+		lines = appendLCSDiff(lines, middleFrom, middleTo)
+	}
+
+	for _, text := range from[len(from)-suffix:] {
+		lines = append(lines, models.DiffLine{Op: "equal", Text: text})
+	}
+	return lines, coarse
+}
+
+// appendLCSDiff 使用最长公共子序列表计算差异并追加到lines
+func appendLCSDiff(lines []models.DiffLine, from, to []string) []models.DiffLine {
+	n, m := len(from), len(to)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case from[i] == to[j]:
+			lines = append(lines, models.DiffLine{Op: "equal", Text: from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, models.DiffLine{Op: "delete", Text: from[i]})
+			i++
+		default:
+			lines = append(lines, models.DiffLine{Op: "insert", Text: to[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		lines = append(lines, models.DiffLine{Op: "delete", Text: from[i]})
+	}
+	for ; j < m; j++ {
+		lines = append(lines, models.DiffLine{Op: "insert", Text: to[j]})
+	}
+
+	return lines
+}
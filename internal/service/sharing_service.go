@@ -0,0 +1,420 @@
+package service
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/repository"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SharingService 定义文档共享服务接口
+//
+// 权限规则：
+//   - viewer    查看文档
+//   - commenter 查看并评论文档
+//   - editor    编辑文档、查看和恢复修订版本、实时协作
+//   - owner     发布、删除文档以及管理协作者和分享链接
+//
+// 分享链接只能授予viewer或commenter角色，持有链接的人无需登录即可访问
+type SharingService interface {
+	// 获取用户对文档的角色，无权访问时返回空字符串
+	GetRole(doc *models.Document, userID string) (models.DocumentRole, error)
+	// 获取文档并检查用户是否拥有required角色
+	Authorize(documentID, userID string, required models.DocumentRole) (*models.Document, error)
+	ListCollaborators(documentID, userID string) ([]models.DocumentCollaborator, error)
+	InviteCollaborator(documentID, userID, inviteeID string, role models.DocumentRole) (*models.DocumentCollaborator, error)
+	RevokeCollaborator(documentID, userID, collaboratorID string) error
+	GetSharedDocs(userID string) ([]models.SharedDocumentList, error)
+	CreateShareLink(documentID, userID string, role models.DocumentRole, password string, expiresAt *time.Time) (*models.DocumentShareLinkList, error)
+	ListShareLinks(documentID, userID string) ([]models.DocumentShareLinkList, error)
+	RevokeShareLink(documentID, userID, linkID string) error
+	// 通过分享链接获取文档，不需要登录
+	GetSharedDocument(token, password string) (*models.SharedDocument, error)
+	// 校验分享链接并返回它授予文档的角色，未提供令牌时返回空字符串
+	GetLinkRole(documentID string, credentials models.ShareLinkCredentials) (models.DocumentRole, error)
+	// 删除文档的所有协作者和分享链接
+	DeleteSharing(documentID string) error
+}
+
+// sharingService 文档共享服务实现
+type sharingService struct {
+	docRepo  repository.DocumentRepository
+	repo     repository.DocumentCollaboratorRepository
+	linkRepo repository.DocumentShareLinkRepository
+}
+
+// NewSharingService 创建新的文档共享服务实例
+func NewSharingService(docRepo repository.DocumentRepository, repo repository.DocumentCollaboratorRepository, linkRepo repository.DocumentShareLinkRepository) SharingService {
+	return &sharingService{
+		docRepo:  docRepo,
+		repo:     repo,
+		linkRepo: linkRepo,
+	}
+}
+
+// GetRole 获取用户对文档的角色，所有者优先于协作者记录
+func (s *sharingService) GetRole(doc *models.Document, userID string) (models.DocumentRole, error) {
+	if userID == "" {
+		return "", nil
+	}
+	if doc.OwnerID == userID {
+		return models.DocumentRoleOwner, nil
+	}
+
+	collaborator, err := s.repo.Find(doc.ID, userID)
+	if err != nil {
+		return "", err
+	}
+	if collaborator == nil {
+		return "", nil
+	}
+	return collaborator.Role, nil
+}
+
+// Authorize 获取文档并检查用户角色。
+// 无任何角色时返回ErrDocumentNotFound，避免泄露文档是否存在；
+// 有角色但权限不足时返回ErrPermissionDenied
+func (s *sharingService) Authorize(documentID, userID string, required models.DocumentRole) (*models.Document, error) {
+	doc, err := s.docRepo.FindByID(documentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, ErrDocumentNotFound
+	}
+
+	role, err := s.GetRole(doc, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, ErrDocumentNotFound
+	}
+	if !role.AtLeast(required) {
+		logger.Warn("User does not have the required role for document",
+			zap.String("documentID", documentID),
+			zap.String("userID", userID),
+			zap.String("role", string(role)),
+			zap.String("required", string(required)))
+		return nil, ErrPermissionDenied
+	}
+
+	return doc, nil
+}
+
+// ListCollaborators 获取文档的协作者列表，文档的所有者和协作者都可以查看
+func (s *sharingService) ListCollaborators(documentID, userID string) ([]models.DocumentCollaborator, error) {
+	if _, err := s.Authorize(documentID, userID, models.DocumentRoleViewer); err != nil {
+		return nil, err
+	}
+
+	collaborators, err := s.repo.ListByDocument(documentID)
+	if err != nil {
+		return nil, err
+	}
+	if collaborators == nil {
+		collaborators = []models.DocumentCollaborator{}
+	}
+	return collaborators, nil
+}
+
+// InviteCollaborator 邀请用户协作，用户已是协作者时更新其角色
+func (s *sharingService) InviteCollaborator(documentID, userID, inviteeID string, role models.DocumentRole) (*models.DocumentCollaborator, error) {
+	if !role.IsCollaboratorRole() {
+		return nil, ErrInvalidRole
+	}
+
+	doc, err := s.Authorize(documentID, userID, models.DocumentRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	if inviteeID == "" || inviteeID == doc.OwnerID {
+		return nil, ErrInvalidCollaborator
+	}
+
+	now := time.Now()
+	collaborator := &models.DocumentCollaborator{
+		ID:         uuid.New().String(),
+		DocumentID: documentID,
+		UserID:     inviteeID,
+		Role:       role,
+		InvitedBy:  userID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.repo.Upsert(collaborator); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Document collaborator invited",
+		zap.String("documentID", documentID),
+		zap.String("userID", inviteeID),
+		zap.String("role", string(role)))
+
+	// 重新读取，已存在的记录保留原ID和创建时间
+	saved, err := s.repo.Find(documentID, inviteeID)
+	if err != nil || saved == nil {
+		return collaborator, err
+	}
+	return saved, nil
+}
+
+// RevokeCollaborator 移除协作者。所有者可以移除任何协作者，协作者可以移除自己（退出共享）
+func (s *sharingService) RevokeCollaborator(documentID, userID, collaboratorID string) error {
+	required := models.DocumentRoleOwner
+	if collaboratorID == userID {
+		required = models.DocumentRoleViewer
+	}
+	if _, err := s.Authorize(documentID, userID, required); err != nil {
+		return err
+	}
+
+	collaborator, err := s.repo.Find(documentID, collaboratorID)
+	if err != nil {
+		return err
+	}
+	if collaborator == nil {
+		return ErrCollaboratorNotFound
+	}
+
+	if err := s.repo.Delete(documentID, collaboratorID); err != nil {
+		return err
+	}
+
+	logger.Info("Document collaborator revoked",
+		zap.String("documentID", documentID),
+		zap.String("userID", collaboratorID),
+		zap.String("revokedBy", userID))
+	return nil
+}
+
+// GetSharedDocs 获取共享给用户的文档列表，按共享时间降序排序
+func (s *sharingService) GetSharedDocs(userID string) ([]models.SharedDocumentList, error) {
+	collaborators, err := s.repo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(collaborators))
+	for i, collaborator := range collaborators {
+		ids[i] = collaborator.DocumentID
+	}
+	docs, err := s.docRepo.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	docMap := make(map[string]models.Document, len(docs))
+	for _, doc := range docs {
+		docMap[doc.ID] = doc
+	}
+
+	result := make([]models.SharedDocumentList, 0, len(collaborators))
+	for _, collaborator := range collaborators {
+		doc, ok := docMap[collaborator.DocumentID]
+		// 跳过已删除的文档以及用户自己拥有的文档
+		if !ok || doc.OwnerID == userID {
+			continue
+		}
+		result = append(result, models.SharedDocumentList{
+			ID:         doc.ID,
+			Title:      doc.Title,
+			CoverImage: doc.CoverImage,
+			OwnerID:    doc.OwnerID,
+			Role:       collaborator.Role,
+			SharedAt:   collaborator.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// CreateShareLink 为文档创建私密分享链接，password和expiresAt为空时不限制
+func (s *sharingService) CreateShareLink(documentID, userID string, role models.DocumentRole, password string, expiresAt *time.Time) (*models.DocumentShareLinkList, error) {
+	if role != models.DocumentRoleViewer && role != models.DocumentRoleCommenter {
+		return nil, ErrInvalidRole
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, ErrInvalidExpiry
+	}
+
+	if _, err := s.Authorize(documentID, userID, models.DocumentRoleOwner); err != nil {
+		return nil, err
+	}
+
+	token, err := generateShareToken()
+	if err != nil {
+		return nil, err
+	}
+
+	link := &models.DocumentShareLink{
+		ID:         uuid.New().String(),
+		DocumentID: documentID,
+		Token:      token,
+		Role:       role,
+		ExpiresAt:  expiresAt,
+		CreatedBy:  userID,
+		CreatedAt:  time.Now(),
+	}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		link.PasswordHash = string(hash)
+	}
+
+	if err := s.linkRepo.Create(link); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Document share link created",
+		zap.String("documentID", documentID),
+		zap.String("linkID", link.ID),
+		zap.String("role", string(role)))
+
+	result := link.ToShareLinkList()
+	return &result, nil
+}
+
+// ListShareLinks 获取文档的分享链接列表，只有所有者可以查看
+func (s *sharingService) ListShareLinks(documentID, userID string) ([]models.DocumentShareLinkList, error) {
+	if _, err := s.Authorize(documentID, userID, models.DocumentRoleOwner); err != nil {
+		return nil, err
+	}
+
+	links, err := s.linkRepo.ListByDocument(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.DocumentShareLinkList, len(links))
+	for i, link := range links {
+		result[i] = link.ToShareLinkList()
+	}
+	return result, nil
+}
+
+// RevokeShareLink 撤销分享链接，撤销后链接立即失效
+func (s *sharingService) RevokeShareLink(documentID, userID, linkID string) error {
+	if _, err := s.Authorize(documentID, userID, models.DocumentRoleOwner); err != nil {
+		return err
+	}
+
+	deleted, err := s.linkRepo.Delete(documentID, linkID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrShareLinkNotFound
+	}
+
+	logger.Info("Document share link revoked",
+		zap.String("documentID", documentID),
+		zap.String("linkID", linkID))
+	return nil
+}
+
+// GetSharedDocument 通过分享链接获取文档
+func (s *sharingService) GetSharedDocument(token, password string) (*models.SharedDocument, error) {
+	link, err := s.findValidLink(token, password)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.docRepo.FindByID(link.DocumentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, ErrShareLinkNotFound
+	}
+
+	result := doc.ToSharedDocument(link.Role)
+	return &result, nil
+}
+
+// GetLinkRole 校验分享链接的令牌、有效期和密码，返回链接授予文档的角色。
+// 链接不属于该文档时返回ErrShareLinkNotFound
+func (s *sharingService) GetLinkRole(documentID string, credentials models.ShareLinkCredentials) (models.DocumentRole, error) {
+	if credentials.Token == "" {
+		return "", nil
+	}
+
+	link, err := s.findValidLink(credentials.Token, credentials.Password)
+	if err != nil {
+		return "", err
+	}
+	if link.DocumentID != documentID {
+		return "", ErrShareLinkNotFound
+	}
+	return link.Role, nil
+}
+
+// findValidLink 获取未过期的分享链接，设置了密码的链接需要提供正确的密码
+func (s *sharingService) findValidLink(token, password string) (*models.DocumentShareLink, error) {
+	link, err := s.linkRepo.FindByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrShareLinkNotFound
+	}
+	if link.IsExpired() {
+		return nil, ErrShareLinkExpired
+	}
+
+	if link.PasswordHash != "" {
+		if password == "" {
+			return nil, ErrPasswordRequired
+		}
+		if bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+			logger.Warn("Invalid share link password", zap.String("linkID", link.ID))
+			return nil, ErrInvalidPassword
+		}
+	}
+	return link, nil
+}
+
+// DeleteSharing 删除文档的所有协作者和分享链接
+func (s *sharingService) DeleteSharing(documentID string) error {
+	if err := s.repo.DeleteByDocument(documentID); err != nil {
+		return err
+	}
+	return s.linkRepo.DeleteByDocument(documentID)
+}
+
+// generateShareToken 生成URL安全的随机分享token
+func generateShareToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// canViewDoc 检查用户是否可以查看文档，公开文档所有人可见，私有文档需要viewer及以上角色
+func canViewDoc(sharing SharingService, doc *models.Document, userID string) (bool, error) {
+	if doc == nil {
+		return false, nil
+	}
+	if isDocPublic(doc) {
+		return true, nil
+	}
+	role, err := sharing.GetRole(doc, userID)
+	if err != nil {
+		return false, err
+	}
+	return role.AtLeast(models.DocumentRoleViewer), nil
+}
+
+// isDocPublic 判断文档是否已公开
+func isDocPublic(doc *models.Document) bool {
+	return doc != nil && doc.IsPublic != nil && *doc.IsPublic
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"betalyr-learning-server/internal/config"
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// 默认回收站保留天数
+	defaultTrashRetentionDays = 30
+	// 自动清理任务的执行间隔
+	trashPurgeInterval = time.Hour
+	// 每批清理的文档数量
+	trashPurgeBatchSize = 100
+)
+
+// TrashService 定义回收站服务接口
+type TrashService interface {
+	// 获取用户回收站中的文档
+	GetTrash(userID string) ([]models.TrashDocumentList, error)
+	// 恢复回收站中的文档及其子文档，只有所有者可以恢复
+	RestoreDoc(id string, userID string) (*models.Document, error)
+	// 永久删除回收站中的文档及其子文档，只有所有者可以删除
+	PurgeDoc(id string, userID string) error
+	// 永久删除在回收站中超过保留天数的文档，返回删除的数量
+	PurgeExpired() (int, error)
+	// 启动定期清理回收站的后台任务
+	StartPurgeJob()
+}
+
+// trashService 回收站服务实现
+type trashService struct {
+	repo       repository.DocumentRepository
+	revisions  RevisionService
+	sharing    SharingService
+	comments   repository.DocumentCommentRepository
+	engagement repository.EngagementRepository
+	quizzes    repository.QuizRepository
+	retention  time.Duration
+}
+
+// NewTrashService 创建新的回收站服务实例
+func NewTrashService(cfg *config.Config, repo repository.DocumentRepository, revisions RevisionService, sharing SharingService, comments repository.DocumentCommentRepository, engagement repository.EngagementRepository, quizzes repository.QuizRepository) TrashService {
+	return &trashService{
+		repo:       repo,
+		revisions:  revisions,
+		sharing:    sharing,
+		comments:   comments,
+		engagement: engagement,
+		quizzes:    quizzes,
+		retention:  parseRetention(cfg.Trash.RetentionDays),
+	}
+}
+
+// parseRetention 解析回收站保留天数，0表示不自动清理，无效值使用默认值
+func parseRetention(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultTrashRetentionDays * 24 * time.Hour
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		logger.Warn("Invalid trash retention days, using default",
+			zap.String("value", value),
+			zap.Int("default", defaultTrashRetentionDays))
+		days = defaultTrashRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetTrash 获取用户回收站中的文档，按删除时间降序排序。
+// 与父文档一起删除的子文档不单独列出，恢复或永久删除父文档时一并处理
+func (s *trashService) GetTrash(userID string) ([]models.TrashDocumentList, error) {
+	docs, err := s.repo.GetDeletedByOwner(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedAt := make(map[string]time.Time, len(docs))
+	for i := range docs {
+		deletedAt[docs[i].ID] = docs[i].DeletedAt.Time
+	}
+
+	result := make([]models.TrashDocumentList, 0, len(docs))
+	for i := range docs {
+		doc := &docs[i]
+		if doc.ParentID != nil {
+			if t, ok := deletedAt[*doc.ParentID]; ok && t.Equal(doc.DeletedAt.Time) {
+				continue
+			}
+		}
+		result = append(result, doc.ToTrashDocumentList(s.retention))
+	}
+	return result, nil
+}
+
+// findTrashedDoc 查找用户自己回收站中的文档，不存在或不属于该用户时返回ErrDocumentNotFound
+func (s *trashService) findTrashedDoc(id string, userID string) (*models.Document, error) {
+	doc, err := s.repo.FindDeletedByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil || doc.OwnerID != userID {
+		return nil, ErrDocumentNotFound
+	}
+	return doc, nil
+}
+
+// RestoreDoc 恢复回收站中的文档及与其一起删除的子文档
+func (s *trashService) RestoreDoc(id string, userID string) (*models.Document, error) {
+	if _, err := s.findTrashedDoc(id, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Restore(id); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Document restored from trash", zap.String("documentID", id), zap.String("userID", userID))
+
+	doc, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, ErrDocumentNotFound
+	}
+	return doc, nil
+}
+
+// PurgeDoc 永久删除回收站中的文档及与其一起删除的子文档
+func (s *trashService) PurgeDoc(id string, userID string) error {
+	if _, err := s.findTrashedDoc(id, userID); err != nil {
+		return err
+	}
+
+	ids, err := s.purge(id)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Document purged from trash",
+		zap.String("documentID", id),
+		zap.String("userID", userID),
+		zap.Int("count", len(ids)))
+	return nil
+}
+
+// purge 永久删除文档及其子文档，并删除它们的修订版本、分享设置、评论、互动记录和测验
+func (s *trashService) purge(id string) ([]string, error) {
+	ids, err := s.repo.Purge(id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, purgedID := range ids {
+		// 删除文档的修订版本
+		if err := s.revisions.DeleteRevisions(purgedID); err != nil {
+			logger.Error("Failed to delete document revisions", zap.String("documentID", purgedID), zap.Error(err))
+		}
+
+		// 删除文档的协作者和分享链接
+		if err := s.sharing.DeleteSharing(purgedID); err != nil {
+			logger.Error("Failed to delete document sharing", zap.String("documentID", purgedID), zap.Error(err))
+		}
+
+		// 删除文档的评论和举报记录
+		if err := s.comments.DeleteByDocument(purgedID); err != nil {
+			logger.Error("Failed to delete document comments", zap.String("documentID", purgedID), zap.Error(err))
+		}
+
+		// 删除文档的点赞、收藏和浏览记录
+		if err := s.engagement.DeleteByItem(models.EngagementItemDocument, purgedID); err != nil {
+			logger.Error("Failed to delete document engagement", zap.String("documentID", purgedID), zap.Error(err))
+		}
+
+		// 删除文档的测验和作答记录
+		if err := s.quizzes.DeleteByTarget(models.QuizTargetDocument, purgedID); err != nil {
+			logger.Error("Failed to delete document quiz", zap.String("documentID", purgedID), zap.Error(err))
+		}
+	}
+
+	return ids, nil
+}
+
+// PurgeExpired 分批永久删除超过保留天数的文档
+func (s *trashService) PurgeExpired() (int, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+
+	before := time.Now().Add(-s.retention)
+	purged := 0
+	for {
+		docs, err := s.repo.GetDeletedBefore(before, trashPurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for i := range docs {
+			ids, err := s.purge(docs[i].ID)
+			if err != nil {
+				return purged, err
+			}
+			purged += len(ids)
+		}
+
+		if len(docs) < trashPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// StartPurgeJob 启动后台任务，启动时和之后每小时清理一次过期文档
+func (s *trashService) StartPurgeJob() {
+	if s.retention <= 0 {
+		logger.Info("Trash auto purge disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			count, err := s.PurgeExpired()
+			if err != nil {
+				logger.Error("Failed to purge expired trash", zap.Error(err))
+			} else if count > 0 {
+				logger.Info("Purged expired trash", zap.Int("count", count))
+			}
+			<-ticker.C
+		}
+	}()
+
+	logger.Info("Trash auto purge started", zap.Duration("retention", s.retention))
+}
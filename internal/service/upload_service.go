@@ -0,0 +1,577 @@
+package service
+
+import (
+	"betalyr-learning-server/internal/models"
+	"betalyr-learning-server/internal/pkg/logger"
+	"betalyr-learning-server/internal/repository"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// 分片上传限制
+const (
+	// S3要求除最后一个分片外每个分片至少5MB，单个上传最多10000个分片
+	minUploadPartSize = 8 << 20
+	maxUploadPartSize = 64 << 20
+	maxUploadParts    = 10000
+	maxUploadFileSize = maxUploadPartSize * maxUploadParts
+	// S3单次PUT上传的最大文件大小，超过时直传必须分片上传
+	maxSinglePutSize = 5 << 30
+	// 预签名地址的有效期和单次请求最多签名的分片数
+	presignExpiry   = time.Hour
+	maxPresignParts = 100
+	// 上传会话在最后一次上传分片后保留的时间
+	uploadSessionTTL = 24 * time.Hour
+	// 清理过期会话的间隔和每批数量
+	uploadCleanupInterval  = time.Hour
+	uploadCleanupBatchSize = 100
+)
+
+// UploadService 定义可断点续传的分片上传服务接口
+type UploadService interface {
+	// 创建上传会话，分片经由服务器上传
+	CreateSession(userID string, init models.UploadSessionInit) (*models.UploadSessionDetail, error)
+	// 创建直传会话，客户端使用预签名地址直接上传到存储
+	CreateDirectSession(userID string, init models.UploadSessionInit) (*models.DirectUploadDetail, error)
+	// 为直传会话的分片生成预签名上传地址
+	PresignParts(id string, userID string, partNumbers []int) ([]models.PresignedURL, error)
+	// 获取上传会话及已上传的分片，用于断点续传
+	GetSession(id string, userID string) (*models.UploadSessionDetail, error)
+	// 上传一个分片，checksum不为空时校验分片内容的SHA-256
+	UploadPart(id string, userID string, partNumber int, body io.Reader, checksum string) (*models.UploadPart, error)
+	// 合并所有分片并创建媒体，媒体加入后台处理队列。已完成的会话重复调用时返回同一媒体
+	Complete(id string, userID string) (*models.Media, error)
+	// 取消上传并删除已上传的分片
+	Abort(id string, userID string) error
+	// 取消所有过期的上传会话，返回取消的数量
+	AbortExpired() (int, error)
+	// 启动定期取消过期会话的后台任务
+	StartCleanupJob()
+}
+
+// uploadService 分片上传服务实现
+type uploadService struct {
+	repo       repository.UploadSessionRepository
+	media      repository.MediaRepository
+	processing MediaProcessingService
+}
+
+// NewUploadService 创建新的分片上传服务实例
+func NewUploadService(repo repository.UploadSessionRepository, media repository.MediaRepository, processing MediaProcessingService) UploadService {
+	return &uploadService{
+		repo:       repo,
+		media:      media,
+		processing: processing,
+	}
+}
+
+// uploadPartSize 选择分片大小：默认minUploadPartSize，文件较大时增大分片以保证分片数不超过maxUploadParts
+func uploadPartSize(fileSize int64) int64 {
+	partSize := int64(minUploadPartSize)
+	if needed := (fileSize + maxUploadParts - 1) / maxUploadParts; needed > partSize {
+		// 按1MB向上取整
+		partSize = (needed + 1<<20 - 1) &^ (1<<20 - 1)
+	}
+	return partSize
+}
+
+// normalizeUploadInit 校验并规范化上传参数，补充默认的标题和分类
+func normalizeUploadInit(init *models.UploadSessionInit) error {
+	init.FileName = strings.TrimSpace(init.FileName)
+	init.Title = strings.TrimSpace(init.Title)
+	init.Category = strings.TrimSpace(init.Category)
+	init.Description = strings.TrimSpace(init.Description)
+
+	if init.FileName == "" || init.FileSize <= 0 || init.FileSize > maxUploadFileSize {
+		return ErrInvalidUpload
+	}
+	switch init.MediaType {
+	case models.MediaTypeVideo:
+		if init.Category == "" {
+			init.Category = "其他"
+		}
+	case models.MediaTypeAudio:
+		init.Category = "音频" // 音频默认分类
+	default:
+		return ErrInvalidUpload
+	}
+	// 与直接上传一致，内容类型必须与媒体类型相符
+	if !strings.Contains(init.ContentType, string(init.MediaType)) {
+		return ErrInvalidUpload
+	}
+	if init.Title == "" {
+		init.Title = init.FileName
+	}
+	return nil
+}
+
+// newUploadSession 根据上传参数创建上传中的会话
+func newUploadSession(userID string, init models.UploadSessionInit, fileKey, storageUploadID string) *models.UploadSession {
+	session := &models.UploadSession{
+		ID:              uuid.New().String(),
+		UploaderID:      userID,
+		MediaType:       init.MediaType,
+		Title:           init.Title,
+		Category:        init.Category,
+		FileName:        init.FileName,
+		ContentType:     init.ContentType,
+		FileSize:        init.FileSize,
+		PartSize:        uploadPartSize(init.FileSize),
+		FileKey:         fileKey,
+		StorageUploadID: storageUploadID,
+		Status:          models.UploadSessionActive,
+		ExpiresAt:       time.Now().Add(uploadSessionTTL),
+	}
+	if init.Description != "" {
+		session.Description = &init.Description
+	}
+	return session
+}
+
+// CreateSession 校验文件信息并在存储上创建分片上传
+func (s *uploadService) CreateSession(userID string, init models.UploadSessionInit) (*models.UploadSessionDetail, error) {
+	if err := normalizeUploadInit(&init); err != nil {
+		return nil, err
+	}
+
+	fileKey, storageUploadID, err := s.media.CreateMultipartUpload(init.FileName, init.ContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	session := newUploadSession(userID, init, fileKey, storageUploadID)
+	if err := s.create(session); err != nil {
+		return nil, err
+	}
+
+	detail := session.ToUploadSessionDetail(nil)
+	return &detail, nil
+}
+
+// CreateDirectSession 创建直传会话。整个文件一次上传时直接返回预签名地址，
+// 分片上传时在存储上创建分片上传，分片地址通过PresignParts获取
+func (s *uploadService) CreateDirectSession(userID string, init models.UploadSessionInit) (*models.DirectUploadDetail, error) {
+	if err := normalizeUploadInit(&init); err != nil {
+		return nil, err
+	}
+
+	var session *models.UploadSession
+	var uploadURL *models.PresignedURL
+	if init.Multipart || init.FileSize > maxSinglePutSize {
+		fileKey, storageUploadID, err := s.media.CreateMultipartUpload(init.FileName, init.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		session = newUploadSession(userID, init, fileKey, storageUploadID)
+	} else {
+		session = newUploadSession(userID, init, s.media.NewFileKey(init.FileName, init.ContentType), "")
+		// 整个文件作为唯一的分片
+		session.PartSize = session.FileSize
+
+		// 先签名再保存会话，签名失败时不会留下无法使用的会话
+		var err error
+		if uploadURL, err = s.presign(session, 1); err != nil {
+			return nil, err
+		}
+	}
+	session.Direct = true
+
+	if err := s.create(session); err != nil {
+		return nil, err
+	}
+
+	return &models.DirectUploadDetail{
+		UploadSessionDetail: session.ToUploadSessionDetail(nil),
+		UploadURL:           uploadURL,
+	}, nil
+}
+
+// create 保存会话，失败时取消存储上已创建的分片上传
+func (s *uploadService) create(session *models.UploadSession) error {
+	if err := s.repo.Create(session); err != nil {
+		if session.StorageUploadID != "" {
+			if abortErr := s.media.AbortMultipartUpload(session.FileKey, session.StorageUploadID); abortErr != nil {
+				logger.Error("Failed to abort orphaned multipart upload", zap.Error(abortErr), zap.String("fileKey", session.FileKey))
+			}
+		}
+		return err
+	}
+
+	logger.Info("Upload session created",
+		zap.String("sessionID", session.ID),
+		zap.String("userID", session.UploaderID),
+		zap.Bool("direct", session.Direct),
+		zap.Int64("fileSize", session.FileSize),
+		zap.Int("parts", session.TotalParts()))
+	return nil
+}
+
+// PresignParts 为直传会话的分片生成预签名地址，同时顺延会话的过期时间
+func (s *uploadService) PresignParts(id string, userID string, partNumbers []int) ([]models.PresignedURL, error) {
+	session, err := s.getActiveSession(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !session.Direct {
+		return nil, ErrUploadModeMismatch
+	}
+	if len(partNumbers) == 0 || len(partNumbers) > maxPresignParts {
+		return nil, ErrInvalidUploadPart
+	}
+
+	urls := make([]models.PresignedURL, 0, len(partNumbers))
+	for _, partNumber := range partNumbers {
+		if session.PartLength(partNumber) == 0 {
+			return nil, ErrInvalidUploadPart
+		}
+		url, err := s.presign(session, partNumber)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, *url)
+	}
+
+	if err := s.repo.Extend(session.ID, time.Now().Add(uploadSessionTTL)); err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
+
+// presign 生成分片的预签名地址，整个文件一次上传的会话签名的是对象本身
+func (s *uploadService) presign(session *models.UploadSession, partNumber int) (*models.PresignedURL, error) {
+	size := session.PartLength(partNumber)
+	expiresAt := time.Now().Add(presignExpiry)
+
+	var url string
+	var headers map[string]string
+	var err error
+	if session.StorageUploadID == "" {
+		url, headers, err = s.media.PresignPutObject(session.FileKey, session.ContentType, size, presignExpiry)
+	} else {
+		url, headers, err = s.media.PresignUploadPart(session.FileKey, session.StorageUploadID, partNumber, size, presignExpiry)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PresignedURL{
+		PartNumber: partNumber,
+		URL:        url,
+		Method:     http.MethodPut,
+		Headers:    headers,
+		Size:       size,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// uploadedParts 获取已上传的分片：经由服务器上传的分片记录在数据库中，
+// 直传的分片从存储查询，整个文件一次上传时文件存在即视为唯一的分片已上传
+func (s *uploadService) uploadedParts(session *models.UploadSession) ([]models.UploadPart, error) {
+	switch {
+	case !session.Direct:
+		return s.repo.GetParts(session.ID)
+	case session.StorageUploadID != "":
+		return s.media.ListParts(session.FileKey, session.StorageUploadID)
+	default:
+		size, exists, err := s.media.HeadMedia(session.FileKey)
+		if err != nil || !exists {
+			return nil, err
+		}
+		return []models.UploadPart{{SessionID: session.ID, PartNumber: 1, Size: size}}, nil
+	}
+}
+
+// GetSession 获取上传会话及上传进度
+func (s *uploadService) GetSession(id string, userID string) (*models.UploadSessionDetail, error) {
+	session, err := s.getOwnSession(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 已完成或已取消的会话在存储上已没有分片上传
+	var parts []models.UploadPart
+	if session.Status == models.UploadSessionActive {
+		if parts, err = s.uploadedParts(session); err != nil {
+			return nil, err
+		}
+	}
+
+	detail := session.ToUploadSessionDetail(parts)
+	return &detail, nil
+}
+
+// UploadPart 读取分片内容，校验大小和校验和后上传到存储
+func (s *uploadService) UploadPart(id string, userID string, partNumber int, body io.Reader, checksum string) (*models.UploadPart, error) {
+	session, err := s.getActiveSession(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Direct {
+		return nil, ErrUploadModeMismatch
+	}
+
+	expected := session.PartLength(partNumber)
+	if expected == 0 {
+		return nil, ErrInvalidUploadPart
+	}
+
+	// 多读一个字节以发现超出分片大小的请求体
+	data, err := io.ReadAll(io.LimitReader(body, expected+1))
+	if err != nil {
+		return nil, fmt.Errorf("read part body: %w", err)
+	}
+	if int64(len(data)) != expected {
+		return nil, ErrInvalidUploadPart
+	}
+
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+	if checksum != "" && !strings.EqualFold(checksum, actual) {
+		return nil, ErrChecksumMismatch
+	}
+
+	etag, err := s.media.UploadPart(session.FileKey, session.StorageUploadID, partNumber, data)
+	if err != nil {
+		return nil, err
+	}
+
+	part := &models.UploadPart{
+		SessionID:  session.ID,
+		PartNumber: partNumber,
+		Size:       expected,
+		Checksum:   actual,
+		ETag:       etag,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.repo.SavePart(part, time.Now().Add(uploadSessionTTL)); err != nil {
+		return nil, err
+	}
+	return part, nil
+}
+
+// Complete 确认所有分片都已上传后合并分片，并创建媒体加入处理队列。整个文件直传时无需合并。
+// 会话已完成时可以重试，用于合并分片后创建媒体失败的情况
+func (s *uploadService) Complete(id string, userID string) (*models.Media, error) {
+	session, err := s.getOwnSession(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == models.UploadSessionCompleted && session.MediaID != nil {
+		return s.resumeComplete(session)
+	}
+	if session.Status != models.UploadSessionActive || time.Now().After(session.ExpiresAt) {
+		return nil, ErrUploadClosed
+	}
+
+	// 直传时通过HeadObject或ListParts确认文件已上传到存储
+	parts, err := s.uploadedParts(session)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) != session.TotalParts() {
+		return nil, ErrUploadIncomplete
+	}
+	for i, part := range parts {
+		if part.PartNumber != i+1 || part.Size != session.PartLength(part.PartNumber) {
+			return nil, ErrUploadIncomplete
+		}
+	}
+
+	// 先将会话标记为完成，保证并发的完成请求只会创建一个媒体
+	mediaID := uuid.New().String()
+	ok, err := s.repo.Finish(session.ID, models.UploadSessionCompleted, &mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUploadClosed
+	}
+
+	fileURL := s.media.FileURL(session.FileKey)
+	if session.StorageUploadID != "" {
+		if fileURL, err = s.media.CompleteMultipartUpload(session.FileKey, session.StorageUploadID, parts); err != nil {
+			// 合并失败时恢复会话，客户端可以重试
+			if reopenErr := s.repo.Reopen(session.ID); reopenErr != nil {
+				logger.Error("Failed to reopen upload session", zap.Error(reopenErr), zap.String("sessionID", session.ID))
+			}
+			return nil, err
+		}
+	}
+
+	if err := s.repo.DeleteParts(session.ID); err != nil {
+		logger.Error("Failed to delete upload parts", zap.Error(err), zap.String("sessionID", session.ID))
+	}
+
+	media, err := s.submitMedia(session, mediaID, fileURL)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Upload session completed", zap.String("sessionID", session.ID), zap.String("mediaID", mediaID))
+	return media, nil
+}
+
+// resumeComplete 处理已完成会话的完成请求。媒体已创建时直接返回；
+// 上次合并分片后创建媒体失败时，使用会话记录的媒体ID重新创建，避免合并后的文件没有对应的媒体
+func (s *uploadService) resumeComplete(session *models.UploadSession) (*models.Media, error) {
+	media, err := s.media.GetMediaByID(*session.MediaID)
+	if err != nil {
+		return nil, err
+	}
+	if media != nil {
+		return media, nil
+	}
+
+	// 文件已不在存储中（如媒体创建后又被删除）时无法恢复
+	_, exists, err := s.media.HeadMedia(session.FileKey)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrUploadClosed
+	}
+
+	media, err = s.submitMedia(session, *session.MediaID, s.media.FileURL(session.FileKey))
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Upload session completion resumed", zap.String("sessionID", session.ID), zap.String("mediaID", media.ID))
+	return media, nil
+}
+
+// submitMedia 根据会话创建媒体并加入处理队列
+func (s *uploadService) submitMedia(session *models.UploadSession, mediaID string, fileURL string) (*models.Media, error) {
+	media := &models.Media{
+		ID:          mediaID,
+		UploaderID:  session.UploaderID,
+		Title:       session.Title,
+		Description: session.Description,
+		FileName:    session.FileName,
+		FileKey:     session.FileKey,
+		FileURL:     fileURL,
+		FileSize:    session.FileSize,
+		ContentType: session.ContentType,
+		MediaType:   session.MediaType,
+		Category:    session.Category,
+	}
+	if err := s.processing.Submit(media); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// Abort 取消上传
+func (s *uploadService) Abort(id string, userID string) error {
+	session, err := s.getActiveSession(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.abort(session)
+}
+
+// AbortExpired 分批取消过期的上传会话
+func (s *uploadService) AbortExpired() (int, error) {
+	total := 0
+	for {
+		sessions, err := s.repo.GetExpired(time.Now(), uploadCleanupBatchSize)
+		if err != nil {
+			return total, err
+		}
+
+		for i := range sessions {
+			if err := s.abort(&sessions[i]); err != nil {
+				// 已被并发请求完成或取消的会话不计数
+				if errors.Is(err, ErrUploadClosed) {
+					continue
+				}
+				return total, err
+			}
+			total++
+		}
+
+		if len(sessions) < uploadCleanupBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// StartCleanupJob 启动后台任务，启动时和之后每小时取消一次过期会话
+func (s *uploadService) StartCleanupJob() {
+	go func() {
+		ticker := time.NewTicker(uploadCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			count, err := s.AbortExpired()
+			if err != nil {
+				logger.Error("Failed to abort expired upload sessions", zap.Error(err))
+			} else if count > 0 {
+				logger.Info("Aborted expired upload sessions", zap.Int("count", count))
+			}
+			<-ticker.C
+		}
+	}()
+
+	logger.Info("Upload session cleanup started", zap.Duration("ttl", uploadSessionTTL))
+}
+
+// abort 先将会话标记为已取消，再取消存储上的分片上传或删除直传的文件，并删除分片记录。
+// 存储上取消失败时只记录日志，未完成的分片上传会由存储的生命周期规则清理
+func (s *uploadService) abort(session *models.UploadSession) error {
+	ok, err := s.repo.Finish(session.ID, models.UploadSessionAborted, nil)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUploadClosed
+	}
+
+	if session.StorageUploadID != "" {
+		if err := s.media.AbortMultipartUpload(session.FileKey, session.StorageUploadID); err != nil {
+			logger.Error("Failed to abort multipart upload", zap.Error(err), zap.String("sessionID", session.ID))
+		}
+	} else if session.Direct {
+		// 整个文件直传的会话可能已经上传了文件
+		if err := s.media.DeleteMedia(session.FileKey); err != nil {
+			logger.Error("Failed to delete directly uploaded file", zap.Error(err), zap.String("sessionID", session.ID))
+		}
+	}
+	if err := s.repo.DeleteParts(session.ID); err != nil {
+		logger.Error("Failed to delete upload parts", zap.Error(err), zap.String("sessionID", session.ID))
+	}
+	return nil
+}
+
+// getOwnSession 获取当前用户的上传会话，会话不存在或属于其他用户时返回ErrUploadNotFound
+func (s *uploadService) getOwnSession(id string, userID string) (*models.UploadSession, error) {
+	session, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UploaderID != userID {
+		return nil, ErrUploadNotFound
+	}
+	return session, nil
+}
+
+// getActiveSession 获取当前用户仍可上传的会话，已完成、已取消或已过期时返回ErrUploadClosed
+func (s *uploadService) getActiveSession(id string, userID string) (*models.UploadSession, error) {
+	session, err := s.getOwnSession(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.UploadSessionActive || time.Now().After(session.ExpiresAt) {
+		return nil, ErrUploadClosed
+	}
+	return session, nil
+}